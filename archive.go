@@ -0,0 +1,130 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Combined size of the unpacked entries, so a zip bomb fails instead of
+// filling the disk
+const maxArchiveSize = 512 << 20
+
+// unpacker writes the regular files of an archive below dir
+type unpacker struct {
+	dir     string
+	written int64
+}
+
+// extractArchive unpacks the regular files of the .zip, .tar, .tar.gz or .tgz
+// archive at file into a new temporary directory and returns it, so the
+// entries are collected with their archive-relative paths. The caller removes
+// the directory.
+func extractArchive(file string) (string, error) {
+	dir, err := os.MkdirTemp("", "clip4llm-archive-")
+	if err != nil {
+		return "", err
+	}
+	if err := (&unpacker{dir: dir}).unpack(file); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to unpack %s: %w", file, err)
+	}
+	return dir, nil
+}
+
+// unpack writes the regular files of the archive at file, picking the format
+// by its extension
+func (u *unpacker) unpack(file string) error {
+	name := strings.ToLower(file)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		archive, err := zip.OpenReader(file)
+		if err != nil {
+			return err
+		}
+		defer archive.Close()
+		for _, f := range archive.File {
+			if !f.Mode().IsRegular() {
+				continue
+			}
+			r, err := f.Open()
+			if err != nil {
+				return err
+			}
+			err = u.write(f.Name, r)
+			r.Close()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"), strings.HasSuffix(name, ".tar"):
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		var r io.Reader = f
+		if !strings.HasSuffix(name, ".tar") {
+			gz, err := gzip.NewReader(f)
+			if err != nil {
+				return err
+			}
+			defer gz.Close()
+			r = gz
+		}
+		archive := tar.NewReader(r)
+		for {
+			header, err := archive.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if header.Typeflag == tar.TypeReg {
+				if err := u.write(header.Name, archive); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return fmt.Errorf("unsupported archive type (expected .zip, .tar, .tar.gz or .tgz)")
+}
+
+// write stores the entry named name below dir. Names are cleaned as if rooted
+// so ../ entries cannot escape dir.
+func (u *unpacker) write(name string, r io.Reader) error {
+	name = path.Clean("/" + strings.ReplaceAll(name, `\`, "/"))
+	if name == "/" {
+		return nil
+	}
+	target := filepath.Join(u.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	written, err := io.Copy(out, io.LimitReader(r, maxArchiveSize-u.written+1))
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	u.written += written
+	if u.written > maxArchiveSize {
+		return fmt.Errorf("unpacked entries exceed %d MB", maxArchiveSize>>20)
+	}
+	return nil
+}
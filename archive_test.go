@@ -0,0 +1,64 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractArchive(t *testing.T) {
+	src := t.TempDir()
+	entries := map[string]string{"src/main.go": "package main\n", "../escape.txt": "nope\n"}
+
+	zipPath := filepath.Join(src, "release.zip")
+	zf, err := os.Create(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(zf)
+	for name, content := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	zf.Close()
+
+	tarPath := filepath.Join(src, "release.tgz")
+	tf, err := os.Create(tarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(tf)
+	tw := tar.NewWriter(gz)
+	for name, content := range entries {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	tf.Close()
+
+	for _, archive := range []string{zipPath, tarPath} {
+		dir, err := extractArchive(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		if content, err := os.ReadFile(filepath.Join(dir, "src", "main.go")); err != nil || string(content) != "package main\n" {
+			t.Errorf("%s: src/main.go = %q, %v", archive, content, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "escape.txt")); err != nil {
+			t.Errorf("%s: ../escape.txt should land inside the directory: %v", archive, err)
+		}
+	}
+
+	if _, err := extractArchive(filepath.Join(src, "release.rar")); err == nil {
+		t.Error("expected an error for an unsupported archive")
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// clipboardDiagnosis explains why writing to the clipboard failed on this
+// system and how to fix it, in place of the clipboard library's generic error.
+func clipboardDiagnosis(err error, verbose bool) string {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, lookErr := exec.LookPath("pbcopy"); lookErr != nil {
+			return "pbcopy was not found in PATH; it ships with macOS, check that /usr/bin is on your PATH"
+		}
+		return fmt.Sprintf("pbcopy failed: %v", err)
+
+	case "windows":
+		return fmt.Sprintf("the Windows clipboard could not be opened, another program may be holding it: %v", err)
+	}
+
+	wayland := os.Getenv("WAYLAND_DISPLAY")
+	display := os.Getenv("DISPLAY")
+	termux := os.Getenv("TERMUX_VERSION") != ""
+
+	available := make(map[string]bool)
+	for _, tool := range []string{"wl-copy", "xclip", "xsel", "termux-clipboard-set"} {
+		if _, lookErr := exec.LookPath(tool); lookErr == nil {
+			available[tool] = true
+		}
+	}
+
+	if verbose {
+		var tools []string
+		for tool := range available {
+			tools = append(tools, tool)
+		}
+		fmt.Printf("Clipboard environment: WAYLAND_DISPLAY=%q DISPLAY=%q Termux=%v tools=%v\n", wayland, display, termux, tools)
+	}
+
+	switch {
+	case termux:
+		if !available["termux-clipboard-set"] {
+			return "termux-clipboard-set was not found; install the Termux:API app and run: pkg install termux-api"
+		}
+	case wayland != "":
+		if !available["wl-copy"] {
+			return "wl-copy was not found for the Wayland session; install it with: " + installHint("wl-clipboard")
+		}
+	case display != "":
+		if !available["xclip"] && !available["xsel"] {
+			return "neither xclip nor xsel was found for the X11 session; install one with: " + installHint("xclip")
+		}
+	default:
+		return "no graphical session was found (DISPLAY and WAYLAND_DISPLAY are unset), as over SSH or in a container; " +
+			"use --stdout or --output instead"
+	}
+
+	return fmt.Sprintf("the clipboard tool failed: %v", err)
+}
+
+// installHint returns the command that installs pkg with the system's package manager
+func installHint(pkg string) string {
+	managers := []struct {
+		name    string
+		command string
+	}{
+		{"apt-get", "sudo apt-get install "},
+		{"dnf", "sudo dnf install "},
+		{"pacman", "sudo pacman -S "},
+		{"zypper", "sudo zypper install "},
+		{"apk", "sudo apk add "},
+		{"brew", "brew install "},
+	}
+	for _, manager := range managers {
+		if _, err := exec.LookPath(manager.name); err == nil {
+			return manager.command + pkg
+		}
+	}
+	return "your package manager (package " + pkg + ")"
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Magic numbers opening the compressed snapshots read back by --from
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// compressContent compresses content with method, gzip or zstd, and returns
+// it unchanged when method is empty
+func compressContent(content []byte, method string) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch method {
+	case "":
+		return content, nil
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zstd":
+		var err error
+		if w, err = zstd.NewWriter(&buf); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid --compress %q (expected gzip or zstd)", method)
+	}
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeOutputFile writes content to path, compressed as dest asks
+func writeOutputFile(path string, content string, dest *delivery) error {
+	data, err := compressContent([]byte(content), dest.compress)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// readSnapshot returns the content of an output file written earlier, which
+// is decompressed when it starts with the gzip or zstd magic number
+func readSnapshot(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var r io.Reader
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", fmt.Errorf("%s is not a valid gzip file: %v", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	case bytes.HasPrefix(data, zstdMagic):
+		zr, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", err
+		}
+		defer zr.Close()
+		r = zr
+	default:
+		return string(data), nil
+	}
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress %s: %v", path, err)
+	}
+	return string(content), nil
+}
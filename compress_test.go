@@ -0,0 +1,27 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCompressedSnapshotRoundTrip(t *testing.T) {
+	content := "```\n./main.go\npackage main\n```\n"
+	for _, method := range []string{"", "gzip", "zstd"} {
+		path := filepath.Join(t.TempDir(), "snapshot")
+		if err := writeOutputFile(path, content, &delivery{compress: method}); err != nil {
+			t.Fatalf("%q: %v", method, err)
+		}
+		got, err := readSnapshot(path)
+		if err != nil {
+			t.Fatalf("%q: %v", method, err)
+		}
+		if got != content {
+			t.Errorf("%q: readSnapshot = %q, want %q", method, got, content)
+		}
+	}
+
+	if _, err := compressContent([]byte(content), "brotli"); err == nil {
+		t.Error("expected an error for an unknown method")
+	}
+}
@@ -8,9 +8,19 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/UnitVectorY-Labs/clip4llm/pkg/clip4llm"
 )
 
+// configVariable matches a ${VAR} reference to an environment variable in a
+// config value. The bare $VAR form is left alone as regular expressions in
+// redact: rules use $ for the end of the line.
+var configVariable = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
 // Helper function to find and load the .clip4llm file from home or current directory
 func loadConfig(verbose bool) map[string]string {
 	config := make(map[string]string)
@@ -32,14 +42,80 @@ func loadConfig(verbose bool) map[string]string {
 		if verbose {
 			log.Printf("Error getting current directory: %v", err)
 		}
-	} else {
+	} else if currentDir != homeDir {
+		// The project config arrives with whatever was cloned or unpacked, so
+		// it can only select and render the files of the directory
 		currentConfigPath := filepath.Join(currentDir, ".clip4llm")
-		loadConfigFromFile(currentConfigPath, config, verbose)
+		project := make(map[string]string)
+		loadConfigFromFile(currentConfigPath, project, verbose)
+		for key, value := range dropUntrustedKeys(project, currentConfigPath) {
+			config[key] = value
+		}
 	}
 
 	return config
 }
 
+// Keys a .clip4llm in the working directory can set: those choosing among
+// the files of the directory and shaping how they are rendered. Everything
+// else may run commands, connect to databases, read or write files outside
+// of the directory, change where the output goes or lift a guard, and only
+// works in ~/.clip4llm and on the command line.
+var trustedConfigKeys = map[string]bool{
+	// Selection
+	"include": true, "exclude": true, "max-size": true, "max-depth": true, "depth-guard": true,
+	"truncate": true, "max-total-size": true, "grep": true, "grep-context": true, "go-tags": true,
+	"with-tests": true, "resolve-includes": true, "resolve-depth": true, "resolve-budget": true,
+	"entry": true, "py-module": true, "route": true, "diff-base": true, "budget": true,
+	"symbol": true, "rename-to": true, "max-tokens": true, "max-tokens-action": true,
+	"i18n": true, "i18n-default": true, "include-generated": true, "git-tracked": true,
+	"hidden": true, "git-diff": true, "infra": true, "owner": true, "not-owner": true,
+	// Rendering
+	"delimiter": true, "format": true, "tokenizer": true, "decode-descriptors": true,
+	"strip-comments": true, "extract-email": true, "strip-front-matter": true,
+	"keep-front-matter": true, "image-placeholders": true, "metadata": true, "raw": true,
+	"blame-summary": true, "compact": true, "compact-indent": true, "sort": true,
+	"sort-reverse": true, "todos": true, "diff-mode": true, "tree": true, "tree-empty": true,
+	"tree-labels": true, "deps-summary": true, "build-targets": true, "redact-secrets": true,
+}
+
+// Prefixes of the trusted keys that are not flags
+var trustedConfigPrefixes = []string{redactConfigPrefix, languageConfigPrefix}
+
+// isTrustedConfigKey reports whether a .clip4llm in the working directory
+// can set the key, the key of a [section] without its section
+func isTrustedConfigKey(name string) bool {
+	if trustedConfigKeys[name] {
+		return true
+	}
+	for _, prefix := range trustedConfigPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropUntrustedKeys returns the config loaded from path with only the keys it
+// can set, including within its [sections], warning about each other key
+func dropUntrustedKeys(config map[string]string, path string) map[string]string {
+	kept := make(map[string]string, len(config))
+	for key, value := range config {
+		name := key
+		if strings.HasPrefix(key, "[") {
+			if _, after, ok := strings.Cut(key, "]"); ok {
+				name = after
+			}
+		}
+		if !isTrustedConfigKey(name) {
+			fmt.Fprintf(os.Stderr, "Warning: ignoring %s in %s, set it in ~/.clip4llm or on the command line\n", key, path)
+			continue
+		}
+		kept[key] = value
+	}
+	return kept
+}
+
 // Helper function to load configuration from a file and add to the config map
 func loadConfigFromFile(path string, config map[string]string, verbose bool) {
 	if verbose {
@@ -61,18 +137,24 @@ func loadConfigFromFile(path string, config map[string]string, verbose bool) {
 	defer file.Close()
 
 	scanner := bufio.NewScanner(file)
+	profile := ""
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" || strings.HasPrefix(line, "#") {
 			// Skip empty lines and comments
 			continue
 		}
+		// A [name] line starts the keys of a profile or pattern, up to the next one
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			profile = configSection(strings.TrimSpace(line[1:len(line)-1]), path)
+			continue
+		}
 		// Expect lines in the format "key=value"
 		parts := strings.SplitN(line, "=", 2)
 		if len(parts) == 2 {
 			key := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-			config[key] = value
+			value := expandConfigValue(strings.TrimSpace(parts[1]), verbose)
+			config[profileKey(profile, key)] = value
 		}
 	}
 
@@ -82,3 +164,191 @@ func loadConfigFromFile(path string, config map[string]string, verbose bool) {
 		}
 	}
 }
+
+// profileKey returns the config key under which the key of the named profile
+// is stored, the key itself outside of any profile
+func profileKey(profile, key string) string {
+	if profile == "" {
+		return key
+	}
+	return "[" + profile + "]" + key
+}
+
+// selectProfile returns the configuration without its profiles, overlaid
+// with the keys of the named profile when name is set
+func selectProfile(config map[string]string, name string) (map[string]string, error) {
+	selected := make(map[string]string)
+	found := false
+	prefix := profileKey(name, "")
+	for key, value := range config {
+		if !strings.HasPrefix(key, "[") {
+			if _, ok := selected[key]; !ok {
+				selected[key] = value
+			}
+			continue
+		}
+		if name != "" && strings.HasPrefix(key, prefix) {
+			selected[strings.TrimPrefix(key, prefix)] = value
+			found = true
+		}
+	}
+	if name != "" && !found {
+		return nil, fmt.Errorf("profile %q is not defined in any .clip4llm file", name)
+	}
+	return selected, nil
+}
+
+// Prefix of the stored name of a [pattern] section, which no profile has
+const patternSection = "pattern "
+
+// configSection returns the stored name of a [header] section of the config
+// file at path. [profile name] and [pattern glob] say what the section is, a
+// bare [name] is a file pattern when it holds a *, ? or . and a profile
+// otherwise. A bare name with a dot alone, such as [v1.2] or [go.sum], is
+// read as a pattern with a warning, as it may well be meant as a profile.
+func configSection(header string, path string) string {
+	if name, ok := strings.CutPrefix(header, "profile "); ok {
+		return strings.TrimSpace(name)
+	}
+	if glob, ok := strings.CutPrefix(header, patternSection); ok {
+		return patternSection + strings.TrimSpace(glob)
+	}
+	if !strings.ContainsAny(header, "*?.") {
+		return header
+	}
+	if !strings.ContainsAny(header, "*?") {
+		fmt.Fprintf(os.Stderr, "Warning: reading [%s] in %s as a file pattern, write [pattern %s] or [profile %s] to say which\n", header, path, header, header)
+	}
+	return patternSection + header
+}
+
+// fileOverrides returns the exclude patterns and size limits of the [pattern]
+// sections of the configuration. When several patterns match a file the
+// longest, most specific one wins.
+func fileOverrides(config map[string]string, verbose bool) ([]string, []clip4llm.SizeLimit) {
+	var patterns []string
+	seen := make(map[string]bool)
+	for key := range config {
+		section, ok := strings.CutPrefix(key, "["+patternSection)
+		pattern, _, closed := strings.Cut(section, "]")
+		if !ok || !closed || seen[pattern] {
+			continue
+		}
+		seen[pattern] = true
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+
+	var excludes []string
+	var limits []clip4llm.SizeLimit
+	for _, pattern := range patterns {
+		if value, ok := config[profileKey(patternSection+pattern, "exclude")]; ok {
+			if excluded, err := strconv.ParseBool(value); err == nil && excluded {
+				excludes = append(excludes, pattern)
+			} else if err != nil && verbose {
+				fmt.Printf("Ignoring invalid config value for [%s] exclude: %s\n", pattern, value)
+			}
+		}
+		if value, ok := config[profileKey(patternSection+pattern, "max-size")]; ok {
+			if size, err := strconv.Atoi(value); err == nil && size > 0 {
+				limits = append(limits, clip4llm.SizeLimit{Pattern: pattern, MaxSize: size})
+			} else if verbose {
+				fmt.Printf("Ignoring invalid config value for [%s] max-size: %s\n", pattern, value)
+			}
+		}
+	}
+	return excludes, limits
+}
+
+// Prefix of the config keys setting the language of files as language:<pattern>=<language>
+const languageConfigPrefix = "language:"
+
+// languageOverrides returns the language:<pattern> entries of the
+// configuration. As for [pattern] sections, the longest, most specific
+// pattern matching a file wins.
+func languageOverrides(config map[string]string) []clip4llm.LanguageOverride {
+	var overrides []clip4llm.LanguageOverride
+	for key, language := range config {
+		if pattern, ok := strings.CutPrefix(key, languageConfigPrefix); ok && pattern != "" && language != "" {
+			overrides = append(overrides, clip4llm.LanguageOverride{Pattern: pattern, Language: language})
+		}
+	}
+	sort.Slice(overrides, func(i, j int) bool {
+		if len(overrides[i].Pattern) != len(overrides[j].Pattern) {
+			return len(overrides[i].Pattern) > len(overrides[j].Pattern)
+		}
+		return overrides[i].Pattern < overrides[j].Pattern
+	})
+	return overrides
+}
+
+// expandConfigValue replaces the ${VAR} references in value with the values
+// of the environment variables, an unset variable expands to nothing
+func expandConfigValue(value string, verbose bool) string {
+	return configVariable.ReplaceAllStringFunc(value, func(ref string) string {
+		name := configVariable.FindStringSubmatch(ref)[1]
+		expanded, ok := os.LookupEnv(name)
+		if !ok && verbose {
+			fmt.Printf("Config references unset environment variable %s\n", name)
+		}
+		return expanded
+	})
+}
+
+// configPaths returns the .clip4llm files loadConfig reads, watched by
+// --watch to reload the configuration when one of them changes
+func configPaths() []string {
+	var paths []string
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(homeDir, ".clip4llm"))
+	}
+	if currentDir, err := os.Getwd(); err == nil {
+		paths = append(paths, filepath.Join(currentDir, ".clip4llm"))
+	}
+	return paths
+}
+
+// configChanges describes every key whose value differs between the configuration
+// before and after a reload as "key: old → new", sorted by key
+func configChanges(before map[string]string, after map[string]string) []string {
+	show := func(config map[string]string, key string) string {
+		value, ok := config[key]
+		if !ok {
+			return "(unset)"
+		}
+		return strconv.Quote(value)
+	}
+	var changes []string
+	for key := range before {
+		if value, ok := after[key]; !ok || value != before[key] {
+			changes = append(changes, key)
+		}
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			changes = append(changes, key)
+		}
+	}
+	sort.Strings(changes)
+	for i, key := range changes {
+		changes[i] = fmt.Sprintf("%s: %s → %s", key, show(before, key), show(after, key))
+	}
+	return changes
+}
+
+// printConfigChanges reports a reloaded configuration with what changed
+func printConfigChanges(changes []string) {
+	if len(changes) == 0 {
+		fmt.Println("Configuration reloaded, no settings changed")
+		return
+	}
+	fmt.Println("Configuration reloaded:")
+	for _, change := range changes {
+		fmt.Printf("\t%s\n", change)
+	}
+}
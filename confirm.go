@@ -0,0 +1,30 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// confirmLargeOutput asks on the terminal whether output over the threshold
+// should really be delivered, showing what it contains.
+func confirmLargeOutput(output string, fileCount int) (bool, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, fmt.Errorf("output of %.1f KB exceeds --confirm-over and there is no terminal to confirm it", float64(len(output))/1024)
+	}
+
+	// Prompt on stderr so a --stdout pipe stays clean
+	fmt.Fprintf(os.Stderr, "Output is %.1f KB (%d files, ~%d tokens). Continue? [y/N] ",
+		float64(len(output))/1024, fileCount, estimateTokens(output))
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
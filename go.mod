@@ -2,4 +2,13 @@ module github.com/UnitVectorY-Labs/clip4llm
 
 go 1.23.5
 
-require github.com/atotto/clipboard v0.1.4
+require (
+	github.com/atotto/clipboard v0.1.4
+	github.com/fsnotify/fsnotify v1.9.0
+	github.com/klauspost/compress v1.18.0
+	golang.org/x/term v0.28.0
+	golang.org/x/text v0.21.0
+	google.golang.org/protobuf v1.36.5
+)
+
+require golang.org/x/sys v0.29.0 // indirect
@@ -0,0 +1,152 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/UnitVectorY-Labs/clip4llm/pkg/clip4llm"
+)
+
+const (
+	// A run is large enough for exclude suggestions over either of these
+	hintFiles  = 500
+	hintTokens = 100000
+
+	// Share of the tokens a directory or file needs to be worth excluding,
+	// and the share above which excluding it would leave too little
+	hintMinShare = 0.25
+	hintMaxShare = 0.95
+
+	// Suggestions printed per run, and how often per directory
+	hintLimit    = 3
+	hintInterval = 24 * time.Hour
+)
+
+// isLargeRun reports whether a run is large enough to suggest excludes
+func isLargeRun(files int, tokens int) bool {
+	return files > hintFiles || tokens > hintTokens
+}
+
+// excludeHints returns suggestions naming the directories and files holding
+// a large share of the tokens of the run, with the exclude pattern leaving
+// them out. A directory whose tokens mostly come from a single directory or
+// file inside it gives way to that one, as it is the more precise exclude.
+func excludeHints(report clip4llm.Report) []string {
+	total := 0
+	tokens := make(map[string]int)
+	for _, file := range report.Files {
+		path := strings.TrimPrefix(file.Path, "./")
+		total += file.Tokens
+		tokens[path] += file.Tokens
+		for dir := filepath.ToSlash(filepath.Dir(path)); dir != "."; dir = filepath.ToSlash(filepath.Dir(dir)) {
+			tokens[dir+"/"] += file.Tokens
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	// Directories come before what they hold, as they have at least as many tokens
+	var candidates []string
+	for path, count := range tokens {
+		if share := float64(count) / float64(total); share >= hintMinShare && share < hintMaxShare {
+			candidates = append(candidates, path)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if tokens[candidates[i]] != tokens[candidates[j]] {
+			return tokens[candidates[i]] > tokens[candidates[j]]
+		}
+		return len(candidates[i]) < len(candidates[j])
+	})
+
+	var chosen []string
+	for _, path := range candidates {
+		if insideAny(path, chosen) || dominatedBy(path, candidates, tokens) {
+			continue
+		}
+		chosen = append(chosen, path)
+	}
+
+	var hints []string
+	for _, path := range chosen[:min(len(chosen), hintLimit)] {
+		// A top level name matches anywhere, a nested one needs its path
+		pattern := strings.TrimSuffix(path, "/")
+		hints = append(hints, fmt.Sprintf("%s contributed %d%% of the tokens; add exclude=%s to .clip4llm or pass --exclude=%s",
+			path, tokens[path]*100/total, pattern, pattern))
+	}
+	return hints
+}
+
+// insideAny reports whether path lies inside one of the directories
+func insideAny(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		if strings.HasSuffix(dir, "/") && strings.HasPrefix(path, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// dominatedBy reports whether the directory path has a candidate inside it
+// holding nearly all of its tokens
+func dominatedBy(path string, candidates []string, tokens map[string]int) bool {
+	if !strings.HasSuffix(path, "/") {
+		return false
+	}
+	for _, other := range candidates {
+		if other != path && strings.HasPrefix(other, path) && tokens[other]*10 >= tokens[path]*9 {
+			return true
+		}
+	}
+	return false
+}
+
+// hintsPath returns the location of the file recording when suggestions were
+// last shown for each directory
+func hintsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".clip4llm_hints.json"), nil
+}
+
+// hintsDue reports whether suggestions for dir were not shown within the
+// last hintInterval, and records them as shown now when they are due
+func hintsDue(dir string, now time.Time) bool {
+	path, err := hintsPath()
+	if err != nil {
+		return true
+	}
+	shown := make(map[string]time.Time)
+	if content, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(content, &shown)
+	}
+	if now.Sub(shown[dir]) < hintInterval {
+		return false
+	}
+	shown[dir] = now
+	if content, err := json.Marshal(shown); err == nil {
+		os.WriteFile(path, content, 0600)
+	}
+	return true
+}
+
+// printHints prints the exclude suggestions for a large run
+func printHints(hints []string) {
+	if len(hints) == 0 {
+		return
+	}
+	fmt.Println("This run is large, some excludes could trim it down:")
+	for _, hint := range hints {
+		fmt.Printf("\t%s\n", hint)
+	}
+}
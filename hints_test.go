@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UnitVectorY-Labs/clip4llm/pkg/clip4llm"
+)
+
+func TestExcludeHints(t *testing.T) {
+	report := clip4llm.Report{Files: []clip4llm.ReportFile{
+		{Path: "./main.go", Tokens: 100},
+		{Path: "./web/app.js", Tokens: 50},
+		{Path: "./web/node_modules/react/index.js", Tokens: 600},
+		{Path: "./web/node_modules/lodash/lodash.js", Tokens: 150},
+		{Path: "./fixtures.json", Tokens: 100},
+	}}
+	hints := excludeHints(report)
+	if len(hints) != 1 || hints[0] != "web/node_modules/ contributed 75% of the tokens; add exclude=web/node_modules to .clip4llm or pass --exclude=web/node_modules" {
+		t.Errorf("hints = %q, want only web/node_modules", hints)
+	}
+
+	report.Files = report.Files[:2]
+	if hints := excludeHints(report); len(hints) != 2 || !strings.HasPrefix(hints[0], "main.go contributed 66%") || !strings.HasPrefix(hints[1], "web/app.js contributed 33%") {
+		t.Errorf("hints = %q, want main.go then web/app.js", hints)
+	}
+}
+
+func TestHintsDue(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	now := time.Now()
+	if !hintsDue("/src/app", now) {
+		t.Error("hints should be due on the first large run")
+	}
+	if _, err := os.Stat(filepath.Join(home, ".clip4llm_hints.json")); err != nil {
+		t.Errorf("hints were not recorded in the home directory: %v", err)
+	}
+	if hintsDue("/src/app", now.Add(time.Hour)) {
+		t.Error("hints should not repeat within a day")
+	}
+	if !hintsDue("/src/other", now.Add(time.Hour)) {
+		t.Error("hints are rate limited per directory")
+	}
+	if !hintsDue("/src/app", now.Add(25*time.Hour)) {
+		t.Error("hints should be due again after a day")
+	}
+}
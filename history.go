@@ -0,0 +1,195 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// historyEntry is a recorded invocation that can be repeated with rerun
+type historyEntry struct {
+	Time   time.Time `json:"time"`
+	Dir    string    `json:"dir"`
+	Args   []string  `json:"args"`
+	Files  int       `json:"files"`
+	Bytes  int       `json:"bytes"`
+	Tokens int       `json:"tokens"`
+}
+
+// chunkCache holds the parts of the most recent chunked run for the chunk
+// command
+type chunkCache struct {
+	Time   time.Time `json:"time"`
+	Dir    string    `json:"dir"`
+	Args   []string  `json:"args"`
+	Chunks []string  `json:"chunks"`
+}
+
+// historyPath returns the location of the history file in the home directory
+func historyPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".clip4llm_history.json"), nil
+}
+
+// loadHistory returns the recorded invocations, most recent first
+func loadHistory() ([]historyEntry, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var history []historyEntry
+	if err := json.Unmarshal(content, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return history, nil
+}
+
+// recordHistory adds an invocation to the front of the history, keeping at
+// most limit entries.
+func recordHistory(entry historyEntry, limit int) error {
+	history, err := loadHistory()
+	if err != nil {
+		return err
+	}
+	history = append([]historyEntry{entry}, history...)
+	if len(history) > limit {
+		history = history[:limit]
+	}
+
+	content, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0600)
+}
+
+// printHistory lists the recorded invocations numbered for rerun
+func printHistory() error {
+	history, err := loadHistory()
+	if err != nil {
+		return err
+	}
+	if len(history) == 0 {
+		fmt.Println("No recorded runs yet.")
+		return nil
+	}
+	for i, entry := range history {
+		fmt.Printf("%3d  %s  %s\n     clip4llm %s\n     %d files, %d bytes, ~%d tokens\n",
+			i+1, entry.Time.Local().Format("2006-01-02 15:04"), entry.Dir,
+			strings.Join(entry.Args, " "), entry.Files, entry.Bytes, entry.Tokens)
+	}
+	return nil
+}
+
+// rerunArgs returns the arguments of the nth most recent invocation (1 when
+// args is empty) after changing to the directory it was run in.
+func rerunArgs(args []string) ([]string, error) {
+	n := 1
+	if len(args) > 1 {
+		return nil, fmt.Errorf("usage: clip4llm rerun [n]")
+	}
+	if len(args) == 1 {
+		var err error
+		n, err = strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid history number %q", args[0])
+		}
+	}
+
+	history, err := loadHistory()
+	if err != nil {
+		return nil, err
+	}
+	if n > len(history) {
+		return nil, fmt.Errorf("no run #%d in the history (%d recorded)", n, len(history))
+	}
+
+	entry := history[n-1]
+	if err := os.Chdir(entry.Dir); err != nil {
+		return nil, err
+	}
+	// Flags are not parsed yet, so stay off stdout in case the run pipes its payload
+	fmt.Fprintf(os.Stderr, "Rerunning in %s: clip4llm %s\n", entry.Dir, strings.Join(entry.Args, " "))
+	return entry.Args, nil
+}
+
+// chunkCachePath returns the location of the chunk cache in the home directory
+func chunkCachePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".clip4llm_chunks.json"), nil
+}
+
+// saveChunks replaces the cached parts with those of the run just made
+func saveChunks(cache chunkCache) error {
+	content, err := json.Marshal(cache)
+	if err != nil {
+		return err
+	}
+	path, err := chunkCachePath()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0600)
+}
+
+// chunkNumber parses the arguments of "clip4llm chunk <n>" and returns the
+// part number along with the flags that follow it
+func chunkNumber(args []string) (int, []string, error) {
+	if len(args) == 0 {
+		return 0, nil, fmt.Errorf("usage: clip4llm chunk <n> [flags]")
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, nil, fmt.Errorf("invalid part number %q", args[0])
+	}
+	return n, args[1:], nil
+}
+
+// loadChunk returns the nth part of the most recent chunked run
+func loadChunk(n int) (string, error) {
+	path, err := chunkCachePath()
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("no chunked run recorded yet, run with --chunk first")
+	}
+	if err != nil {
+		return "", err
+	}
+	var cache chunkCache
+	if err := json.Unmarshal(content, &cache); err != nil {
+		return "", fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	if n > len(cache.Chunks) {
+		return "", fmt.Errorf("no part %d in the last chunked run (%d parts)", n, len(cache.Chunks))
+	}
+	// Flags may send the payload to stdout, so stay off it
+	fmt.Fprintf(os.Stderr, "Part %d of %d from the run in %s at %s: clip4llm %s\n", n, len(cache.Chunks),
+		cache.Dir, cache.Time.Local().Format("2006-01-02 15:04"), strings.Join(cache.Args, " "))
+	return cache.Chunks[n-1], nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecordHistory(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if history, err := loadHistory(); err != nil || len(history) != 0 {
+		t.Fatalf("loadHistory = %v, %v, want no history yet", history, err)
+	}
+	for i, dir := range []string{"/src/a", "/src/b", "/src/c"} {
+		entry := historyEntry{Time: time.Now(), Dir: dir, Args: []string{"--tree"}, Files: i}
+		if err := recordHistory(entry, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := loadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Dir != "/src/c" || history[1].Dir != "/src/b" {
+		t.Errorf("history = %+v, want /src/c then /src/b", history)
+	}
+}
+
+func TestRerunArgs(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	for _, args := range [][]string{{"--tree"}, {"--stdout", "--include=*.md"}} {
+		if err := recordHistory(historyEntry{Time: time.Now(), Dir: dir, Args: args}, 20); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	args, err := rerunArgs([]string{"2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"--tree"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("rerunArgs = %v, want %v", args, want)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved, _ := filepath.EvalSymlinks(dir); cwd != resolved && cwd != dir {
+		t.Errorf("working directory = %s, want %s", cwd, dir)
+	}
+
+	for _, bad := range [][]string{{"3"}, {"0"}, {"one"}, {"1", "2"}} {
+		if _, err := rerunArgs(bad); err == nil {
+			t.Errorf("rerunArgs(%v) should fail", bad)
+		}
+	}
+}
+
+func TestChunkCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	if _, err := loadChunk(1); err == nil {
+		t.Error("expected an error before any chunked run")
+	}
+
+	cache := chunkCache{Time: time.Now(), Dir: "/src", Args: []string{"--chunk"}, Chunks: []string{"part one", "part two"}}
+	if err := saveChunks(cache); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := loadChunk(2); err != nil || got != "part two" {
+		t.Errorf("loadChunk(2) = %q, %v", got, err)
+	}
+	if _, err := loadChunk(3); err == nil {
+		t.Error("expected an error for a part past the last one")
+	}
+
+	n, rest, err := chunkNumber([]string{"2", "--stdout"})
+	if err != nil || n != 2 || len(rest) != 1 || rest[0] != "--stdout" {
+		t.Errorf("chunkNumber = %d, %v, %v", n, rest, err)
+	}
+	if _, _, err := chunkNumber([]string{"0"}); err == nil {
+		t.Error("expected an error for part 0")
+	}
+}
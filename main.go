@@ -8,260 +8,868 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"strconv"
+	"sort"
 	"strings"
+	"time"
 
-	"github.com/atotto/clipboard"
+	"github.com/UnitVectorY-Labs/clip4llm/pkg/clip4llm"
 )
 
-// Define the max total size limit in bytes (1MB = 1,048,576 bytes)
-const maxTotalSize = 1 * 1024 * 1024 // 1MB in bytes
-
 func main() {
 	// Define existing flags
 	delimiter := flag.String("delimiter", "```", "Set the delimiter for file content (default: ```)")
 	maxSize := flag.Int("max-size", 32, "Maximum file size to include in KB (default: 32 KB)")
+	maxDepth := flag.Int("max-depth", 0, "Directory levels to walk, 1 for only the files in the starting directory (0 walks everything)")
+	depthGuard := flag.Int("depth-guard", 256, "Directory levels walked before stopping with a warning, to survive runaway trees (0 for no guard)")
+	truncate := flag.String("truncate", "", "Include the start of files over --max-size instead of skipping them, up to a size or line count (e.g., 8kb or 200lines)")
+	maxTotalSize := flag.String("max-total-size", "1MB", "Maximum size of the whole output, e.g. 512kb or 4mb, unless --max-tokens is set (default: 1MB)")
 	verbose := flag.Bool("verbose", false, "Enable verbose logging")
 
 	// Define new flags for include and exclude with support for wildcards
 	include := flag.String("include", "", "Comma-separated list of patterns to include, even if hidden (e.g., .github,*.env)")
 	exclude := flag.String("exclude", "", "Comma-separated list of patterns to exclude (e.g., LICENSE,*.md)")
+	includeFrom := flag.String("include-from", "", "File listing patterns to include, one per line with # comments, ahead of --include")
+	excludeFrom := flag.String("exclude-from", "", "File listing patterns to exclude, one per line with # comments, ahead of --exclude")
 
-	flag.Parse()
+	// Define flag for selecting files by their content
+	grep := flag.String("grep", "", "Only include files whose content matches this regular expression (e.g., PaymentService)")
+	grepContext := flag.Int("grep-context", 0, "With --grep, include only this many lines around each match instead of whole files (0 keeps whole files)")
 
-	// Load configuration from .clip4llm files
-	config := loadConfig(*verbose)
+	// Define flag for Go build tag filtering
+	goTags := flag.String("go-tags", "", "Comma-separated build tags; Go files that would not build with them are skipped (e.g., linux,integration)")
 
-	// Determine if flags were set by the user
-	delimiterSet := false
-	maxSizeSet := false
-	includeSetFlag := false
-	excludeSetFlag := false
+	// Define flag for pairing source files with their tests
+	withTests := flag.Bool("with-tests", false, "Also include the conventionally named test file for each included source file and vice versa")
 
-	flag.Visit(func(f *flag.Flag) {
-		if f.Name == "delimiter" {
-			delimiterSet = true
+	// Define flags for resolving project-local C/C++ headers
+	resolveIncludes := flag.Bool("resolve-includes", false, "Also include project-local headers referenced by #include \"...\" in the C/C++ files named on the command line")
+	resolveDepth := flag.Int("resolve-depth", 3, "Maximum depth of nested #include directives to follow (default: 3)")
+	resolveBudget := flag.Int("resolve-budget", 256, "Maximum combined size in KB of headers added by --resolve-includes (default: 256 KB)")
+
+	// Define flag for selecting the import closure of JS/TS entry files
+	entry := flag.String("entry", "", "Comma-separated JS/TS entry files; only they and their transitive local imports are included (e.g., src/index.ts)")
+
+	// Define flag for selecting Python modules and their local imports
+	pyModule := flag.String("py-module", "", "Comma-separated dotted Python modules; only they and their intra-project imports are included (e.g., app.services.billing)")
+
+	// Define flag for selecting the files behind an HTTP route
+	route := flag.String("route", "", "Only include the files registering the route plus its handlers and middleware (e.g., /api/users)")
+
+	// Define flag for including a live database schema
+	dbSchema := flag.String("db-schema", "", "Include the schema DDL (no data) of a database: postgres://..., mysql://... or a SQLite file")
+
+	// Define flag for summarizing direct dependencies from the manifests
+	depsSummary := flag.Bool("deps-summary", false, "Include a compact list of direct dependencies from go.mod, package.json and requirements.txt")
+
+	// Define flag for summarizing the targets of the build files
+	buildTargets := flag.Bool("build-targets", false, "Include the targets of the Makefile, Taskfile and justfile with their comments, leaving out pattern and file rules")
+
+	// Define flag for the failing command captured by the bugreport command
+	execCommand := flag.String("exec", "", "Shell command whose output is captured in the bugreport command (e.g., \"go test ./...\")")
+
+	// Define flag for the base ref the review command compares against
+	diffBase := flag.String("diff-base", "", "Git ref the review command diffs against (default: main, or master if there is no main)")
+
+	// Define flag for the token budget the onboard command fits its selection to
+	budget := flag.Int("budget", 32000, "Estimated token budget the onboard command sizes its selection to (default: 32000)")
+
+	// Define flag for writing the output to a file instead of the clipboard
+	outputPath := flag.String("output", "", "Write the output to this file instead of copying it to the clipboard")
+	alsoCopy := flag.Bool("also-copy", false, "With --output, copy the output to the clipboard as well as writing the file")
+
+	// Define flag for adding to the clipboard or output file
+	appendFlag := flag.Bool("append", false, "Add the output to what the clipboard (or --output file) already holds instead of replacing it")
+
+	// Define flags for compressed output files and reading them back
+	compress := flag.String("compress", "", "Compress the --output file with gzip or zstd")
+	from := flag.String("from", "", "Deliver a file written earlier with --output, compressed or not, instead of collecting files")
+
+	// Define flags for the symbol the refactor command gathers references of
+	symbol := flag.String("symbol", "", "Symbol whose referencing files the refactor command includes")
+	renameTo := flag.String("rename-to", "", "New name the refactor command asks to rename --symbol to")
+
+	// Define flag for writing the output to stdout for piping
+	toStdout := flag.Bool("stdout", false, "Write the output to stdout instead of the clipboard; all other messages go to stderr")
+
+	// Define flag for how the output reaches the clipboard
+	clipboardBackend := flag.String("clipboard-backend", backendAuto, "How to copy: auto (native, falling back to an OSC 52 terminal escape), native, osc52 or stdout (same as --stdout)")
+
+	// Define flags for token estimation and the token budget
+	showTokens := flag.Bool("tokens", false, "Print the estimated token count of the output for common tokenizers")
+	maxTokens := flag.Int("max-tokens", 0, "Estimated token budget for the output, replacing the --max-total-size limit (0 disables)")
+	maxTokensAction := flag.String("max-tokens-action", "abort", "What to do when the output exceeds --max-tokens: abort or trim trailing files")
+	tokenizer := flag.String("tokenizer", clip4llm.Tokenizers()[0], "Tokenizer the --max-tokens budget is estimated with: cl100k_base, o200k_base or llama")
+
+	// Define flags for the handling of localization files
+	i18nMode := flag.String("i18n", "all", "Localization files (*.po, locales/*.json): keys only, the default language only, or all")
+	i18nDefault := flag.String("i18n-default", "en", "Default language kept by --i18n=default")
+
+	// Define flag for decoding compiled protobuf descriptor sets
+	decodeDescriptors := flag.Bool("decode-descriptors", false, "Include compiled protobuf descriptor sets (*.pb, *.desc) as .proto schema text instead of skipping them as binary")
+
+	// Define flag for removing comments from source files
+	stripComments := flag.Bool("strip-comments", false, "Remove line and block comments from Go, JS/TS, Python, C-family, shell and YAML files to save tokens")
+
+	// Define flag for reducing email files to their text
+	extractEmail := flag.Bool("extract-email", false, "Include .eml and .mbox files as their From/To/Cc/Date/Subject headers and plain text bodies, without attachments")
+
+	// Define flags for removing the front matter of markdown files
+	stripFrontMatter := flag.Bool("strip-front-matter", false, "Remove the YAML (---) or TOML (+++) front matter block atop .md, .markdown and .mdx files")
+	keepFrontMatter := flag.String("keep-front-matter", "", "Comma-separated front matter keys kept when stripping, such as title,date (implies --strip-front-matter)")
+
+	// Define flag for describing images instead of linking them
+	imagePlaceholders := flag.Bool("image-placeholders", false, "Replace local images referenced by markdown and notebooks with placeholders giving their type, dimensions and size")
+
+	// Define flag for noting the size, age and length of each file
+	metadata := flag.Bool("metadata", false, "Note the size, modification time and line count next to each file's path")
+	raw := flag.Bool("raw", false, "Give exact byte counts and timestamps in --metadata and --stats instead of rounded sizes and relative times")
+
+	// Define flag for noting the git provenance of each file
+	blameSummary := flag.Bool("blame-summary", false, "Note the last change and the top authors by line from git blame next to each file's path")
+
+	// Define flag for keeping generated files
+	includeGenerated := flag.Bool("include-generated", false, "Keep generated code (DO NOT EDIT and @generated markers, *.pb.go), lockfiles, sourcemaps and minified JS/CSS, which are skipped by default")
+
+	// Define flags for squeezing whitespace out of the files
+	compact := flag.Bool("compact", false, "Trim trailing whitespace and collapse runs of blank lines to one")
+	compactIndent := flag.Bool("compact-indent", false, "With --compact, also shrink every indentation level to a single space (Makefiles and Markdown keep theirs)")
+
+	// Define flag for the output format
+	format := flag.String("format", "delimited", "Output format: delimited (files wrapped in --delimiter), xml (<document> elements) or json (array of objects)")
+
+	// Define flags for the guard against copying sensitive directories
+	force := flag.Bool("force", false, "Run even in a sensitive directory such as $HOME or /")
+	sensitiveDirs := flag.String("sensitive-dirs", "", "Comma-separated extra directories to refuse to run in without --force (e.g., ~/Documents)")
+
+	// Define flag for restricting the walk to files tracked by git
+	gitTracked := flag.Bool("git-tracked", false, "Only include files tracked by git (git ls-files), skipping build output and other untracked files")
+
+	// Define flag for the hidden file policy
+	hidden := flag.String("hidden", "skip", "Hidden files and directories not matched by --include: skip, list (names only, no content) or include")
+
+	// Define flag for selecting only the files changed since a git ref
+	gitDiffRef := flag.String("git-diff", "", "Only include files changed relative to this git ref, plus new untracked files (e.g., main or HEAD~3)")
+
+	// Define flag for selecting the files changed by a saved patch
+	patch := flag.String("patch", "", "Unified diff or saved GitHub PR patch; include it plus the files it changes at their local state")
+
+	// Define flags for the order of the files in the output
+	sortOrder := flag.String("sort", "", "Order the files by path, size (smallest first), mtime (most recent first) or extension instead of the walk order")
+	sortReverse := flag.Bool("sort-reverse", false, "With --sort, reverse the order")
+
+	// Define flag for selecting the infrastructure files only
+	infra := flag.Bool("infra", false, "Only include Dockerfiles, compose files, CI workflows and deployment manifests, hidden ones like .github included")
+
+	// Define flag for the aggregated list of TODO comments
+	todos := flag.Bool("todos", false, "Append a list of the TODO, FIXME and HACK comments in the included files")
+
+	// Define flag for emitting patches instead of whole files
+	diffMode := flag.Bool("diff-mode", false, "With --git-diff, include the unified diff of each changed file instead of its full content")
+
+	// Define flag for the size above which the output needs confirmation
+	confirmOver := flag.String("confirm-over", "", "Ask for confirmation before delivering output larger than this size (e.g., 200kb)")
+
+	// Define flag for the directory tree of the included files
+	tree := flag.Bool("tree", false, "Start the output with a directory tree of the included files")
+	treeEmpty := flag.Bool("tree-empty", false, "With --tree, also show empty directories and those whose files were all filtered out, with counts")
+	treeLabels := flag.Bool("tree-labels", false, "With --tree, mark each file with its kind: ⚙️ config, 🧪 test, 📄 doc or 🧩 code")
+
+	// Define flag for splitting the output into parts that fit the limit
+	chunk := flag.Bool("chunk", false, "Split output over the limit (--max-tokens or --max-total-size) into self-contained parts instead of failing")
+	chunkAuto := flag.Bool("chunk-auto", false, "With --chunk on the clipboard, also move to the next part as soon as the clipboard holds something else")
+
+	// Define flag for the prompt template wrapping the output
+	templatePath := flag.String("template", "", "Go text/template file wrapping the output, with {{.Files}}, {{.Tree}}, {{.Date}}, {{.FileCount}} and {{.TokenCount}}")
+
+	// Define flag for keeping the output fresh while the files change
+	watch := flag.Bool("watch", false, "Keep running and copy the output again whenever files change, until interrupted")
+	pprofAddr := flag.String("pprof", "", "With --watch, serve the pprof profiling endpoints on this localhost port or address (e.g., localhost:6060)")
+
+	// Define flag for running without delivering the output
+	noCopy := flag.Bool("no-copy", false, "Run the selection and checks without copying or writing the output, for reports and CI")
+
+	// Define flag for the machine readable run report
+	reportJSON := flag.String("report-json", "", "Write a JSON summary of the run (files, sizes, token estimates) to this file")
+
+	// Define flag for replacing secrets with placeholders
+	redactFlag := flag.Bool("redact-secrets", false, "Replace AWS keys, private keys, bearer tokens, random looking KEY=VALUE secrets and redact:<name> config patterns with [REDACTED:<name>]")
+
+	// Define flags for the summary printed after copying
+	showStats := flag.Bool("stats", false, "Print a summary after copying: files included, files skipped by reason, total size, estimated tokens and the largest files")
+	statsJSON := flag.Bool("stats-json", false, "Like --stats, but print the summary as JSON for scripts")
+
+	// Define flag for silencing the exclude suggestions of large runs
+	noHints := flag.Bool("no-hints", false, "Never suggest excludes when a run is large (over 500 files or 100000 tokens)")
+
+	// Define flag for timing the phases of the run
+	trace := flag.Bool("trace", false, "Print the time spent walking, classifying, reading, formatting and delivering, and the slowest files")
+
+	// Define flag for the number of files read at once
+	concurrency := flag.Int("concurrency", 0, "Number of files classified and read at once (default: 0, four per CPU)")
+
+	// Define flag for curating the selection interactively
+	pick := flag.Bool("pick", false, "Review the candidate files in an interactive picker before copying")
+
+	// Define flag for the named set of config values to use
+	profile := flag.String("profile", "", "Use the keys of the [name] section of the .clip4llm config on top of the rest, such as --profile review")
+
+	// Define flag for collecting a remote repository instead of the working directory
+	repo := flag.String("repo", "", "Fetch a remote repository, host/owner/name[@ref] such as github.com/org/name@v1.2.0, into a temporary directory and collect it")
+
+	// Define flag for collecting the entries of an archive
+	archive := flag.String("archive", "", "Collect the entries of a .zip, .tar, .tar.gz or .tgz file instead of the working directory, with archive-relative paths")
+
+	// Define flag for walking into symlinked directories
+	followSymlinks := flag.Bool("follow-symlinks", false, "Walk symlinked directories and include symlinked files, skipping links that would loop (by default symlinks are skipped)")
+
+	// Define flags for selecting files by their CODEOWNERS owners
+	owner := flag.String("owner", "", "Comma-separated owners from CODEOWNERS; only files owned by one of them are included (e.g., @org/team-payments)")
+	notOwner := flag.String("not-owner", "", "Comma-separated owners from CODEOWNERS whose files are left out")
+
+	// Define flag for the directories gathered in a single run
+	var roots repeatedFlag
+	flag.Var(&roots, "root", "Directory to collect from, repeat it to gather several into one output with paths prefixed by the directory name")
+
+	// Define flag for the number of invocations kept for rerun
+	historySize := flag.Int("history", 20, "Number of recent invocations kept for the history and rerun commands (0 disables)")
+
+	// List or repeat previous invocations from the history
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "history" {
+		if err := printHistory(); err != nil {
+			log.Fatal(err)
 		}
-		if f.Name == "max-size" {
-			maxSizeSet = true
+		return
+	}
+	if len(args) > 0 && args[0] == "report" {
+		if err := runReportCommand(args[1:]); err != nil {
+			log.Fatal(err)
 		}
-		if f.Name == "include" {
-			includeSetFlag = true
+		return
+	}
+	if len(args) > 0 && args[0] == "rerun" {
+		var err error
+		args, err = rerunArgs(args[1:])
+		if err != nil {
+			log.Fatal(err)
 		}
-		if f.Name == "exclude" {
-			excludeSetFlag = true
+	}
+
+	// Copy a part of the last chunked run again, with the delivery flags that follow
+	var chunkPart int
+	if len(args) > 0 && args[0] == "chunk" {
+		var err error
+		chunkPart, args, err = chunkNumber(args[1:])
+		if err != nil {
+			log.Fatal(err)
 		}
-	})
+	}
+
+	invocation := args
 
-	// Override flag values with config values if the flag was not set by the user
-	if !delimiterSet {
-		if val, ok := config["delimiter"]; ok {
-			*delimiter = val
+	// Run a prompt preset when the first argument names a command
+	var command string
+	if len(args) > 0 {
+		if _, ok := clip4llm.Commands()[args[0]]; ok {
+			command = args[0]
+			args = args[1:]
 		}
 	}
 
-	if !maxSizeSet {
-		if val, ok := config["max-size"]; ok {
-			if parsedVal, err := strconv.Atoi(val); err == nil {
-				*maxSize = parsedVal
+	flag.Usage = usage
+	flag.CommandLine.Parse(args)
+
+	// In stdout mode the payload owns standard output, with --stats-json the summary does
+	if *toStdout || *statsJSON {
+		routeMessagesToStderr()
+	}
+
+	// Flags given on the command line win over every .clip4llm file
+	given := commandLineFlags()
+
+	// Load configuration from .clip4llm files and gather the effective settings
+	// for this run, again in --watch whenever one of the files changes. The
+	// loaded configuration comes along to tell what a reload changed.
+	loadOptions := func() (clip4llm.Options, map[string]string, error) {
+		config := loadConfig(*verbose)
+		loaded := config
+		overrideExcludes, sizeLimits := fileOverrides(config, *verbose)
+		config, err := selectProfile(config, *profile)
+		if err != nil {
+			return clip4llm.Options{}, nil, err
+		}
+
+		// Override flag values with config values if the flag was not set by the user
+		applyConfig(config, given, *verbose)
+
+		// Gather the effective settings for this run
+		o := clip4llm.DefaultOptions()
+		o.RedactSecrets = *redactFlag
+		o.DiffMode = *diffMode
+		o.GitDiff = *gitDiffRef
+		o.Patch = *patch
+		o.Infra = *infra
+		o.Sort = *sortOrder
+		o.SortReverse = *sortReverse
+		o.Hidden = *hidden
+		o.Force = *force
+		o.SensitiveDirs = parseCommaSeparated(*sensitiveDirs)
+		o.Format = *format
+		o.Delimiter = *delimiter
+		o.MaxSize = *maxSize
+		o.MaxSizes = sizeLimits
+		o.MaxDepth = *maxDepth
+		o.DepthGuard = *depthGuard
+		o.Truncate = *truncate
+		o.Verbose = *verbose
+		o.Concurrency = *concurrency
+		o.Include = parseCommaSeparated(*include)
+		// Excluded [pattern] sections come first so a !pattern can take them back
+		o.Exclude = append(overrideExcludes, parseCommaSeparated(*exclude)...)
+		o.IncludeFrom = *includeFrom
+		o.ExcludeFrom = *excludeFrom
+		o.Grep = *grep
+		o.GrepContext = *grepContext
+		o.WithTests = *withTests
+		o.ResolveIncludes = *resolveIncludes
+		o.ResolveDepth = *resolveDepth
+		o.ResolveBudget = *resolveBudget
+		o.Entries = parseCommaSeparated(*entry)
+		o.PyModules = parseCommaSeparated(*pyModule)
+		o.Route = *route
+		o.GitTracked = *gitTracked
+		o.FollowSymlinks = *followSymlinks
+		o.Owners = parseCommaSeparated(*owner)
+		o.NotOwners = parseCommaSeparated(*notOwner)
+		o.Tree = *tree
+		o.TreeEmpty = *treeEmpty
+		o.TreeLabels = *treeLabels
+		o.DepsSummary = *depsSummary
+		o.BuildTargets = *buildTargets
+		o.DBSchema = *dbSchema
+		o.Todos = *todos
+		o.Exec = *execCommand
+		o.DiffBase = *diffBase
+		o.Budget = *budget
+		o.Command = command
+		o.Args = flag.Args()
+		for _, root := range roots {
+			abs, err := filepath.Abs(root)
+			if err != nil {
+				return clip4llm.Options{}, nil, err
+			}
+			if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+				return clip4llm.Options{}, nil, fmt.Errorf("invalid --root %q (expected a directory)", root)
 			}
+			o.Roots = append(o.Roots, abs)
+		}
+		o.Symbol = *symbol
+		o.RenameTo = *renameTo
+		o.MaxTokens = *maxTokens
+		o.MaxTokensAction = *maxTokensAction
+		o.Tokenizer = *tokenizer
+		o.Chunk = *chunk
+		o.I18n = *i18nMode
+		o.I18nDefault = *i18nDefault
+		o.DecodeDescriptors = *decodeDescriptors
+		o.ExtractEmail = *extractEmail
+		o.StripFrontMatter = *stripFrontMatter
+		o.KeepFrontMatter = parseCommaSeparated(*keepFrontMatter)
+		o.ImagePlaceholders = *imagePlaceholders
+		o.BlameSummary = *blameSummary
+		o.Metadata = *metadata
+		o.Raw = *raw
+		o.IncludeGenerated = *includeGenerated
+		o.StripComments = *stripComments
+		o.Compact = *compact
+		o.CompactIndent = *compactIndent
+		o.Template = *templatePath
+		o.RedactRules = redactConfigRules(config)
+		o.Languages = languageOverrides(config)
+
+		if *trace {
+			o.Tracer = clip4llm.NewTracer()
+		}
+
+		o.MaxTotalSize, err = clip4llm.ParseByteSize(*maxTotalSize)
+		if err != nil || o.MaxTotalSize <= 0 {
+			return clip4llm.Options{}, nil, fmt.Errorf("invalid --max-total-size %q (expected a positive size such as 512kb or 4mb)", *maxTotalSize)
 		}
-	}
 
-	if !includeSetFlag {
-		if val, ok := config["include"]; ok {
-			*include = val
+		// Parse the Go build tags, a nil list disables the filter
+		if *goTags != "" {
+			o.GoTags = parseCommaSeparated(*goTags)
 		}
+		return o, loaded, nil
+	}
+	o, config, err := loadOptions()
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	if !excludeSetFlag {
-		if val, ok := config["exclude"]; ok {
-			*exclude = val
+	// The stdout backend replaces the clipboard only, an output file or a
+	// report-only run still wins
+	switch *clipboardBackend {
+	case backendStdout:
+		if *outputPath == "" && !*noCopy {
+			*toStdout = true
 		}
+	case backendAuto, backendNative, backendOSC52:
+	default:
+		log.Fatalf("invalid --clipboard-backend %q (expected auto, native, osc52 or stdout)", *clipboardBackend)
+	}
+	if *toStdout || *statsJSON {
+		routeMessagesToStderr()
 	}
 
-	// Parse include and exclude patterns from flags or config
-	var includePatterns []string
-	if *include != "" {
-		includePatterns = parseCommaSeparated(*include)
-	} else if val, ok := config["include"]; ok {
-		includePatterns = parseCommaSeparated(val)
+	if *statsJSON && *toStdout {
+		log.Fatal("--stats-json cannot be combined with --stdout, both write to standard output")
 	}
 
-	var excludePatterns []string
-	if *exclude != "" {
-		excludePatterns = parseCommaSeparated(*exclude)
+	if *noCopy && (*toStdout || *outputPath != "") {
+		log.Fatal("--no-copy cannot be combined with --stdout or --output")
 	}
 
-	if *verbose {
-		// Print out the configuration values
-		fmt.Println("Configuration:")
-		fmt.Printf("\tDelimiter: %s\n", *delimiter)
-		fmt.Printf("\tMax Size: %d KB\n", *maxSize)
-		fmt.Printf("\tInclude Patterns: %v\n", includePatterns)
-		fmt.Printf("\tExclude Patterns: %v\n", excludePatterns)
+	if *watch && (*noCopy || *pick) {
+		log.Fatal("--watch cannot be combined with --no-copy or --pick")
+	}
+	if *pprofAddr != "" && !*watch {
+		log.Fatal("--pprof needs --watch, a single run is over before it can be profiled")
 	}
 
-	// Get the current working directory
-	dir, err := os.Getwd()
-	if err != nil {
-		log.Fatal(err)
+	if *toStdout && *outputPath != "" {
+		log.Fatal("--stdout and --output cannot be combined")
+	}
+
+	if (*from != "" || chunkPart > 0) && (*noCopy || *watch || *pick) {
+		log.Fatal("--from and the chunk command cannot be combined with --no-copy, --watch or --pick")
+	}
+	if *appendFlag && (*toStdout || *chunk || *compress != "") {
+		log.Fatal("--append cannot be combined with --stdout, --chunk or --compress")
+	}
+	if *repo != "" && (*watch || *from != "" || len(roots) > 0) {
+		log.Fatal("--repo cannot be combined with --watch, --from or --root")
+	}
+	if *archive != "" && (*repo != "" || *watch || *from != "" || len(roots) > 0) {
+		log.Fatal("--archive cannot be combined with --repo, --watch, --from or --root")
+	}
+	if *chunkAuto && (!*chunk || *toStdout || *outputPath != "") {
+		log.Fatal("--chunk-auto needs --chunk and the clipboard as the destination")
+	}
+	if *compress != "" && *outputPath == "" {
+		log.Fatal("--compress needs an --output file")
+	}
+	if *alsoCopy && *outputPath == "" {
+		log.Fatal("--also-copy needs an --output file")
+	}
+	if *compress != "" && *compress != "gzip" && *compress != "zstd" {
+		log.Fatalf("invalid --compress %q (expected gzip or zstd)", *compress)
 	}
 
-	var builder strings.Builder
-	totalSize := 0 // Track total size of the output
+	// Standard output by now, or standard error when the payload owns it
+	o.Log = os.Stdout
 
-	// Walk through the current folder and process files
-	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	// Resolve the output file and the report so they are never picked up as input
+	dest := &delivery{compress: *compress, stdout: *toStdout, backend: *clipboardBackend, autoNext: *chunkAuto, append: *appendFlag, alsoCopy: *alsoCopy, verbose: *verbose}
+	if *outputPath != "" {
+		dest.output, err = filepath.Abs(*outputPath)
 		if err != nil {
-			return err
+			log.Fatal(err)
 		}
+		o.SkipFiles = append(o.SkipFiles, dest.output)
+	}
 
-		// Get the base name of the file/directory
-		name := info.Name()
+	// Reshare an earlier output instead of collecting the files again
+	if *from != "" {
+		dest.source = *from
+		content, err := readSnapshot(*from)
+		if err != nil {
+			log.Fatal(err)
+		}
+		deliverOutput(content, dest)
+		return
+	}
+	if chunkPart > 0 {
+		dest.source = fmt.Sprintf("part %d of the last chunked run", chunkPart)
+		content, err := loadChunk(chunkPart)
+		if err != nil {
+			log.Fatal(err)
+		}
+		deliverOutput(content, dest)
+		return
+	}
 
-		// Check if the file/directory matches any exclude patterns
-		excluded, err := matchesAnyPattern(name, excludePatterns)
+	var reportPath string
+	if *reportJSON != "" {
+		reportPath, err = filepath.Abs(*reportJSON)
 		if err != nil {
-			if *verbose {
-				fmt.Printf("Error matching exclude patterns for %s: %v\n", path, err)
-			}
-			// In case of error, do not exclude
-			excluded = false
+			log.Fatal(err)
 		}
-		if excluded {
-			if info.IsDir() {
-				if *verbose {
-					fmt.Printf("Excluding directory (matched exclude pattern): %s\n", path)
-				}
-				return filepath.SkipDir // Skip the entire directory
+		o.SkipFiles = append(o.SkipFiles, reportPath)
+	}
+
+	// The directory the run was started in, where rerun repeats it even when a
+	// temporary copy of --repo or --archive is collected instead
+	workDir, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Collect and deliver in a function of its own so the temporary copy of
+	// --repo or --archive is removed on every way out, failures included
+	run := func() error {
+		// Collect a fresh shallow copy of the remote repository in its place
+		if *repo != "" {
+			url, ref, err := parseRepoSpec(*repo)
+			if err != nil {
+				return err
 			}
-			if *verbose {
-				fmt.Printf("Excluding file (matched exclude pattern): %s\n", path)
+			clone, err := cloneRepo(url, ref, *verbose)
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(clone)
+			// Leave it before it goes, a directory in use cannot be removed everywhere
+			defer os.Chdir(workDir)
+			if err := os.Chdir(clone); err != nil {
+				return err
 			}
-			return nil // Skip the file
 		}
 
-		// Handle hidden files and directories
-		if strings.HasPrefix(name, ".") {
-			// Check if the hidden file/directory matches any include patterns
-			included, err := matchesAnyPattern(name, includePatterns)
+		// Collect the unpacked entries of the archive in its place
+		if *archive != "" {
+			unpacked, err := extractArchive(*archive)
 			if err != nil {
-				if *verbose {
-					fmt.Printf("Error matching include patterns for %s: %v\n", path, err)
-				}
-				// In case of error, do not include
-				included = false
+				return err
+			}
+			defer os.RemoveAll(unpacked)
+			// Leave it before it goes, a directory in use cannot be removed everywhere
+			defer os.Chdir(workDir)
+			if err := os.Chdir(unpacked); err != nil {
+				return err
 			}
+		}
 
-			if !included {
-				if *verbose {
-					fmt.Printf("Skipping hidden file/directory: %s\n", path)
-				}
-				if info.IsDir() {
-					return filepath.SkipDir // Skip the entire hidden directory
-				}
-				return nil // Skip the hidden file
+		// Get the current working directory
+		dir, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+
+		// Name the collected directory by what the user gave for temporary copies
+		switch {
+		case *repo != "":
+			dest.source = *repo
+		case *archive != "":
+			dest.source = *archive
+		default:
+			dest.source = dir
+		}
+
+		collector, err := clip4llm.NewCollector(dir, o)
+		if err != nil {
+			return err
+		}
+
+		if o.Verbose {
+			// Print out the configuration values
+			fmt.Println("Configuration:")
+			if *profile != "" {
+				fmt.Printf("\tProfile: %s\n", *profile)
+			}
+			fmt.Printf("\tDelimiter: %s\n", o.Delimiter)
+			fmt.Printf("\tMax Size: %d KB\n", o.MaxSize)
+			fmt.Printf("\tInclude Patterns: %v\n", o.Include)
+			fmt.Printf("\tExclude Patterns: %v\n", o.Exclude)
+			if o.GoTags != nil {
+				fmt.Printf("\tGo Tags: %s\n", *goTags)
+			}
+			if o.WithTests {
+				fmt.Println("\tWith Tests: enabled")
+			}
+			if len(o.Entries) > 0 {
+				fmt.Printf("\tEntry Files: %v\n", o.Entries)
+			}
+			if len(o.PyModules) > 0 {
+				fmt.Printf("\tPython Modules: %v\n", o.PyModules)
+			}
+			if o.Route != "" {
+				fmt.Printf("\tRoute: %s\n", o.Route)
+			}
+			if dest.output != "" {
+				fmt.Printf("\tOutput: %s\n", dest.output)
+			}
+			if o.MaxTokens > 0 {
+				fmt.Printf("\tMax Tokens: %d %s (%s)\n", o.MaxTokens, o.Tokenizer, o.MaxTokensAction)
+			}
+			if o.I18n != "all" {
+				fmt.Printf("\tI18n: %s (default language %s)\n", o.I18n, o.I18nDefault)
 			}
-			// If the hidden file/directory is in the include patterns, proceed
-			if *verbose {
-				fmt.Printf("Including hidden file/directory (matched include pattern): %s\n", path)
+			if o.ResolveIncludes {
+				fmt.Printf("\tResolve Includes: depth %d, budget %d KB\n", o.ResolveDepth, o.ResolveBudget)
 			}
 		}
 
-		// If it's a directory (and not skipped), continue traversing
-		if info.IsDir() {
-			if *verbose {
-				fmt.Printf("Entering directory: %s\n", path)
+		// Let the user curate the final selection
+		if *pick {
+			collector.Pick = func(files []clip4llm.File) ([]clip4llm.File, error) {
+				return pickFiles(files, collector)
 			}
-			return nil
 		}
 
-		// Skip files larger than the specified max size
-		maxSizeBytes := int64(*maxSize) * 1024
-		if info.Size() > maxSizeBytes {
-			if *verbose {
-				fmt.Printf("Skipping large file (%.2f KB): %s\n", float64(info.Size())/1024, path)
+		// Select the files and assemble them into the final output, or into parts
+		// that each fit the limit in chunk mode
+		result, err := collector.Collect()
+		if err != nil {
+			return err
+		}
+		output := result.Output
+		warnDelimiterCollisions(o.Delimiter, result.DelimiterCollisions)
+		warnDepthGuarded(o.DepthGuard, result.DepthGuarded)
+
+		if *showTokens {
+			printTokenEstimates(output)
+		}
+
+		delivered := "clipboard"
+		switch {
+		case *noCopy:
+			delivered = "none"
+		case dest.stdout:
+			delivered = "stdout"
+		case dest.output != "":
+			delivered = "file"
+		}
+
+		// Write the run report before delivery so report-only runs get one too
+		if reportPath != "" {
+			if err := clip4llm.WriteReport(reportPath, collector.Report(result, delivered)); err != nil {
+				return err
 			}
-			return nil
 		}
 
-		// Check if the file is binary
-		isBinary, err := isBinaryFile(path, *maxSize)
-		if err != nil {
-			if *verbose {
-				fmt.Printf("Error checking if file is binary: %s\n", path)
+		// Summarize the run once the output has gone out, or has been checked
+		printSummary := func() error {
+			stats := *showStats || *statsJSON
+			hints := !*noHints && !*statsJSON && isLargeRun(len(result.Files), estimateTokens(output))
+			if !stats && !hints {
+				return nil
+			}
+			report := collector.Report(result, delivered)
+			if stats {
+				stats := buildStats(report, result.Skipped, dir)
+				stats.Documents = documentsStats(result.Files, report, collector.Content)
+				// Show where the size goes along with the tree in the output
+				if o.Tree {
+					stats.SizeTree = clip4llm.SizeTree(report.Files)
+				}
+				if err := printStats(stats, *statsJSON, *raw, time.Now()); err != nil {
+					return err
+				}
+			}
+			// Suggest excludes at most once a day per directory
+			if hints {
+				if list := excludeHints(report); len(list) > 0 && hintsDue(dest.source, time.Now()) {
+					printHints(list)
+				}
 			}
 			return nil
 		}
-		if isBinary {
-			if *verbose {
-				fmt.Printf("Skipping binary file: %s\n", path)
+
+		// Stop short of delivering anything, the checks above have already passed
+		if *noCopy {
+			tokens, _ := clip4llm.EstimateTokens(output, o.Tokenizer)
+			fmt.Printf("Output not copied (--no-copy): %d files, %.1f KB, ~%d %s tokens.\n",
+				len(result.Files), float64(len(output))/1024, tokens, o.Tokenizer)
+			if err := printSummary(); err != nil {
+				return err
 			}
+			o.Tracer.Print(os.Stdout, dir)
 			return nil
 		}
 
-		// Read the content of the file using os.ReadFile
-		content, err := os.ReadFile(path)
-		if err != nil {
-			if *verbose {
-				fmt.Printf("Failed to read file: %s\n", path)
+		// Make sure a giant paste is intended
+		if *confirmOver != "" {
+			threshold, err := clip4llm.ParseByteSize(*confirmOver)
+			if err != nil {
+				return err
+			}
+			if len(output) > threshold {
+				confirmed, err := confirmLargeOutput(output, len(result.Files))
+				if err != nil {
+					return err
+				}
+				if !confirmed {
+					fmt.Println("Cancelled, nothing was copied.")
+					return nil
+				}
 			}
-			return nil
 		}
 
-		// Get the relative path of the file, ensuring it starts with "./"
-		relPath, err := filepath.Rel(dir, path)
-		if err != nil {
-			return err
+		// Deliver the final content to the output file, stdout or clipboard
+		endDeliver := o.Tracer.Begin("deliver", "")
+		if *chunk {
+			deliverChunks(result.Chunks, dest)
+		} else {
+			deliverOutput(output, dest)
 		}
-		if !strings.HasPrefix(relPath, ".") {
-			relPath = "./" + relPath
+		endDeliver()
+		if err := printSummary(); err != nil {
+			return err
 		}
+		o.Tracer.Print(os.Stdout, dir)
 
-		// Prepare the content to append
-		fileContent := fmt.Sprintf("\nFile: %s\n\n%s\n%s\n%s\n\n", relPath, *delimiter, content, *delimiter)
-		fileSize := len(fileContent)
+		// Remember the invocation so it can be repeated with rerun
+		if *historySize > 0 {
+			entry := historyEntry{Time: time.Now(), Dir: workDir, Args: invocation, Files: len(result.Files), Bytes: len(output), Tokens: estimateTokens(output)}
+			if err := recordHistory(entry, *historySize); err != nil && o.Verbose {
+				fmt.Printf("Failed to record history: %v\n", err)
+			}
+			if *chunk {
+				cache := chunkCache{Time: entry.Time, Dir: workDir, Args: invocation, Chunks: result.Chunks}
+				if err := saveChunks(cache); err != nil && o.Verbose {
+					fmt.Printf("Failed to cache the parts: %v\n", err)
+				}
+			}
+		}
 
-		// Check if the total size exceeds the 1MB limit
-		if totalSize+fileSize > maxTotalSize {
-			return fmt.Errorf("total output size exceeds 1MB limit; content not copied to the clipboard")
+		// Copy the output again whenever it changes, until interrupted
+		if *watch {
+			fmt.Println("Watching for changes, press Ctrl+C to stop.")
+			last := output
+			// Profile the long-running session in the field when asked
+			if *pprofAddr != "" {
+				listener, err := startPprof(*pprofAddr)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Profiling endpoints at http://%s/debug/pprof/\n", listener.Addr())
+			}
+			err := watchFiles(dir, o.SkipFiles, configPaths(), o.Verbose, func(reload bool) {
+				// Pick up the edited configuration, delivery stays as it started
+				if reload {
+					next, nextConfig, err := loadOptions()
+					if err == nil {
+						next.Log, next.SkipFiles, next.Chunk = o.Log, o.SkipFiles, o.Chunk
+						var reloaded *clip4llm.Collector
+						if reloaded, err = clip4llm.NewCollector(dir, next); err == nil {
+							collector = reloaded
+						}
+					}
+					if err != nil {
+						fmt.Println("Failed to reload the configuration, keeping the previous one:", err)
+					} else {
+						printConfigChanges(configChanges(config, nextConfig))
+						config = nextConfig
+					}
+				}
+				result, err := collector.Collect()
+				if err != nil {
+					fmt.Println("Failed to rebuild the output:", err)
+					return
+				}
+				if result.Output == last {
+					if o.Verbose {
+						fmt.Println("Output unchanged, nothing copied")
+					}
+					return
+				}
+				last = result.Output
+				fmt.Printf("[%s] Output changed, rebuilt with %d files\n", time.Now().Format("15:04:05"), len(result.Files))
+				if *chunk {
+					deliverChunks(result.Chunks, dest)
+				} else {
+					deliverOutput(result.Output, dest)
+				}
+			})
+			if err != nil {
+				return err
+			}
 		}
+		return nil
+	}
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
 
-		// Append the file path and content to the builder
-		builder.WriteString(fileContent)
-		totalSize += fileSize
+// commandLineFlags returns the names of the flags given on the command line
+func commandLineFlags() map[string]bool {
+	given := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+	return given
+}
 
-		return nil
+// applyConfig sets every flag that was not given on the command line from the
+// key of the same name in the loaded .clip4llm configuration. The others go
+// back to their defaults first, so a key removed from a reloaded file stops
+// applying.
+func applyConfig(config map[string]string, given map[string]bool, verbose bool) {
+	flag.VisitAll(func(f *flag.Flag) {
+		if given[f.Name] {
+			return
+		}
+		// Repeated flags would pile up their values otherwise
+		if r, ok := f.Value.(*repeatedFlag); ok {
+			*r = nil
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		val, ok := config[f.Name]
+		if !ok {
+			return
+		}
+		if err := f.Value.Set(val); err != nil {
+			if verbose {
+				fmt.Printf("Ignoring invalid config value for %s: %v\n", f.Name, err)
+			}
+		}
 	})
+}
 
-	if err != nil {
-		log.Fatal(err)
+// Number of files or directories named in a warning before the rest are counted
+const maxListedInWarning = 3
+
+// warnDelimiterCollisions points out the files containing a custom
+// delimiter, where the LLM cannot tell where the file ends
+func warnDelimiterCollisions(delimiter string, files []string) {
+	if len(files) == 0 {
+		return
+	}
+	listed := strings.Join(files[:min(len(files), maxListedInWarning)], ", ")
+	if len(files) > maxListedInWarning {
+		listed += fmt.Sprintf(" and %d more", len(files)-maxListedInWarning)
 	}
+	fmt.Printf("Warning: the delimiter %q also appears inside %s, so their end is ambiguous. "+
+		"Consider the default --delimiter=```, which is lengthened automatically for files containing fences.\n", delimiter, listed)
+}
 
-	// Copy the final content to the clipboard
-	err = clipboard.WriteAll(builder.String())
-	if err != nil {
-		fmt.Println("Failed to copy to clipboard:", err)
+// warnDepthGuarded points out the directories the depth guard stopped the
+// walk at, which usually means a runaway tree rather than real sources
+func warnDepthGuarded(guard int, dirs []string) {
+	if len(dirs) == 0 {
 		return
 	}
+	listed := strings.Join(dirs[:min(len(dirs), maxListedInWarning)], ", ")
+	if len(dirs) > maxListedInWarning {
+		listed += fmt.Sprintf(" and %d more", len(dirs)-maxListedInWarning)
+	}
+	fmt.Printf("Warning: stopped descending at %s, %d levels deep. "+
+		"Raise --depth-guard if the files below are really wanted.\n", listed, guard)
+}
+
+// repeatedFlag collects every value of a flag given more than once
+type repeatedFlag []string
 
-	fmt.Println("Content copied to clipboard successfully.")
+func (r *repeatedFlag) String() string {
+	return strings.Join(*r, ",")
 }
 
-// matchesAnyPattern checks if the given name matches any pattern in the list.
-// It returns true if a match is found.
-func matchesAnyPattern(name string, patterns []string) (bool, error) {
-	for _, pattern := range patterns {
-		matched, err := filepath.Match(pattern, name)
-		if err != nil {
-			return false, err
-		}
-		if matched {
-			return true, nil
-		}
-	}
-	return false, nil
+func (r *repeatedFlag) Set(value string) error {
+	*r = append(*r, value)
+	return nil
 }
 
 // Helper function to parse comma-separated strings into a slice
@@ -276,3 +884,57 @@ func parseCommaSeparated(input string) []string {
 	}
 	return result
 }
+
+// Prefix of the config keys defining custom rules as redact:<name>=<regex>
+const redactConfigPrefix = "redact:"
+
+// redactConfigRules returns the custom redaction rules of the configuration
+// as regular expressions by name
+func redactConfigRules(config map[string]string) map[string]string {
+	rules := make(map[string]string)
+	for key, pattern := range config {
+		if name, ok := strings.CutPrefix(key, redactConfigPrefix); ok {
+			rules[name] = pattern
+		}
+	}
+	return rules
+}
+
+// estimateTokens returns the estimated token count of text for the default tokenizer
+func estimateTokens(text string) int {
+	tokens, _ := clip4llm.EstimateTokens(text, clip4llm.Tokenizers()[0])
+	return tokens
+}
+
+// printTokenEstimates reports the estimated token count of text for every tokenizer
+func printTokenEstimates(text string) {
+	fmt.Println("Estimated tokens:")
+	for _, name := range clip4llm.Tokenizers() {
+		tokens, _ := clip4llm.EstimateTokens(text, name)
+		fmt.Printf("\t%s: %d\n", name, tokens)
+	}
+}
+
+// usage prints the command line help including the available commands
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [command] [flags]\n\nCommands:\n", filepath.Base(os.Args[0]))
+
+	commands := clip4llm.Commands()
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-12s %s\n", name, commands[name])
+	}
+
+	fmt.Fprintf(out, "  %-12s %s\n", "history", "List the recent invocations")
+	fmt.Fprintf(out, "  %-12s %s\n", "rerun [n]", "Repeat the nth most recent invocation (default 1) in its directory")
+	fmt.Fprintf(out, "  %-12s %s\n", "chunk <n>", "Copy part n of the last --chunk run again without regenerating it")
+	fmt.Fprintf(out, "  %-12s %s\n", "report", "Validate a --report-json file (report validate <file>) or print its schema (report schema)")
+
+	fmt.Fprintf(out, "\nFlags:\n")
+	flag.PrintDefaults()
+}
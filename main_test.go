@@ -4,5 +4,7 @@ import "testing"
 
 // TestGreet is a basic unit test for the Greet function
 func TestGreet(t *testing.T) {
+	// Keep the history, hints and chunk cache of the run out of the real home
+	t.Setenv("HOME", t.TempDir())
 	main()
 }
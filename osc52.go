@@ -0,0 +1,74 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+)
+
+// The ways content can be put on the clipboard
+const (
+	backendAuto   = "auto"   // the native clipboard, falling back to OSC 52
+	backendNative = "native" // the system clipboard tools only
+	backendOSC52  = "osc52"  // the terminal's clipboard through an escape sequence
+	backendStdout = "stdout" // standard output, the same as --stdout
+)
+
+// writeClipboard replaces the content of the system clipboard
+var writeClipboard = clipboard.WriteAll
+
+// copyToClipboard puts content on the clipboard with the configured backend
+// and returns a description of where it went.
+func copyToClipboard(content string, dest *delivery) (string, error) {
+	if dest.backend == backendOSC52 {
+		return "terminal clipboard (OSC 52)", writeOSC52(content)
+	}
+
+	err := writeClipboard(content)
+	if err == nil {
+		return "clipboard", nil
+	}
+	if dest.backend == backendNative {
+		return "", fmt.Errorf("%s", clipboardDiagnosis(err, dest.verbose))
+	}
+
+	// Remote shells and containers have no clipboard tools, the terminal may
+	// still accept the content
+	diagnosis := clipboardDiagnosis(err, dest.verbose)
+	if dest.verbose {
+		fmt.Printf("Native clipboard unavailable (%s), falling back to OSC 52\n", diagnosis)
+	}
+	if oscErr := writeOSC52(content); oscErr != nil {
+		return "", fmt.Errorf("%s; the OSC 52 fallback failed too: %v", diagnosis, oscErr)
+	}
+	return "terminal clipboard (OSC 52, if your terminal supports it)", nil
+}
+
+// writeOSC52 sends content to the terminal's clipboard with the OSC 52 escape
+// sequence, wrapped for tmux to pass it through to the outer terminal. The
+// sequence goes to the controlling terminal so --stdout pipes stay clean.
+func writeOSC52(content string) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("no terminal to send the OSC 52 sequence to: %v", err)
+	}
+	defer tty.Close()
+
+	_, err = tty.WriteString(osc52Sequence(content, os.Getenv("TMUX") != ""))
+	return err
+}
+
+// osc52Sequence returns the escape sequence setting the clipboard to content
+func osc52Sequence(content string, tmux bool) string {
+	sequence := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(content)) + "\a"
+	if tmux {
+		// tmux forwards a DCS passthrough with every ESC doubled
+		sequence = "\x1bPtmux;" + strings.ReplaceAll(sequence, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return sequence
+}
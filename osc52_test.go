@@ -0,0 +1,12 @@
+package main
+
+import "testing"
+
+func TestOSC52Sequence(t *testing.T) {
+	if got, want := osc52Sequence("hi", false), "\x1b]52;c;aGk=\a"; got != want {
+		t.Errorf("osc52Sequence = %q, want %q", got, want)
+	}
+	if got, want := osc52Sequence("hi", true), "\x1bPtmux;\x1b\x1b]52;c;aGk=\a\x1b\\"; got != want {
+		t.Errorf("osc52Sequence in tmux = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+)
+
+// payloadOut is the original standard output, kept for writing the payload in
+// --stdout mode after every other message has been routed to standard error
+var payloadOut io.Writer = os.Stdout
+
+// routeMessagesToStderr sends everything printed through os.Stdout to
+// standard error so only the payload reaches a pipe.
+func routeMessagesToStderr() {
+	os.Stdout = os.Stderr
+}
+
+// delivery is where the assembled output goes and how
+type delivery struct {
+	output   string // absolute path of the output file, empty for none
+	compress string // gzip or zstd compression of the output file, empty for none
+	stdout   bool   // write to standard output for piping
+	backend  string // how the clipboard is written to
+	autoNext bool   // move to the next part once the clipboard changes
+	append   bool   // add to the clipboard or output file instead of replacing it
+	alsoCopy bool   // copy to the clipboard as well when writing the output file
+	source   string // what the run collected, named in the separator before appended content
+	verbose  bool
+}
+
+// deliverOutput sends the assembled content to its destination: the output
+// file, standard output or, by default, the clipboard. With alsoCopy the
+// output file is written and the clipboard gets the content too.
+func deliverOutput(content string, dest *delivery) {
+	// Write the final content to the output file instead of the clipboard when one is set
+	if dest.output != "" {
+		if dest.append {
+			if err := appendOutputFile(dest.output, appendSeparator(dest.source), content); err != nil {
+				fmt.Println("Failed to append to output file:", err)
+				return
+			}
+			fmt.Printf("Content appended to %s successfully.\n", dest.output)
+		} else {
+			err := writeOutputFile(dest.output, content, dest)
+			if err != nil {
+				fmt.Println("Failed to write output file:", err)
+				return
+			}
+			fmt.Printf("Content written to %s successfully.\n", dest.output)
+		}
+		if !dest.alsoCopy {
+			return
+		}
+
+		// The file keeps the record, the clipboard only needs this run
+		target, err := copyToClipboard(content, dest)
+		if err != nil {
+			fmt.Println("Failed to copy to clipboard:", err)
+			return
+		}
+		fmt.Printf("Content copied to %s successfully.\n", target)
+		return
+	}
+
+	// Write the final content to standard output for piping into other tools
+	if dest.stdout {
+		_, err := io.WriteString(payloadOut, content)
+		if err != nil {
+			fmt.Println("Failed to write to stdout:", err)
+			return
+		}
+		fmt.Println("Content written to stdout successfully.")
+		return
+	}
+
+	// Add the final content to what the clipboard already holds
+	if dest.append {
+		existing, err := readClipboard()
+		if err != nil {
+			// Copying anyway would lose what is there, which is what --append avoids
+			fmt.Println("Failed to read the clipboard to append to, nothing was copied:", err)
+			return
+		}
+		content = joinAppended(existing, appendSeparator(dest.source), content)
+	}
+
+	// Copy the final content to the clipboard
+	target, err := copyToClipboard(content, dest)
+	if err != nil {
+		fmt.Println("Failed to copy to clipboard:", err)
+		return
+	}
+
+	if dest.append {
+		fmt.Printf("Content appended to %s successfully.\n", target)
+		return
+	}
+	fmt.Printf("Content copied to %s successfully.\n", target)
+}
+
+// appendSeparator returns the line set between appended runs, naming what
+// the new run collected since the paths of both are relative to their own
+// directory
+func appendSeparator(source string) string {
+	return fmt.Sprintf("\n===== %s =====\n", source)
+}
+
+// joinAppended returns content added after existing, on a line of its own
+// following separator. Nothing separates content from an empty existing.
+func joinAppended(existing string, separator string, content string) string {
+	if existing == "" {
+		return content
+	}
+	if !strings.HasSuffix(existing, "\n") {
+		existing += "\n"
+	}
+	return existing + separator + content
+}
+
+// appendOutputFile adds content to the end of the file at path after
+// separator, creating the file when needed
+func appendOutputFile(path string, separator string, content string) error {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err != nil {
+			return err
+		}
+		content = joinAppended(string(last), separator, content)[1:]
+	}
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	if _, err := file.WriteString(content); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// deliverChunks sends the parts of a chunked output to their destination:
+// numbered output files, standard output one after another or, by default,
+// the clipboard one part at a time as the user presses Enter.
+func deliverChunks(chunks []string, dest *delivery) {
+	if len(chunks) == 1 {
+		deliverOutput(chunks[0], dest)
+		return
+	}
+
+	if dest.output != "" {
+		ext := filepath.Ext(dest.output)
+		base := strings.TrimSuffix(dest.output, ext)
+		for i, chunk := range chunks {
+			path := fmt.Sprintf("%s.part%d%s", base, i+1, ext)
+			if err := writeOutputFile(path, chunk, dest); err != nil {
+				fmt.Println("Failed to write output file:", err)
+				return
+			}
+			fmt.Printf("Part %d of %d written to %s successfully.\n", i+1, len(chunks), path)
+		}
+		// With alsoCopy the parts are handed out to the clipboard below as well
+		if !dest.alsoCopy {
+			return
+		}
+	}
+
+	if dest.stdout {
+		for _, chunk := range chunks {
+			if _, err := io.WriteString(payloadOut, chunk); err != nil {
+				fmt.Println("Failed to write to stdout:", err)
+				return
+			}
+		}
+		fmt.Printf("%d parts written to stdout successfully.\n", len(chunks))
+		return
+	}
+
+	// Lines typed while the parts are handed out, one at a time
+	enter := make(chan error)
+	go func() {
+		reader := bufio.NewReader(os.Stdin)
+		for {
+			_, err := reader.ReadString('\n')
+			enter <- err
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var previous, target string
+	for i, chunk := range chunks {
+		if i > 0 {
+			// Only the native clipboard can be read back to notice a new copy
+			watch := dest.autoNext && target == "clipboard"
+			if watch {
+				fmt.Printf("Press Enter or copy something else to copy part %d of %d...", i+1, len(chunks))
+			} else {
+				fmt.Printf("Press Enter to copy part %d of %d...", i+1, len(chunks))
+			}
+			if !waitForNextPart(enter, previous, watch) {
+				fmt.Println("\nStopped before part", i+1)
+				return
+			}
+		}
+		var err error
+		target, err = copyToClipboard(chunk, dest)
+		if err != nil {
+			fmt.Println("Failed to copy to clipboard:", err)
+			return
+		}
+		previous = chunk
+		fmt.Printf("Part %d of %d copied to %s successfully.\n", i+1, len(chunks), target)
+	}
+}
+
+// Interval at which --chunk-auto checks the clipboard for a new copy
+const clipboardPollInterval = 500 * time.Millisecond
+
+// readClipboard returns the content of the system clipboard
+var readClipboard = clipboard.ReadAll
+
+// waitForNextPart blocks until a line is entered or, when watch is set, the
+// clipboard no longer holds copied because the user has moved on and copied
+// something else. It reports false when standard input is closed.
+func waitForNextPart(enter <-chan error, copied string, watch bool) bool {
+	var poll <-chan time.Time
+	if watch {
+		ticker := time.NewTicker(clipboardPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+	// Clipboards may hand the text back with Windows line endings
+	copied = strings.ReplaceAll(copied, "\r\n", "\n")
+	for {
+		select {
+		case err := <-enter:
+			return err == nil
+		case <-poll:
+			current, err := readClipboard()
+			if err != nil {
+				// Unreadable after all, fall back to waiting for Enter
+				poll = nil
+				continue
+			}
+			if strings.ReplaceAll(current, "\r\n", "\n") != copied {
+				fmt.Println()
+				return true
+			}
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWaitForNextPart(t *testing.T) {
+	defer func(read func() (string, error)) { readClipboard = read }(readClipboard)
+
+	clipboardContent := "part 1\r\n"
+	readClipboard = func() (string, error) { return clipboardContent, nil }
+
+	// A line entered moves on, a closed standard input stops
+	enter := make(chan error, 1)
+	enter <- nil
+	if !waitForNextPart(enter, "part 1\n", true) {
+		t.Error("Enter should move to the next part")
+	}
+	enter <- io.EOF
+	if waitForNextPart(enter, "part 1\n", false) {
+		t.Error("closed input should stop")
+	}
+
+	// A new copy moves on without Enter
+	clipboardContent = "something else"
+	if !waitForNextPart(make(chan error), "part 1\n", true) {
+		t.Error("a new copy should move to the next part")
+	}
+
+	// An unreadable clipboard falls back to waiting for Enter
+	readClipboard = func() (string, error) { return "", errors.New("no clipboard") }
+	go func() { enter <- nil }()
+	if !waitForNextPart(enter, "part 1\n", true) {
+		t.Error("Enter should still work without a readable clipboard")
+	}
+}
+
+func TestAppendOutputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "context.md")
+	for _, content := range []string{"first", "second\n", "third\n"} {
+		if err := appendOutputFile(path, appendSeparator("run"), content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "first\n\n===== run =====\nsecond\n\n===== run =====\nthird\n" {
+		t.Errorf("appended file = %q", got)
+	}
+
+	if got := joinAppended("", appendSeparator("run"), "new"); got != "new" {
+		t.Errorf("joinAppended on an empty clipboard = %q", got)
+	}
+}
+
+func TestAppendOutputFileLastByte(t *testing.T) {
+	cases := map[string]string{
+		"":          "new",
+		"old":       "old\n\n===== run =====\nnew",
+		"old\n":     "old\n\n===== run =====\nnew",
+		"old\r\n":   "old\r\n\n===== run =====\nnew",
+		"old\n\n\n": "old\n\n\n\n===== run =====\nnew",
+	}
+	for existing, want := range cases {
+		path := filepath.Join(t.TempDir(), "context.md")
+		if err := os.WriteFile(path, []byte(existing), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := appendOutputFile(path, appendSeparator("run"), "new"); err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("appending to %q = %q, want %q", existing, got, want)
+		}
+	}
+}
+
+func TestDeliverOutputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "context.md")
+	if err := os.WriteFile(path, []byte("stale content that is longer"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	deliverOutput("fresh", &delivery{output: path})
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "fresh" {
+		t.Errorf("output file = %q, want it replaced with %q", got, "fresh")
+	}
+}
+
+func TestDeliverChunksFiles(t *testing.T) {
+	dir := t.TempDir()
+	deliverChunks([]string{"one", "two", "three"}, &delivery{output: filepath.Join(dir, "context.md")})
+	for i, want := range []string{"one", "two", "three"} {
+		got, err := os.ReadFile(filepath.Join(dir, fmt.Sprintf("context.part%d.md", i+1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("part %d = %q, want %q", i+1, got, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "context.md")); !os.IsNotExist(err) {
+		t.Errorf("chunked output wrote the unnumbered file too: %v", err)
+	}
+
+	// A single part keeps the name it was given
+	single := filepath.Join(dir, "single")
+	deliverChunks([]string{"only"}, &delivery{output: single})
+	if got, err := os.ReadFile(single); err != nil || string(got) != "only" {
+		t.Errorf("single part = %q, %v", got, err)
+	}
+}
+
+func TestDeliverStdout(t *testing.T) {
+	defer func(out io.Writer) { payloadOut = out }(payloadOut)
+	var payload bytes.Buffer
+	payloadOut = &payload
+
+	deliverOutput("whole output", &delivery{stdout: true})
+	if payload.String() != "whole output" {
+		t.Errorf("stdout got %q", payload.String())
+	}
+
+	// Parts follow one another without waiting for Enter
+	payload.Reset()
+	deliverChunks([]string{"one ", "two"}, &delivery{stdout: true})
+	if payload.String() != "one two" {
+		t.Errorf("stdout got %q for the parts", payload.String())
+	}
+}
+
+func TestDeliverAlsoCopy(t *testing.T) {
+	defer func(write func(string) error) { writeClipboard = write }(writeClipboard)
+	var copied []string
+	writeClipboard = func(content string) error {
+		copied = append(copied, content)
+		return nil
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "context.md")
+	deliverOutput("whole output", &delivery{output: path, alsoCopy: true, backend: backendNative})
+	if got, err := os.ReadFile(path); err != nil || string(got) != "whole output" {
+		t.Errorf("output file = %q, %v", got, err)
+	}
+	if len(copied) != 1 || copied[0] != "whole output" {
+		t.Errorf("clipboard got %q", copied)
+	}
+
+	// Without it the clipboard is left alone
+	copied = nil
+	deliverOutput("whole output", &delivery{output: path, backend: backendNative})
+	if len(copied) != 0 {
+		t.Errorf("clipboard got %q without --also-copy", copied)
+	}
+
+	// The clipboard only gets this run when the file is appended to
+	copied = nil
+	deliverOutput("more", &delivery{output: path, append: true, source: "run", alsoCopy: true, backend: backendNative})
+	if len(copied) != 1 || copied[0] != "more" {
+		t.Errorf("clipboard got %q when appending", copied)
+	}
+}
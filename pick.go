@@ -0,0 +1,239 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/UnitVectorY-Labs/clip4llm/pkg/clip4llm"
+	"golang.org/x/term"
+)
+
+// pickItem is a candidate file in the interactive picker
+type pickItem struct {
+	file     clip4llm.File
+	size     int
+	tokens   int
+	selected bool
+}
+
+// picker holds the state of the interactive file picker
+type picker struct {
+	items     []*pickItem
+	visible   []*pickItem // items matching the search query
+	cursor    int         // index into visible
+	offset    int         // first visible item on screen
+	query     string
+	searching bool
+}
+
+// pickFiles shows a terminal UI listing the candidate files, all selected to
+// start with, and returns the files left selected once confirmed. The UI is
+// drawn on standard error so a --stdout pipe stays clean.
+func pickFiles(files []clip4llm.File, collector *clip4llm.Collector) ([]clip4llm.File, error) {
+	stdin := int(os.Stdin.Fd())
+	if !term.IsTerminal(stdin) || !term.IsTerminal(int(os.Stderr.Fd())) {
+		return nil, fmt.Errorf("--pick needs an interactive terminal")
+	}
+
+	p := &picker{}
+	for _, file := range files {
+		item := &pickItem{file: file, selected: true}
+		if content, err := collector.Content(file); err == nil {
+			item.size = len(content)
+			item.tokens = estimateTokens(string(content))
+		}
+		p.items = append(p.items, item)
+	}
+	p.filter()
+
+	state, err := term.MakeRaw(stdin)
+	if err != nil {
+		return nil, err
+	}
+	defer term.Restore(stdin, state)
+	// Use the alternate screen so the listing does not clutter the scrollback
+	fmt.Fprint(os.Stderr, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(os.Stderr, "\x1b[?25h\x1b[?1049l")
+
+	buf := make([]byte, 16)
+	for {
+		p.render()
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		done, err := p.handleKey(string(buf[:n]))
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			break
+		}
+	}
+
+	var picked []clip4llm.File
+	for _, item := range p.items {
+		if item.selected {
+			picked = append(picked, item.file)
+		}
+	}
+	return picked, nil
+}
+
+// handleKey applies a key press and reports whether the selection is confirmed
+func (p *picker) handleKey(key string) (bool, error) {
+	switch key {
+	case "\x03":
+		return false, fmt.Errorf("file selection cancelled")
+	case "\r", "\n":
+		if p.searching {
+			p.searching = false
+			return false, nil
+		}
+		return true, nil
+	case "\x1b[A":
+		p.move(-1)
+		return false, nil
+	case "\x1b[B":
+		p.move(1)
+		return false, nil
+	}
+
+	if p.searching {
+		switch key {
+		case "\x1b":
+			p.searching = false
+			p.query = ""
+		case "\x7f", "\b":
+			if p.query != "" {
+				runes := []rune(p.query)
+				p.query = string(runes[:len(runes)-1])
+			}
+		default:
+			if key[0] >= ' ' {
+				p.query += key
+			}
+		}
+		p.filter()
+		return false, nil
+	}
+
+	switch key {
+	case "q", "\x1b":
+		return false, fmt.Errorf("file selection cancelled")
+	case "k":
+		p.move(-1)
+	case "j":
+		p.move(1)
+	case " ":
+		if p.cursor < len(p.visible) {
+			p.visible[p.cursor].selected = !p.visible[p.cursor].selected
+			p.move(1)
+		}
+	case "a":
+		// Select every matching file, or deselect them when all already are
+		all := true
+		for _, item := range p.visible {
+			all = all && item.selected
+		}
+		for _, item := range p.visible {
+			item.selected = !all
+		}
+	case "/":
+		p.searching = true
+	}
+	return false, nil
+}
+
+// move moves the cursor by delta within the visible items
+func (p *picker) move(delta int) {
+	p.cursor += delta
+	if p.cursor >= len(p.visible) {
+		p.cursor = len(p.visible) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+}
+
+// filter recomputes the items matching the search query
+func (p *picker) filter() {
+	p.visible = p.visible[:0]
+	for _, item := range p.items {
+		if fuzzyMatch(p.query, item.file.RelPath) {
+			p.visible = append(p.visible, item)
+		}
+	}
+	p.move(0)
+}
+
+// render draws the picker: help, running totals, the search query and the
+// window of the list around the cursor
+func (p *picker) render() {
+	_, height, err := term.GetSize(int(os.Stderr.Fd()))
+	if err != nil || height < 6 {
+		height = 24
+	}
+	rows := height - 4
+
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+rows {
+		p.offset = p.cursor - rows + 1
+	}
+
+	count, size, tokens := 0, 0, 0
+	for _, item := range p.items {
+		if item.selected {
+			count++
+			size += item.size
+			tokens += item.tokens
+		}
+	}
+
+	var screen strings.Builder
+	screen.WriteString("\x1b[H\x1b[2J")
+	screen.WriteString("[space] toggle  [a] all  [/] search  [enter] copy  [q] cancel\r\n")
+	fmt.Fprintf(&screen, "Selected %d of %d files, %.1f KB, ~%d tokens\r\n", count, len(p.items), float64(size)/1024, tokens)
+	if p.searching || p.query != "" {
+		cursor := ""
+		if p.searching {
+			cursor = "_"
+		}
+		fmt.Fprintf(&screen, "Search: %s%s\r\n", p.query, cursor)
+	} else {
+		screen.WriteString("\r\n")
+	}
+
+	for i := p.offset; i < len(p.visible) && i < p.offset+rows; i++ {
+		item := p.visible[i]
+		pointer, check := "  ", "[ ]"
+		if i == p.cursor {
+			pointer = "> "
+		}
+		if item.selected {
+			check = "[x]"
+		}
+		fmt.Fprintf(&screen, "%s%s %s (%d tokens)\r\n", pointer, check, item.file.RelPath, item.tokens)
+	}
+	fmt.Fprint(os.Stderr, screen.String())
+}
+
+// fuzzyMatch reports whether the characters of query appear in order, not
+// necessarily adjacent, in text, ignoring case
+func fuzzyMatch(query string, text string) bool {
+	text = strings.ToLower(text)
+	for _, r := range strings.ToLower(query) {
+		i := strings.IndexRune(text, r)
+		if i < 0 {
+			return false
+		}
+		text = text[i+len(string(r)):]
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/UnitVectorY-Labs/clip4llm/pkg/clip4llm"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	cases := []struct {
+		query string
+		text  string
+		want  bool
+	}{
+		{"", "./main.go", true},
+		{"mgo", "./main.go", true},
+		{"MAIN", "./main.go", true},
+		{"srcidx", "./src/index.ts", true},
+		{"ogm", "./main.go", false},
+		{"é", "./café.md", true},
+	}
+	for _, c := range cases {
+		if got := fuzzyMatch(c.query, c.text); got != c.want {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", c.query, c.text, got, c.want)
+		}
+	}
+}
+
+func TestPickerToggleAll(t *testing.T) {
+	p := &picker{}
+	for _, name := range []string{"./a.go", "./b.go", "./c.md"} {
+		p.items = append(p.items, &pickItem{file: clip4llm.File{RelPath: name}, selected: true})
+	}
+	p.filter()
+
+	p.handleKey("/")
+	p.handleKey("g")
+	p.handleKey("o")
+	p.handleKey("\r")
+	p.handleKey("a")
+
+	for _, item := range p.items {
+		want := item.file.RelPath == "./c.md"
+		if item.selected != want {
+			t.Errorf("%s selected = %v, want %v", item.file.RelPath, item.selected, want)
+		}
+	}
+	if done, _ := p.handleKey("\r"); !done {
+		t.Error("enter outside of search should confirm the selection")
+	}
+}
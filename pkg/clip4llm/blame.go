@@ -0,0 +1,63 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Number of authors named in a blame summary
+const blameTopAuthors = 3
+
+// Author git blame gives lines that are not committed yet
+const uncommittedAuthor = "Not Committed Yet"
+
+// blameSummary returns a one-line provenance note for the file at path: when
+// and by whom it was last changed and who wrote most of its current lines.
+func blameSummary(path string) (string, error) {
+	dir, name := filepath.Dir(path), filepath.Base(path)
+	last, err := runCommand(dir, "git", "log", "-1", "--format=%cs%x09%an", "--", name)
+	if err != nil {
+		return "", err
+	}
+	if last == "" {
+		return "not committed yet", nil
+	}
+	date, author, _ := strings.Cut(last, "\t")
+	summary := fmt.Sprintf("last modified %s by %s", date, author)
+
+	porcelain, err := runCommand(dir, "git", "blame", "--line-porcelain", "--", name)
+	if err != nil {
+		return "", err
+	}
+	lines := make(map[string]int)
+	total := 0
+	for _, line := range strings.Split(porcelain, "\n") {
+		if author, ok := strings.CutPrefix(line, "author "); ok && author != uncommittedAuthor {
+			lines[author]++
+			total++
+		}
+	}
+	if total == 0 {
+		return summary, nil
+	}
+
+	authors := make([]string, 0, len(lines))
+	for author := range lines {
+		authors = append(authors, author)
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		if lines[authors[i]] != lines[authors[j]] {
+			return lines[authors[i]] > lines[authors[j]]
+		}
+		return authors[i] < authors[j]
+	})
+	var top []string
+	for _, author := range authors[:min(len(authors), blameTopAuthors)] {
+		top = append(top, fmt.Sprintf("%s %d%%", author, lines[author]*100/total))
+	}
+	return summary + ", authors: " + strings.Join(top, ", "), nil
+}
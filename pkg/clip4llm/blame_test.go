@@ -0,0 +1,50 @@
+package clip4llm
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestBlameSummary(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	commit := func(author string, content string) {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		for _, args := range [][]string{{"add", "main.go"}, {"-c", "user.name=" + author, "-c", "user.email=a@example.com", "commit", "--quiet", "-m", "change"}} {
+			cmd := exec.Command("git", args...)
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE=2024-05-01T12:00:00Z", "GIT_AUTHOR_DATE=2024-05-01T12:00:00Z")
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v\n%s", args, err, out)
+			}
+		}
+	}
+	if out, err := exec.Command("git", "init", "--quiet", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	commit("alice", "a\nb\nc\n")
+	commit("bob", "a\nb\nc\nd\n")
+
+	got, err := blameSummary(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "last modified 2024-05-01 by bob, authors: alice 75%, bob 25%"; got != want {
+		t.Errorf("blameSummary = %q, want %q", got, want)
+	}
+
+	untracked := filepath.Join(dir, "new.go")
+	if err := os.WriteFile(untracked, []byte("x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := blameSummary(untracked); err != nil || got != "not committed yet" {
+		t.Errorf("blameSummary of an untracked file = %q, %v", got, err)
+	}
+}
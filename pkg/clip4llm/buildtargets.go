@@ -0,0 +1,221 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// buildTarget is a target of a build file with the comment documenting it
+type buildTarget struct {
+	name string
+	doc  string
+}
+
+// buildFile is the parsed list of targets of one build file
+type buildFile struct {
+	name    string
+	targets []buildTarget
+}
+
+// summarizeBuildTargets parses the Makefile, Taskfile and justfile found in
+// dir and returns the targets of each with their comments, leaving out
+// pattern rules and the rules building files.
+func summarizeBuildTargets(dir string) (string, error) {
+	parsers := []struct {
+		files []string // names the build file goes by, the first existing one is used
+		parse func(content string) []buildTarget
+	}{
+		{[]string{"GNUmakefile", "Makefile", "makefile"}, parseMakefileTargets},
+		{[]string{"Taskfile.yml", "Taskfile.yaml", "taskfile.yml", "taskfile.yaml"}, parseTaskfileTargets},
+		{[]string{"justfile", "Justfile", ".justfile"}, parseJustfileTargets},
+	}
+
+	var files []buildFile
+	for _, parser := range parsers {
+		for _, name := range parser.files {
+			content, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				continue
+			}
+			files = append(files, buildFile{name: name, targets: parser.parse(string(content))})
+			break
+		}
+	}
+
+	if len(files) == 0 {
+		return "", fmt.Errorf("no build file (Makefile, Taskfile.yml, justfile) found")
+	}
+
+	var builder strings.Builder
+	for i, file := range files {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(file.name + "\n")
+		if len(file.targets) == 0 {
+			builder.WriteString("  (no targets)\n")
+		}
+		for _, target := range file.targets {
+			builder.WriteString("  " + target.name)
+			if target.doc != "" {
+				builder.WriteString(" - " + target.doc)
+			}
+			builder.WriteString("\n")
+		}
+	}
+	return strings.TrimRight(builder.String(), "\n"), nil
+}
+
+// addBuildTarget appends the target unless it is listed already, in which
+// case a missing comment is filled in
+func addBuildTarget(targets []buildTarget, name string, doc string) []buildTarget {
+	for i := range targets {
+		if targets[i].name == name {
+			if targets[i].doc == "" {
+				targets[i].doc = doc
+			}
+			return targets
+		}
+	}
+	return append(targets, buildTarget{name: name, doc: doc})
+}
+
+// A Makefile rule: its targets, and what follows the colon. Assignments
+// (=, :=, ::=, ?=, +=) never match as the targets cannot contain an =.
+var makeRulePattern = regexp.MustCompile(`^([^\s:=#][^:=#]*?)\s*::?([^=].*)?$`)
+
+// parseMakefileTargets returns the targets of a Makefile documented by the
+// comment lines right above them or a trailing ## comment. Special targets
+// such as .PHONY, pattern rules and targets naming files are left out,
+// unless a file-like name is declared .PHONY.
+func parseMakefileTargets(content string) []buildTarget {
+	lines := strings.Split(content, "\n")
+
+	phony := make(map[string]bool)
+	for _, line := range lines {
+		if rest, ok := strings.CutPrefix(line, ".PHONY:"); ok {
+			for _, name := range strings.Fields(rest) {
+				phony[name] = true
+			}
+		}
+	}
+
+	var targets []buildTarget
+	var comment string // the comment lines right above the current line
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if strings.HasPrefix(line, "#") {
+			comment = strings.TrimSpace(strings.TrimLeft(line, "#"))
+			continue
+		}
+		match := makeRulePattern.FindStringSubmatch(line)
+		if match == nil {
+			comment = ""
+			continue
+		}
+
+		doc := comment
+		if _, trailing, ok := strings.Cut(match[2], "##"); ok {
+			doc = strings.TrimSpace(trailing)
+		}
+		comment = ""
+		for _, name := range strings.Fields(match[1]) {
+			generated := strings.ContainsAny(name, "%$") || strings.HasPrefix(name, ".")
+			if generated || (strings.ContainsAny(name, "./") && !phony[name]) {
+				continue
+			}
+			targets = addBuildTarget(targets, name, doc)
+		}
+	}
+	return targets
+}
+
+// A key of a YAML mapping: its indentation, the key and the rest of the line
+var yamlKeyPattern = regexp.MustCompile(`^(\s*)("[^"]+"|'[^']+'|[^\s#:'"][^:#]*?):(?:\s+(.*))?$`)
+
+// parseTaskfileTargets returns the tasks of a Taskfile with their desc,
+// leaving out those marked internal
+func parseTaskfileTargets(content string) []buildTarget {
+	var targets []buildTarget
+	inTasks := false
+	taskIndent := -1 // indentation of the task names once known
+	internal := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		match := yamlKeyPattern.FindStringSubmatch(line)
+		if len(line) > 0 && line[0] != ' ' && line[0] != '\t' {
+			inTasks = match != nil && match[2] == "tasks"
+			continue
+		}
+		if !inTasks || match == nil {
+			continue
+		}
+
+		indent := len(match[1])
+		if taskIndent < 0 {
+			taskIndent = indent
+		}
+		key, value := strings.Trim(match[2], `"'`), strings.Trim(match[3], `"'`)
+		switch {
+		case indent == taskIndent:
+			if internal {
+				targets = targets[:len(targets)-1]
+			}
+			targets = append(targets, buildTarget{name: key})
+			internal = false
+		case len(targets) == 0:
+		case key == "desc" && indent > taskIndent:
+			targets[len(targets)-1].doc = value
+		case key == "internal" && value == "true":
+			internal = true
+		}
+	}
+	if internal {
+		targets = targets[:len(targets)-1]
+	}
+	return targets
+}
+
+// A justfile recipe: its name and what follows the colon ending its
+// parameters. Settings, aliases and variables assign with := instead.
+var justRecipePattern = regexp.MustCompile(`^@?([A-Za-z_][\w-]*)(?:\s[^:]*)?:(?:[^=].*)?$`)
+
+// parseJustfileTargets returns the recipes of a justfile documented by the
+// comment line right above them, leaving out private recipes
+func parseJustfileTargets(content string) []buildTarget {
+	var targets []buildTarget
+	var comment string // the comment line right above the current line
+	private := false
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		switch {
+		case strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "#!"):
+			comment = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+			continue
+		case strings.HasPrefix(line, "["):
+			// Attributes such as [private] or [linux] come between a comment and its recipe
+			private = private || strings.Contains(line, "private")
+			continue
+		}
+
+		match := justRecipePattern.FindStringSubmatch(line)
+		if match != nil && !private && !strings.HasPrefix(match[1], "_") {
+			switch match[1] {
+			case "alias", "set", "export", "import", "mod":
+			default:
+				targets = addBuildTarget(targets, match[1], comment)
+			}
+		}
+		comment = ""
+		private = false
+	}
+	return targets
+}
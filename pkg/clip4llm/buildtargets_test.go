@@ -0,0 +1,104 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSummarizeBuildTargets(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"Makefile": `BIN := bin/app
+CFLAGS = -O2
+
+.PHONY: build test dist/clean
+
+# Build the binary
+build: $(BIN)
+
+test: ## Run the tests
+	go test ./...
+
+$(BIN): main.go
+	go build -o $@
+
+%.o: %.c
+	cc -c $<
+
+dist/clean:
+	rm -rf dist
+
+gen.go: schema.json
+	go generate
+`,
+		"Taskfile.yml": `version: '3'
+
+vars:
+  NAME: app
+
+tasks:
+  lint:
+    desc: Run the linters
+    cmds:
+      - golangci-lint run
+  "release":
+    cmds:
+      - goreleaser
+  helper:
+    internal: true
+    desc: Not for humans
+`,
+		"justfile": `set shell := ["bash", "-c"]
+alias b := build
+version := "1.0"
+
+# Start the dev server
+[no-cd]
+dev port="8080":
+    go run . --port {{port}}
+
+[private]
+cleanup:
+    rm -rf tmp
+
+_hidden:
+    echo no
+
+build: dev
+    go build
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := summarizeBuildTargets(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Join([]string{
+		"Makefile",
+		"  build - Build the binary",
+		"  test - Run the tests",
+		"  dist/clean",
+		"",
+		"Taskfile.yml",
+		"  lint - Run the linters",
+		"  release",
+		"",
+		"justfile",
+		"  dev - Start the dev server",
+		"  build",
+	}, "\n")
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := summarizeBuildTargets(t.TempDir()); err == nil {
+		t.Error("expected an error without build files")
+	}
+}
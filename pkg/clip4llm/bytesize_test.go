@@ -0,0 +1,36 @@
+package clip4llm
+
+import (
+	"testing"
+)
+
+func TestParseByteSize(t *testing.T) {
+	cases := []struct {
+		value string
+		want  int
+	}{
+		{"4096", 4096},
+		{"200kb", 200 * 1024},
+		{"200KB", 200 * 1024},
+		{"1.5mb", 1536 * 1024},
+		{"2 MB", 2 * 1024 * 1024},
+		{"64k", 64 * 1024},
+		{"10b", 10},
+	}
+	for _, c := range cases {
+		got, err := ParseByteSize(c.value)
+		if err != nil {
+			t.Errorf("ParseByteSize(%q) error: %v", c.value, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("ParseByteSize(%q) = %d, want %d", c.value, got, c.want)
+		}
+	}
+
+	for _, value := range []string{"", "big", "-5kb", "kb"} {
+		if _, err := ParseByteSize(value); err == nil {
+			t.Errorf("ParseByteSize(%q) expected an error", value)
+		}
+	}
+}
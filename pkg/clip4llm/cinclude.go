@@ -0,0 +1,151 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Matches a quoted, project-local include directive such as #include "util/log.h"
+var cIncludePattern = regexp.MustCompile(`^\s*#\s*include\s*"([^"]+)"`)
+
+// File extensions treated as C/C++ sources or headers
+var cSourceExtensions = map[string]bool{
+	".c": true, ".h": true, ".cc": true, ".cpp": true, ".cxx": true,
+	".hh": true, ".hpp": true, ".hxx": true, ".ipp": true, ".inl": true,
+}
+
+// isCSourceFile reports whether name looks like a C/C++ source or header file
+func isCSourceFile(name string) bool {
+	return cSourceExtensions[strings.ToLower(filepath.Ext(name))]
+}
+
+// parseCIncludes returns the quoted include targets referenced by the file at path
+func parseCIncludes(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var includes []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if match := cIncludePattern.FindStringSubmatch(scanner.Text()); match != nil {
+			includes = append(includes, match[1])
+		}
+	}
+	return includes, scanner.Err()
+}
+
+// resolveCInclude locates a quoted include target on disk, looking next to the
+// including file first, then at the project root and its include directory.
+// Targets that resolve outside of the project root are ignored.
+func resolveCInclude(dir string, from string, target string) (string, bool) {
+	candidates := []string{
+		filepath.Join(filepath.Dir(from), filepath.FromSlash(target)),
+		filepath.Join(dir, filepath.FromSlash(target)),
+		filepath.Join(dir, "include", filepath.FromSlash(target)),
+	}
+	for _, candidate := range candidates {
+		rel, err := filepath.Rel(dir, candidate)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		info, err := os.Stat(candidate)
+		if err == nil && info.Mode().IsRegular() {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// namedFiles returns the absolute paths of the files named, relative ones
+// being relative to dir
+func namedFiles(dir string, names []string) map[string]bool {
+	named := make(map[string]bool)
+	for _, name := range names {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		if abs, err := filepath.Abs(name); err == nil {
+			named[abs] = true
+		}
+	}
+	return named
+}
+
+// addIncludedHeaders returns the selected files with the project-local headers
+// referenced by each named C/C++ file inserted directly after it, leaving
+// alone the files found by walking a directory. Headers are followed
+// transitively up to opts.resolveDepth levels and the headers added in total
+// may not exceed opts.resolveBudget KB.
+func addIncludedHeaders(dir string, files []fileEntry, named map[string]bool, opts *options) []fileEntry {
+	selected := make(map[string]bool)
+	for _, file := range files {
+		selected[file.path] = true
+	}
+
+	budget := int64(opts.resolveBudget) * 1024
+	var used int64
+
+	var result []fileEntry
+	for _, file := range files {
+		result = append(result, file)
+		if !named[file.path] || !isCSourceFile(file.path) {
+			continue
+		}
+
+		// Breadth-first walk of the include graph starting at this file
+		queue := []string{file.path}
+		for depth := 1; depth <= opts.resolveDepth && len(queue) > 0; depth++ {
+			var next []string
+			for _, current := range queue {
+				includes, err := parseCIncludes(current)
+				if err != nil {
+					if opts.verbose {
+						opts.logf("Error reading includes from %s: %v\n", current, err)
+					}
+					continue
+				}
+
+				for _, target := range includes {
+					headerPath, ok := resolveCInclude(dir, current, target)
+					if !ok || selected[headerPath] {
+						continue
+					}
+					selected[headerPath] = true
+
+					info, err := os.Stat(headerPath)
+					if err != nil || !isEligibleFile(headerPath, info, opts) {
+						continue
+					}
+					if used+info.Size() > budget {
+						if opts.verbose {
+							opts.logf("Skipping header (include budget of %d KB reached): %s\n", opts.resolveBudget, headerPath)
+						}
+						continue
+					}
+					used += info.Size()
+
+					relPath, err := relativePath(dir, headerPath)
+					if err != nil {
+						continue
+					}
+					if opts.verbose {
+						opts.logf("Including header referenced by %s: %s\n", file.relPath, relPath)
+					}
+					result = append(result, fileEntry{path: headerPath, relPath: relPath})
+					next = append(next, headerPath)
+				}
+			}
+			queue = next
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,56 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolveIncludesNamedOnly(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"main.c":      "#include \"util.h\"\n#include <stdio.h>\nint main(void) { return 0; }\n",
+		"util.h":      "#include \"inner/log.h\"\nvoid util(void);\n",
+		"inner/log.h": "void log_line(void);\n",
+		"other.c":     "#include \"other.h\"\n",
+		"other.h":     "void other(void);\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	collect := func(args []string) []string {
+		opts := DefaultOptions()
+		opts.Args = args
+		opts.Exclude = []string{"*.h"}
+		opts.ResolveIncludes = true
+		collector, err := NewCollector(dir, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := collector.Collect()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, file := range result.Files {
+			paths = append(paths, file.RelPath)
+		}
+		return paths
+	}
+
+	// A file named on the command line brings its headers along
+	if got, want := collect([]string{"main.c"}), []string{"./main.c", "./util.h", "./inner/log.h"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("named main.c selected %v, want %v", got, want)
+	}
+
+	// Files found by walking the directory do not
+	if got, want := collect(nil), []string{"./main.c", "./other.c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("walking selected %v, want %v", got, want)
+	}
+}
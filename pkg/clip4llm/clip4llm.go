@@ -0,0 +1,702 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+
+// Package clip4llm gathers the files of a project into a single prompt for a
+// large language model: it walks and filters the project, applies the content
+// transformations and renders the selected files in the output format. It is
+// the engine behind the clip4llm command, which adds configuration files, the
+// clipboard and the interactive features on top.
+package clip4llm
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Options configures a Collector. The fields mirror the command line flags of
+// the same names; start from DefaultOptions so every limit has a sane value.
+type Options struct {
+	Command string   // prompt preset to run, such as review, empty for none
+	Args    []string // paths to include instead of walking, or the command's arguments
+	Roots   []string // directories collected instead of the Collector's, with paths prefixed by their names
+
+	Delimiter    string      // wraps each file in the delimited format
+	Format       string      // delimited, xml or json
+	MaxSize      int         // maximum size of a single file in KB
+	MaxSizes     []SizeLimit // MaxSize overrides for the files matching a pattern
+	MaxDepth     int         // directory levels walked, 1 for only the top directory, 0 for no limit
+	DepthGuard   int         // directory levels walked before stopping with a warning, 0 for no guard
+	MaxTotalSize int         // maximum size of the output in bytes, unless MaxTokens is set
+	Truncate     string      // include the start of files over MaxSize, such as 8kb or 200lines
+
+	Include     []string // patterns included even when hidden
+	Exclude     []string // patterns left out
+	IncludeFrom string   // file listing more Include patterns, one per line, ahead of Include
+	ExcludeFrom string   // file listing more Exclude patterns, one per line, ahead of Exclude
+	Hidden      string   // hidden files not matched by Include: skip, list or include
+	SkipFiles   []string // absolute paths never included, such as the output file
+	Grep        string   // regular expression the content of every file must match, empty for all
+	GrepContext int      // with Grep, keep only this many lines around each match, 0 for whole files
+
+	Languages []LanguageOverride // languages of the files matching a pattern, ahead of detection
+
+	GoTags          []string // Go build tags files must build with, nil to include every Go file
+	WithTests       bool     // add the test file of each source file and vice versa
+	ResolveIncludes bool     // add the headers included by C/C++ files
+	ResolveDepth    int      // depth of nested includes followed
+	ResolveBudget   int      // combined size in KB of the added headers
+	Entries         []string // JS/TS entry files whose import closure is included
+	PyModules       []string // Python modules whose import closure is included
+	Route           string   // HTTP route whose registration and handlers are included
+	GitTracked      bool     // only include files tracked by git
+	FollowSymlinks  bool     // walk symlinked directories and include symlinked files
+	Owners          []string // only include files CODEOWNERS assigns to one of these owners
+	NotOwners       []string // leave out files CODEOWNERS assigns to one of these owners
+	GitDiff         string   // only include files changed since this git ref
+	DiffMode        bool     // with GitDiff, include patches instead of whole files
+	Patch           string   // saved patch file whose changed files are included along with it
+	Infra           bool     // only include Dockerfiles, compose files, CI workflows and deployment manifests
+	Sort            string   // order of the files: path, size, mtime or extension, empty for the selection order
+	SortReverse     bool     // with Sort, reverse the order
+
+	Tree         bool   // open the output with a directory tree
+	TreeEmpty    bool   // show empty and filtered out directories in the tree
+	TreeLabels   bool   // mark the files in the tree with the emoji of their kind
+	DepsSummary  bool   // include the direct dependencies of the manifests
+	BuildTargets bool   // include the targets of the Makefile, Taskfile and justfile
+	DBSchema     string // include the schema of this database
+	Todos        bool   // append the TODO, FIXME and HACK comments
+
+	Exec     string // command whose output the bugreport command captures
+	DiffBase string // ref the review command diffs against
+	Budget   int    // token budget of the onboard command
+	Symbol   string // symbol the refactor command gathers references of
+	RenameTo string // new name the refactor command asks for
+
+	MaxTokens       int    // token budget replacing MaxTotalSize, 0 disables it
+	MaxTokensAction string // abort or trim when over MaxTokens
+	Tokenizer       string // tokenizer estimates are made for
+	Chunk           bool   // split output over the limit into parts
+
+	I18n              string            // localization files: keys, default or all
+	I18nDefault       string            // default language kept with I18n default
+	DecodeDescriptors bool              // render protobuf descriptor sets as schema text
+	ExtractEmail      bool              // reduce .eml and mbox files to headers and text bodies
+	StripFrontMatter  bool              // remove the front matter of markdown files
+	KeepFrontMatter   []string          // front matter keys kept, implies StripFrontMatter
+	ImagePlaceholders bool              // replace local images of markdown and notebooks with placeholders
+	BlameSummary      bool              // note the last change and top authors of each file from git
+	Metadata          bool              // note the size, modification time and line count of each file
+	Raw               bool              // with Metadata, exact byte counts and timestamps instead of rounded sizes and ages
+	IncludeGenerated  bool              // keep generated files, lockfiles and minified code
+	StripComments     bool              // remove comments from recognized source languages
+	Compact           bool              // trim trailing whitespace and collapse runs of blank lines
+	CompactIndent     bool              // with Compact, shrink every indentation level to one space
+	RedactSecrets     bool              // replace secrets with placeholders
+	RedactRules       map[string]string // extra secret patterns by name
+	Template          string            // text/template file wrapping the output
+
+	Force         bool     // run even in a sensitive directory
+	SensitiveDirs []string // extra directories refused without Force
+
+	Concurrency int // files classified and read at once, 0 for 4 per CPU
+
+	Verbose bool      // log every decision to Log
+	Log     io.Writer // receives the progress and Verbose messages, nil discards them
+	Tracer  *Tracer   // records phase and file timings, nil for none
+}
+
+// DefaultOptions returns the options of the clip4llm command without flags
+func DefaultOptions() Options {
+	return Options{
+		Delimiter:       "```",
+		Format:          "delimited",
+		MaxSize:         32,
+		MaxTotalSize:    defaultMaxTotalSize,
+		DepthGuard:      defaultDepthGuard,
+		Hidden:          "skip",
+		ResolveDepth:    3,
+		ResolveBudget:   256,
+		Budget:          32000,
+		MaxTokensAction: "abort",
+		Tokenizer:       tokenizerModels[0].name,
+		I18n:            "all",
+		I18nDefault:     "en",
+	}
+}
+
+// SizeLimit overrides Options.MaxSize for the files whose name matches
+// Pattern. The first matching limit applies.
+type SizeLimit struct {
+	Pattern string // file name pattern, such as *.sql
+	MaxSize int    // maximum size in KB
+}
+
+// LanguageOverride sets the language of the files whose name or path
+// matches Pattern, for names the built-in detection gets wrong. The first
+// matching override applies.
+type LanguageOverride struct {
+	Pattern  string // file name or path pattern, such as Dockerfile* or templates/*.tpl
+	Language string // language name, such as dockerfile or go
+}
+
+// File is a file selected for the output
+type File struct {
+	Path    string // absolute path on disk
+	RelPath string // path relative to the collected directory, prefixed with "./"
+}
+
+// Result is the output of a collection
+type Result struct {
+	Files  []File   // the selected files in output order
+	Output string   // the complete output
+	Chunks []string // the output split into parts with Options.Chunk, nil otherwise
+
+	// Skipped counts the files left out while walking by reason, such as
+	// binary or too large, where a skipped directory counts once
+	Skipped map[string]int
+
+	// DelimiterCollisions lists the files containing a custom delimiter,
+	// which makes their end ambiguous. Markdown fences never collide, they
+	// are lengthened past the fences inside the file instead.
+	DelimiterCollisions []string
+
+	// DepthGuarded lists the directories the walk stopped at because they
+	// are Options.DepthGuard levels deep, relative to the collected directory
+	DepthGuarded []string
+}
+
+// Collector gathers the files of a directory into an output
+type Collector struct {
+	// Pick, when set, curates the selected files before the output is built
+	Pick func(files []File) ([]File, error)
+
+	dir  string
+	o    Options
+	opts *options
+	cmd  *command
+	run  *options // the options of the last collection, as adjusted by the command
+}
+
+// NewCollector validates the options and returns a Collector for dir
+func NewCollector(dir string, o Options) (*Collector, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &options{
+		delimiter:         o.Delimiter,
+		format:            o.Format,
+		maxSize:           o.MaxSize,
+		maxSizes:          o.MaxSizes,
+		languages:         o.Languages,
+		grepContext:       o.GrepContext,
+		maxDepth:          o.MaxDepth,
+		depthGuard:        o.DepthGuard,
+		maxTotalSize:      o.MaxTotalSize,
+		includePatterns:   o.Include,
+		excludePatterns:   o.Exclude,
+		hidden:            o.Hidden,
+		withTests:         o.WithTests,
+		resolveIncludes:   o.ResolveIncludes,
+		resolveDepth:      o.ResolveDepth,
+		resolveBudget:     o.ResolveBudget,
+		entries:           o.Entries,
+		pyModules:         o.PyModules,
+		route:             o.Route,
+		diffMode:          o.DiffMode,
+		diffRef:           o.GitDiff,
+		exec:              o.Exec,
+		diffBase:          o.DiffBase,
+		budget:            o.Budget,
+		symbol:            o.Symbol,
+		renameTo:          o.RenameTo,
+		args:              o.Args,
+		maxTokens:         o.MaxTokens,
+		maxTokensAction:   o.MaxTokensAction,
+		i18nMode:          o.I18n,
+		i18nDefault:       o.I18nDefault,
+		decodeDescriptors: o.DecodeDescriptors,
+		extractEmail:      o.ExtractEmail,
+		stripFrontMatter:  o.StripFrontMatter || len(o.KeepFrontMatter) > 0,
+		keepFrontMatter:   o.KeepFrontMatter,
+		imagePlaceholders: o.ImagePlaceholders,
+		blameSummary:      o.BlameSummary,
+		metadata:          o.Metadata,
+		raw:               o.Raw,
+		includeGenerated:  o.IncludeGenerated,
+		stripComments:     o.StripComments,
+		compact:           o.Compact,
+		compactIndent:     o.CompactIndent,
+		redactSecrets:     o.RedactSecrets,
+		force:             o.Force,
+		sensitiveDirs:     o.SensitiveDirs,
+		concurrency:       o.Concurrency,
+		followSymlinks:    o.FollowSymlinks,
+		verbose:           o.Verbose,
+		log:               o.Log,
+		dir:               dir,
+		trace:             o.Tracer,
+		skipFiles:         make(map[string]bool),
+	}
+
+	var cmd *command
+	if o.Command != "" {
+		var ok bool
+		if cmd, ok = commands[o.Command]; !ok {
+			return nil, fmt.Errorf("unknown command %q", o.Command)
+		}
+	}
+
+	if opts.tokenizer, err = lookupTokenizer(o.Tokenizer); err != nil {
+		return nil, err
+	}
+	if opts.maxTotalSize <= 0 {
+		return nil, fmt.Errorf("invalid maximum total size %d (expected a positive number of bytes)", opts.maxTotalSize)
+	}
+	if o.Truncate != "" {
+		if opts.truncate, err = parseTruncation(o.Truncate); err != nil {
+			return nil, err
+		}
+	}
+	if o.IncludeFrom != "" {
+		patterns, err := readPatternFile(o.IncludeFrom)
+		if err != nil {
+			return nil, fmt.Errorf("reading --include-from: %w", err)
+		}
+		opts.includePatterns = append(patterns, opts.includePatterns...)
+	}
+	if o.ExcludeFrom != "" {
+		patterns, err := readPatternFile(o.ExcludeFrom)
+		if err != nil {
+			return nil, fmt.Errorf("reading --exclude-from: %w", err)
+		}
+		opts.excludePatterns = append(patterns, opts.excludePatterns...)
+	}
+	// Every collection of this Collector walks with the same patterns
+	opts.includeMatcher = newPatternMatcher(opts.includePatterns)
+	opts.excludeMatcher = newPatternMatcher(opts.excludePatterns)
+	if o.Grep != "" {
+		if opts.grep, err = regexp.Compile(o.Grep); err != nil {
+			return nil, fmt.Errorf("invalid --grep %q: %w", o.Grep, err)
+		}
+	}
+	if opts.grepContext < 0 {
+		return nil, fmt.Errorf("invalid --grep-context %d (expected 0 for whole files or a positive number of lines)", opts.grepContext)
+	}
+	if opts.grepContext > 0 && opts.grep == nil {
+		return nil, fmt.Errorf("--grep-context needs --grep")
+	}
+	if opts.maxDepth < 0 {
+		return nil, fmt.Errorf("invalid --max-depth %d (expected 0 for no limit or a positive number of levels)", opts.maxDepth)
+	}
+	if opts.depthGuard < 0 {
+		return nil, fmt.Errorf("invalid --depth-guard %d (expected 0 for no guard or a positive number of levels)", opts.depthGuard)
+	}
+	if opts.concurrency < 0 {
+		return nil, fmt.Errorf("invalid --concurrency %d (expected 0 for the default or a positive number of files)", opts.concurrency)
+	}
+	if opts.maxTokensAction != "abort" && opts.maxTokensAction != "trim" {
+		return nil, fmt.Errorf("invalid --max-tokens-action %q (expected abort or trim)", opts.maxTokensAction)
+	}
+	if opts.format != "delimited" && opts.format != "xml" && opts.format != "json" {
+		return nil, fmt.Errorf("invalid --format %q (expected delimited, xml or json)", opts.format)
+	}
+	if opts.hidden != "skip" && opts.hidden != "list" && opts.hidden != "include" {
+		return nil, fmt.Errorf("invalid --hidden %q (expected skip, list or include)", opts.hidden)
+	}
+	if opts.i18nMode != "keys" && opts.i18nMode != "default" && opts.i18nMode != "all" {
+		return nil, fmt.Errorf("invalid --i18n %q (expected keys, default or all)", opts.i18nMode)
+	}
+	if opts.diffMode && opts.diffRef == "" {
+		return nil, fmt.Errorf("--diff-mode needs the ref to diff against from --git-diff")
+	}
+	if opts.compactIndent && !opts.compact {
+		return nil, fmt.Errorf("--compact-indent needs --compact")
+	}
+	if o.Patch != "" && opts.diffRef != "" {
+		return nil, fmt.Errorf("--patch cannot be combined with --git-diff")
+	}
+	for _, limit := range o.MaxSizes {
+		if _, err := filepath.Match(limit.Pattern, ""); err != nil || limit.MaxSize <= 0 {
+			return nil, fmt.Errorf("invalid max-size %d for %q (expected a pattern and a positive size in KB)", limit.MaxSize, limit.Pattern)
+		}
+	}
+	if o.Sort != "" && !isSortOrder(o.Sort) {
+		return nil, fmt.Errorf("invalid --sort %q (expected path, size, mtime or extension)", o.Sort)
+	}
+	if o.SortReverse && o.Sort == "" {
+		return nil, fmt.Errorf("--sort-reverse needs an order from --sort")
+	}
+	if o.Infra && (o.Command != "" || o.GitDiff != "" || o.Patch != "" || o.Route != "" || len(o.Entries) > 0 || len(o.PyModules) > 0) {
+		return nil, fmt.Errorf("--infra cannot be combined with a command, --git-diff, --patch, --route, --entry or --py-module")
+	}
+	if len(o.Roots) > 0 && (o.Command != "" || len(o.Args) > 0 || o.GitDiff != "" || o.Patch != "" || o.TreeEmpty) {
+		return nil, fmt.Errorf("--root cannot be combined with a command, paths, --git-diff, --patch or --tree-empty")
+	}
+	if o.Template != "" && o.Chunk {
+		return nil, fmt.Errorf("--template cannot be combined with --chunk")
+	}
+
+	if opts.redactRules, err = redactionRules(o.RedactRules); err != nil {
+		return nil, err
+	}
+	if o.GoTags != nil {
+		opts.goTags = goTagSet(o.GoTags)
+	}
+	for _, path := range o.SkipFiles {
+		opts.skipFiles[path] = true
+	}
+
+	return &Collector{dir: dir, o: o, opts: opts, cmd: cmd}, nil
+}
+
+// Collect selects the files, generates the sections of the command and the
+// enabled extras and renders the output, enforcing the output limit.
+func (c *Collector) Collect() (*Result, error) {
+	dir := c.dir
+	// Commands adjust the options, work on a copy so collecting can be repeated
+	run := *c.opts
+	opts := &run
+	opts.skipped = newSkipCounter()
+	c.run = opts
+
+	// Select the files of the directory, or of every root with their paths
+	// prefixed by the root's name
+	var files []fileEntry
+	var sections []section
+	var err error
+	if len(c.o.Roots) > 0 {
+		files, err = c.selectRoots(opts)
+	} else {
+		files, sections, err = c.selectFiles(dir, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Put the files in the requested order instead of the selection order
+	if c.o.Sort != "" {
+		sortFiles(files, c.o.Sort, c.o.SortReverse)
+	}
+
+	// Let the caller curate the final selection
+	if c.Pick != nil {
+		picked, err := c.Pick(exportFiles(files))
+		if err != nil {
+			return nil, err
+		}
+		files = importFiles(picked)
+	}
+
+	// Gather the generated sections emitted ahead of the files
+	if c.o.DepsSummary {
+		summary, err := summarizeDependencies(dir)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, section{title: "Dependencies Summary", content: summary})
+	}
+	if c.o.BuildTargets {
+		summary, err := summarizeBuildTargets(dir)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, section{title: "Build Targets", content: summary})
+	}
+	if c.o.Tree && len(files) > 0 {
+		var paths []string
+		for _, file := range files {
+			paths = append(paths, file.relPath)
+		}
+		var emptyDirs map[string]int
+		if c.o.TreeEmpty {
+			if emptyDirs, err = emptyDirectories(dir, files); err != nil {
+				return nil, err
+			}
+		}
+		sections = append([]section{{title: "Directory Tree", content: renderTree(paths, emptyDirs, c.o.TreeLabels, nil)}}, sections...)
+	}
+	if len(opts.hiddenListed) > 0 {
+		sections = append(sections, section{title: "Hidden Files (not included)", content: strings.Join(opts.hiddenListed, "\n")})
+	}
+	if c.o.DBSchema != "" {
+		schema, err := dumpDatabaseSchema(c.o.DBSchema)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, section{title: "Database Schema: " + redactDSN(c.o.DBSchema), content: schema})
+	}
+
+	if c.o.Todos {
+		if list := collectTodos(files); list != "" {
+			sections = append(sections, section{title: "TODO, FIXME and HACK Comments", content: list, trailing: true})
+		} else if opts.verbose {
+			opts.logf("No TODO, FIXME or HACK comments found\n")
+		}
+	}
+
+	// Assemble the sections and file contents into the final output, or into
+	// parts that each fit the limit in chunk mode
+	result := &Result{Files: exportFiles(files), Skipped: opts.skipped.snapshot()}
+	endFormat := opts.trace.Begin("format", "")
+	if c.o.Chunk {
+		result.Chunks, err = buildChunks(files, sections, opts)
+		result.Output = strings.Join(result.Chunks, "")
+	} else {
+		result.Output, err = buildOutput(files, sections, opts)
+	}
+
+	// Wrap the output in the user's prompt template
+	if err == nil && c.o.Template != "" {
+		result.Output, err = applyTemplate(c.o.Template, result.Output, files, opts)
+	}
+	endFormat()
+	result.DelimiterCollisions = opts.collisions
+	result.DepthGuarded = opts.guarded
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// selectFiles selects the files of dir to include along with the sections
+// generated ahead of them by the patch and the command
+func (c *Collector) selectFiles(dir string, opts *options) ([]fileEntry, []section, error) {
+	var err error
+
+	// Refuse to copy the home directory, the filesystem root and the like
+	if err := checkSensitiveDir(dir, opts); err != nil {
+		return nil, nil, err
+	}
+
+	// Restrict the walk to the files git tracks
+	if c.o.GitTracked {
+		if opts.tracked, err = gitTrackedFiles(dir); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Skip what the .clip4llmignore files of the directories ignore
+	opts.ignoreFiles = newIgnoreFiles(dir, opts.verboseLog())
+
+	// Skip what a Mercurial repository ignores, git users have --git-tracked
+	if opts.hgIgnore, err = loadHgIgnore(dir, opts.verboseLog()); err != nil {
+		return nil, nil, err
+	}
+
+	// Keep the files of the wanted code owners
+	if opts.codeOwners, err = loadCodeOwners(dir, c.o.Owners, c.o.NotOwners, opts.verboseLog()); err != nil {
+		return nil, nil, err
+	}
+
+	// Select exactly the files changed since the ref
+	if opts.diffRef != "" {
+		if err := verifyRef(dir, opts.diffRef); err != nil {
+			return nil, nil, err
+		}
+		if opts.paths, err = gitChangedFiles(dir, opts.diffRef); err != nil {
+			return nil, nil, err
+		}
+		if opts.paths == nil {
+			opts.paths = []string{}
+		}
+		if opts.verbose {
+			opts.logf("Files changed since %s: %v\n", opts.diffRef, opts.paths)
+		}
+	}
+
+	// Select the files a saved patch changes, with the patch ahead of them
+	var sections []section
+	if c.o.Patch != "" {
+		if sections, err = preparePatch(dir, c.o.Patch, opts); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Narrow the selection down to the infrastructure files
+	if c.o.Infra {
+		if err := prepareInfra(dir, opts); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Let the command adjust the options and generate its sections before selection
+	if c.cmd != nil {
+		generated, err := c.cmd.prepare(dir, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		sections = append(sections, generated...)
+	}
+
+	// Select the files to include, either from the import graph of the entry
+	// files and Python modules, the route's handlers, the explicit paths chosen
+	// by a command, the paths given as arguments or by walking through the
+	// directory
+	endWalk := opts.trace.Begin("walk", "")
+	var files []fileEntry
+	var named []string // the files picked by name rather than by walking
+	if len(opts.entries) > 0 || len(opts.pyModules) > 0 {
+		named = opts.entries
+		roots := pythonRoots(dir)
+		starts := append([]string{}, opts.entries...)
+		for _, module := range opts.pyModules {
+			path, err := resolvePyModule(roots, module)
+			if err != nil {
+				return nil, nil, err
+			}
+			starts = append(starts, path)
+		}
+		files, err = collectClosure(dir, starts, combineDependencies(jsDependencies(dir), pyDependencies(roots)), opts)
+	} else if opts.route != "" {
+		files, err = collectRoute(dir, opts.route, opts)
+	} else if opts.paths != nil {
+		named = opts.paths
+		files, err = collectClosure(dir, opts.paths, noDependencies, opts)
+	} else if c.cmd == nil && len(opts.args) > 0 {
+		named = opts.args
+		files, err = collectPaths(dir, opts.args, opts)
+	} else {
+		files, err = collectFiles(dir, opts)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Pull in the test files paired with each selected source file and vice versa
+	if opts.withTests {
+		files = addTestPairs(dir, files, opts)
+	}
+
+	// Pull in the project-local headers referenced by the C/C++ files named
+	if opts.resolveIncludes {
+		files = addIncludedHeaders(dir, files, namedFiles(dir, named), opts)
+	}
+
+	// Keep only the default language of the localization files
+	if opts.i18nMode == "default" {
+		files = filterI18nFiles(files, opts)
+	}
+	endWalk()
+	return files, sections, nil
+}
+
+// selectRoots selects the files of every root directory in turn, with their
+// paths prefixed by the name of the root
+func (c *Collector) selectRoots(opts *options) ([]fileEntry, error) {
+	var files []fileEntry
+	for i, name := range rootNames(c.o.Roots) {
+		rootOpts := *opts
+		rootFiles, _, err := c.selectFiles(c.o.Roots[i], &rootOpts)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range rootFiles {
+			// Rooted like the paths of a single directory, below the root's name
+			file.relPath = "./" + name + "/" + strings.TrimPrefix(filepath.ToSlash(file.relPath), "./")
+			files = append(files, file)
+		}
+		for _, listed := range rootOpts.hiddenListed {
+			opts.hiddenListed = append(opts.hiddenListed, name+"/"+listed)
+		}
+	}
+	return files, nil
+}
+
+// rootNames names every root directory after its base name, numbering the
+// repeated names so each root stays distinguishable
+func rootNames(roots []string) []string {
+	names := make([]string, len(roots))
+	seen := make(map[string]int)
+	for i, root := range roots {
+		name := filepath.Base(filepath.Clean(root))
+		seen[name]++
+		if seen[name] > 1 {
+			name = fmt.Sprintf("%s-%d", name, seen[name])
+		}
+		names[i] = name
+	}
+	return names
+}
+
+// Content returns the content of file as the output includes it, after the
+// transformations of the last collection
+func (c *Collector) Content(file File) ([]byte, error) {
+	opts := c.opts
+	if c.run != nil {
+		opts = c.run
+	}
+	content, err := readFileContent(file.Path, opts)
+	if err != nil {
+		return nil, err
+	}
+	return transformContent(file.RelPath, content, opts), nil
+}
+
+// Report summarizes the result of the last collection, whose output went to
+// delivery: none, stdout, file or clipboard
+func (c *Collector) Report(result *Result, delivery string) Report {
+	opts := c.opts
+	if c.run != nil {
+		opts = c.run
+	}
+	return buildReport(c.dir, importFiles(result.Files), result.Output, delivery, opts)
+}
+
+// Commands returns the description of every prompt preset by name
+func Commands() map[string]string {
+	descriptions := make(map[string]string, len(commands))
+	for name, cmd := range commands {
+		descriptions[name] = cmd.description
+	}
+	return descriptions
+}
+
+// Tokenizers returns the names of the tokenizers estimates are made for, the
+// default first
+func Tokenizers() []string {
+	var names []string
+	for _, model := range tokenizerModels {
+		names = append(names, model.name)
+	}
+	return names
+}
+
+// EstimateTokens returns the estimated number of tokens text consumes with
+// the named tokenizer
+func EstimateTokens(text string, tokenizer string) (int, error) {
+	model, err := lookupTokenizer(tokenizer)
+	if err != nil {
+		return 0, err
+	}
+	return model.estimate(text), nil
+}
+
+// IsDocument reports whether the file at path is documentation, such as
+// Markdown, plain text or a README, judged by its name
+func IsDocument(path string) bool {
+	return fileKind(path) == "doc"
+}
+
+// exportFiles converts selected files to their public form
+func exportFiles(files []fileEntry) []File {
+	exported := make([]File, 0, len(files))
+	for _, file := range files {
+		exported = append(exported, File{Path: file.path, RelPath: file.relPath})
+	}
+	return exported
+}
+
+// importFiles converts public files back to their internal form
+func importFiles(files []File) []fileEntry {
+	imported := make([]fileEntry, 0, len(files))
+	for _, file := range files {
+		imported = append(imported, fileEntry{path: file.Path, relPath: file.RelPath})
+	}
+	return imported
+}
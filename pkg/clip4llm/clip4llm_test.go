@@ -0,0 +1,147 @@
+package clip4llm
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCollectorCollect(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"main.go":   "package main\n",
+		"README.md": "# readme\n",
+		"skip.txt":  "skipped\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := DefaultOptions()
+	opts.Exclude = []string{"*.md"}
+	opts.SkipFiles = []string{filepath.Join(dir, "skip.txt")}
+	collector, err := NewCollector(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var offered []string
+	collector.Pick = func(files []File) ([]File, error) {
+		for _, file := range files {
+			offered = append(offered, file.RelPath)
+		}
+		return files, nil
+	}
+
+	result, err := collector.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Files) != 1 || result.Files[0].RelPath != "./main.go" {
+		t.Fatalf("Files = %v, want only ./main.go", result.Files)
+	}
+	if len(offered) != 1 {
+		t.Errorf("Pick was offered %v", offered)
+	}
+	if !strings.Contains(result.Output, "./main.go") || !strings.Contains(result.Output, "package main") {
+		t.Errorf("Output is missing main.go:\n%s", result.Output)
+	}
+	if report := collector.Report(result, "none"); report.Totals.Files != 1 || report.Files[0].Path != "./main.go" {
+		t.Errorf("Report = %+v", report)
+	}
+	if result.Skipped[skipExcluded] != 1 {
+		t.Errorf("Skipped = %v, want README.md counted as excluded", result.Skipped)
+	}
+}
+
+func TestNewCollectorRejectsInvalidOptions(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Format = "yaml"
+	if _, err := NewCollector(t.TempDir(), opts); err == nil || !strings.Contains(err.Error(), "yaml") {
+		t.Errorf("expected an error naming the format, got %v", err)
+	}
+
+	opts = DefaultOptions()
+	opts.Command = "nope"
+	if _, err := NewCollector(t.TempDir(), opts); err == nil {
+		t.Error("expected an error for an unknown command")
+	}
+
+	opts = DefaultOptions()
+	opts.Grep = "Payment("
+	if _, err := NewCollector(t.TempDir(), opts); err == nil || !strings.Contains(err.Error(), "--grep") {
+		t.Errorf("expected an error naming --grep, got %v", err)
+	}
+}
+
+func TestCollectorCollectRoots(t *testing.T) {
+	base := t.TempDir()
+	roots := []string{filepath.Join(base, "api"), filepath.Join(base, "web"), filepath.Join(base, "other", "api")}
+	for _, root := range roots {
+		if err := os.MkdirAll(root, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := DefaultOptions()
+	opts.Roots = roots
+	collector, err := NewCollector(base, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := collector.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, file := range result.Files {
+		paths = append(paths, file.RelPath)
+	}
+	want := []string{"./api/main.go", "./web/main.go", "./api-2/main.go"}
+	if strings.Join(paths, " ") != strings.Join(want, " ") {
+		t.Errorf("RelPaths = %v, want %v", paths, want)
+	}
+}
+
+func TestCollectorPathsAndLog(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"a.go": "package a\n",
+		"b.go": strings.Repeat("// filler\n", 100),
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Relative paths name files of the collected directory, not of the
+	// working directory, and messages go to Log only
+	var log bytes.Buffer
+	opts := DefaultOptions()
+	opts.Args = []string{"a.go", "b.go"}
+	opts.MaxTokens = 100
+	opts.MaxTokensAction = "trim"
+	opts.Verbose = true
+	opts.Log = &log
+	collector, err := NewCollector(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := collector.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result.Output, "package a") || strings.Contains(result.Output, "filler") {
+		t.Errorf("Output should hold a.go without the trimmed b.go:\n%s", result.Output)
+	}
+	for _, want := range []string{"Trimming file to fit the token budget: ./b.go\n", "Trimmed 1 of 2 files"} {
+		if !strings.Contains(log.String(), want) {
+			t.Errorf("log is missing %q:\n%s", want, log.String())
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Locations of the CODEOWNERS file relative to the repository root, in the
+// order GitHub looks for it
+var codeOwnersFiles = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// ownerRule assigns the owners to the files matching pattern
+type ownerRule struct {
+	pattern string // glob in matchGlob syntax, relative to the root
+	subtree bool   // the pattern also matches everything below a matching directory
+	owners  []string
+}
+
+// codeOwners selects files by the owners a CODEOWNERS file assigns them
+type codeOwners struct {
+	root   string // the directory the rules are relative to
+	rules  []ownerRule
+	want   []string // owners of the files kept, empty keeps all
+	reject []string // owners of the files left out
+}
+
+// loadCodeOwners finds the CODEOWNERS file of the repository holding dir and
+// returns the filter keeping the files owned by one of want and none of
+// reject, or nil when both are empty. log receives the verbose messages, nil
+// for none.
+func loadCodeOwners(dir string, want []string, reject []string, log io.Writer) (*codeOwners, error) {
+	if len(want) == 0 && len(reject) == 0 {
+		return nil, nil
+	}
+	for root := dir; ; root = filepath.Dir(root) {
+		for _, name := range codeOwnersFiles {
+			path := filepath.Join(root, filepath.FromSlash(name))
+			content, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			owners := &codeOwners{root: root, rules: parseCodeOwners(string(content)), want: want, reject: reject}
+			if log != nil {
+				fmt.Fprintf(log, "Loaded %d rules from %s\n", len(owners.rules), path)
+			}
+			return owners, nil
+		}
+		if filepath.Dir(root) == root {
+			return nil, fmt.Errorf("--owner and --not-owner need a CODEOWNERS file, none found in %s or its parents", dir)
+		}
+	}
+}
+
+// parseCodeOwners returns the rules of a CODEOWNERS file. Like .gitignore a
+// pattern without a slash matches at any depth, a leading slash anchors it to
+// the root and a trailing slash matches a directory and all it holds.
+func parseCodeOwners(content string) []ownerRule {
+	var rules []ownerRule
+	for _, line := range strings.Split(content, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		pattern := fields[0]
+		anchored := strings.Contains(strings.TrimSuffix(pattern, "/"), "/")
+		pattern = strings.Trim(pattern, "/")
+		if !anchored {
+			pattern = "**/" + pattern
+		}
+		// GitHub documents docs/* as matching only the files directly in docs
+		rules = append(rules, ownerRule{pattern: pattern, subtree: !strings.HasSuffix(pattern, "/*"), owners: fields[1:]})
+	}
+	return rules
+}
+
+// owners returns the owners of the file at path, from the last rule matching
+// it as in GitHub
+func (c *codeOwners) owners(path string) []string {
+	rel, err := filepath.Rel(c.root, path)
+	if err != nil {
+		return nil
+	}
+	rel = filepath.ToSlash(rel)
+	for i := len(c.rules) - 1; i >= 0; i-- {
+		rule := c.rules[i]
+		if matched, _ := matchGlob(rule.pattern, rel); matched {
+			return rule.owners
+		}
+		if matched, _ := matchGlob(rule.pattern+"/**", rel); matched && rule.subtree {
+			return rule.owners
+		}
+	}
+	return nil
+}
+
+// keeps reports whether the file at path is owned by one of the wanted owners
+// and none of the rejected ones. A nil codeOwners keeps every file.
+func (c *codeOwners) keeps(path string) bool {
+	if c == nil {
+		return true
+	}
+	owners := c.owners(path)
+	if len(c.want) > 0 && !containsOwner(owners, c.want) {
+		return false
+	}
+	return !containsOwner(owners, c.reject)
+}
+
+// containsOwner reports whether any of owners is one of names, ignoring case
+// like GitHub does
+func containsOwner(owners []string, names []string) bool {
+	for _, owner := range owners {
+		for _, name := range names {
+			if strings.EqualFold(owner, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
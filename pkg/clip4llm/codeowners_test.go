@@ -0,0 +1,49 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCodeOwners(t *testing.T) {
+	root := t.TempDir()
+	content := "# Default owners\n*       @org/core\n*.js    @org/web\n/docs/* @org/docs\napps/   @org/apps\n/payments/ @org/team-payments @alice\n"
+	if err := os.MkdirAll(filepath.Join(root, ".github"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".github", "CODEOWNERS"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	owners, err := loadCodeOwners(filepath.Join(root, "payments"), []string{"@ORG/team-payments"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]string{
+		"main.go":                   "@org/core",
+		"web/app.js":                "@org/web",
+		"docs/intro.md":             "@org/docs",
+		"docs/guides/setup.md":      "@org/core",
+		"services/apps/api/main.go": "@org/apps",
+		"payments/ledger/ledger.go": "@org/team-payments",
+	}
+	for rel, want := range cases {
+		got := owners.owners(filepath.Join(root, filepath.FromSlash(rel)))
+		if len(got) == 0 || got[0] != want {
+			t.Errorf("owners(%s) = %v, want %s first", rel, got, want)
+		}
+	}
+
+	if !owners.keeps(filepath.Join(root, "payments", "api.go")) || owners.keeps(filepath.Join(root, "main.go")) {
+		t.Error("--owner should keep only the files of the wanted team, ignoring case")
+	}
+	owners.want, owners.reject = nil, []string{"@alice"}
+	if owners.keeps(filepath.Join(root, "payments", "api.go")) || !owners.keeps(filepath.Join(root, "main.go")) {
+		t.Error("--not-owner should leave out the files of the rejected owner")
+	}
+
+	if _, err := loadCodeOwners(t.TempDir(), []string{"@org/core"}, nil, nil); err == nil {
+		t.Error("expected an error without a CODEOWNERS file")
+	}
+}
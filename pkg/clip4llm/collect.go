@@ -0,0 +1,750 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// options holds the effective settings for a run once flags and config are merged
+type options struct {
+	diffMode          bool            // emit the patch against diffRef instead of the whole file
+	diffRef           string          // git ref given to --git-diff
+	hidden            string          // skip, list or include hidden files and directories
+	hiddenListed      []string        // hidden entries skipped under the list policy
+	tracked           map[string]bool // git tracked files and their directories, nil when not restricted
+	hgIgnore          *hgIgnore       // patterns of the Mercurial repository's .hgignore, nil when there is none
+	codeOwners        *codeOwners     // files kept by their CODEOWNERS owners, nil when not filtering
+	ignoreFiles       *ignoreFiles    // rules of the .clip4llmignore files below the root
+	grep              *regexp.Regexp  // content every file must match, nil for all
+	grepContext       int             // lines kept around each grep match, 0 for whole files
+	force             bool            // run even in a sensitive directory
+	sensitiveDirs     []string        // extra directories refused without force
+	format            string          // delimited (the default), xml or json
+	delimiter         string
+	languages         []LanguageOverride // languages overriding detection by file name pattern
+	maxSize           int
+	maxSizes          []SizeLimit // maxSize overrides by file name pattern
+	maxDepth          int         // directory levels walked below the root, 0 for no limit
+	depthGuard        int         // directory levels walked before stopping with a warning
+	guarded           []string    // directories the depth guard stopped at
+	truncate          *truncation // include the start of files over maxSize, nil to skip them
+	maxTotalSize      int         // output size limit in bytes when there is no token budget
+	verbose           bool
+	log               io.Writer // receives the messages of the run, nil discards them
+	dir               string    // collected directory the relative paths start from
+	includePatterns   []string
+	excludePatterns   []string
+	includeMatcher    *patternMatcher // includePatterns with the outcomes of earlier walks
+	excludeMatcher    *patternMatcher // excludePatterns with the outcomes of earlier walks
+	goTags            map[string]bool
+	withTests         bool
+	resolveIncludes   bool
+	resolveDepth      int
+	resolveBudget     int
+	entries           []string
+	pyModules         []string
+	route             string
+	exec              string
+	diffBase          string
+	budget            int
+	args              []string // positional arguments after the flags
+	maxTokens         int
+	maxTokensAction   string
+	tokenizer         *tokenizerModel
+	i18nMode          string // keys, default or all
+	i18nDefault       string // default language of localization files
+	symbol            string
+	declarationsOnly  bool            // reduce sources to exported declarations and doc comments
+	stripComments     bool            // remove comments from recognized source languages
+	compact           bool            // trim trailing whitespace and collapse blank lines
+	compactIndent     bool            // with compact, shrink every indentation level to one space
+	decodeDescriptors bool            // render compiled protobuf descriptor sets as schema text
+	extractEmail      bool            // reduce .eml and mbox files to headers and text bodies
+	blameSummary      bool            // note the last change and top authors of each file from git
+	metadata          bool            // note the size, modification time and line count of each file
+	raw               bool            // exact sizes and timestamps in the metadata notes
+	stripFrontMatter  bool            // remove the front matter of markdown files
+	keepFrontMatter   []string        // front matter keys kept when stripping
+	imagePlaceholders bool            // replace local images of markdown and notebooks with placeholders
+	includeGenerated  bool            // keep generated files, lockfiles and minified code
+	redactRules       []redactionRule // built-in and configured secret patterns
+	redactSecrets     bool            // replace secrets with placeholders
+	renameTo          string
+	skipFiles         map[string]bool // absolute paths never included, such as the output file
+	trace             *Tracer         // phase and file timings, nil when not tracing
+	skipped           *skipCounter    // entries left out by reason, nil when not counting
+	collisions        []string        // files containing the custom delimiter, which cannot be lengthened
+	followSymlinks    bool            // walk symlinked directories and include symlinked files
+	concurrency       int             // files classified and read at once, 0 for defaultConcurrency
+	paths             []string        // explicit files to include instead of walking
+	preamble          string          // prompt text placed before everything else
+}
+
+// logf writes a message of the run to the log writer, if there is one
+func (opts *options) logf(format string, args ...any) {
+	if opts.log != nil {
+		fmt.Fprintf(opts.log, format, args...)
+	}
+}
+
+// verboseLog returns the writer of the verbose messages, nil when not verbose
+func (opts *options) verboseLog() io.Writer {
+	if !opts.verbose {
+		return nil
+	}
+	return opts.log
+}
+
+// fileEntry is a single file selected for output
+type fileEntry struct {
+	path    string // absolute path on disk
+	relPath string // path relative to the root directory, prefixed with "./"
+}
+
+// section is a block of generated context (such as a database schema) that is
+// emitted ahead of the file contents, or after them when trailing
+type section struct {
+	title    string
+	content  string
+	trailing bool
+}
+
+// collectFiles walks the directory tree rooted at dir and returns the files
+// that pass the exclude, hidden, size, build tag and binary checks.
+func collectFiles(dir string, opts *options) ([]fileEntry, error) {
+	return walkFiles(dir, dir, opts)
+}
+
+// collectPaths selects the files and directory trees named on the command
+// line, in the order given and without duplicates. Named paths are taken
+// even when hidden or excluded, the files below named directories are not.
+func collectPaths(dir string, paths []string, opts *options) ([]fileEntry, error) {
+	var files []fileEntry
+	selected := make(map[string]bool)
+
+	for _, arg := range paths {
+		// Relative paths are relative to the collected directory
+		path := arg
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var found []fileEntry
+		if info.IsDir() {
+			if err := checkSensitiveDir(path, opts); err != nil {
+				return nil, err
+			}
+			found, err = walkFiles(dir, path, opts)
+			if err != nil {
+				return nil, err
+			}
+		} else if isEligibleFile(path, info, opts) {
+			relPath, err := relativePath(dir, path)
+			if err != nil {
+				return nil, err
+			}
+			found = []fileEntry{{path: path, relPath: relPath}}
+		}
+
+		for _, file := range found {
+			if !selected[file.path] {
+				selected[file.path] = true
+				files = append(files, file)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// walkFiles walks the directory tree rooted at root and returns the files
+// that pass the exclude, hidden, size, build tag and binary checks, with
+// paths relative to dir. The root itself is never skipped.
+func walkFiles(dir string, root string, opts *options) ([]fileEntry, error) {
+	var candidates []string
+
+	// The real directories walked so far, a symlink back to one is a cycle
+	visited := make(map[string]bool)
+	if real, err := filepath.EvalSymlinks(root); err == nil {
+		visited[real] = true
+	}
+
+	// The walk applies the rules that only need names, the per-file checks
+	// that stat and read each file run concurrently afterwards
+	var visit fs.WalkDirFunc
+	visit = func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root && entry.IsDir() {
+			return nil
+		}
+
+		// Get the base name and relative path of the file/directory
+		name := entry.Name()
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		// Stop descending once the files inside would be deeper than the limit
+		if opts.maxDepth > 0 && entry.IsDir() && walkDepth(root, path) >= opts.maxDepth {
+			if opts.verbose {
+				opts.logf("Skipping directory (deeper than --max-depth): %s\n", path)
+			}
+			opts.skipped.add(skipTooDeep)
+			return filepath.SkipDir
+		}
+
+		// Check if the file/directory matches any exclude patterns
+		excluded, err := opts.excludeMatcher.matches(name, rel)
+		if err != nil {
+			if opts.verbose {
+				opts.logf("Error matching exclude patterns for %s: %v\n", path, err)
+			}
+			// In case of error, do not exclude
+			excluded = false
+		}
+		if excluded {
+			opts.skipped.add(skipExcluded)
+			if entry.IsDir() {
+				if opts.verbose {
+					opts.logf("Excluding directory (matched exclude pattern): %s\n", path)
+				}
+				return filepath.SkipDir // Skip the entire directory
+			}
+			if opts.verbose {
+				opts.logf("Excluding file (matched exclude pattern): %s\n", path)
+			}
+			return nil // Skip the file
+		}
+
+		// Leave out what the .clip4llmignore files along the way ignore
+		if opts.ignoreFiles.ignored(path, entry.IsDir()) {
+			if opts.verbose {
+				opts.logf("Skipping file/directory ignored by %s: %s\n", ignoreFileName, path)
+			}
+			opts.skipped.add(skipIgnored)
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Leave out what the Mercurial repository ignores
+		if opts.hgIgnore.ignores(path) {
+			if opts.verbose {
+				opts.logf("Skipping file/directory ignored by .hgignore: %s\n", path)
+			}
+			opts.skipped.add(skipHgIgnore)
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Stop at the depth guard, which only runaway trees reach
+		if entry.IsDir() && pastDepthGuard(root, path, rel, opts) {
+			return filepath.SkipDir
+		}
+
+		// Only walk the files tracked by git and the directories holding them
+		if opts.tracked != nil && !opts.tracked[path] {
+			if opts.verbose {
+				opts.logf("Skipping untracked file/directory: %s\n", path)
+			}
+			opts.skipped.add(skipUntracked)
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Handle hidden files and directories
+		if strings.HasPrefix(name, ".") {
+			// Check if the hidden file/directory matches any include patterns
+			included, err := opts.includeMatcher.matches(name, rel)
+			if err != nil {
+				if opts.verbose {
+					opts.logf("Error matching include patterns for %s: %v\n", path, err)
+				}
+				// In case of error, do not include
+				included = false
+			}
+
+			// The include policy takes every hidden entry except the version control metadata
+			if !included && opts.hidden == "include" && !vcsMetadataDirs[name] {
+				included = true
+			}
+
+			if !included {
+				if opts.verbose {
+					opts.logf("Skipping hidden file/directory: %s\n", path)
+				}
+				opts.skipped.add(skipHidden)
+				// The list policy records the entry so it can be named without its content
+				if opts.hidden == "list" {
+					listed := filepath.ToSlash(rel)
+					if entry.IsDir() {
+						listed += "/"
+					}
+					opts.hiddenListed = append(opts.hiddenListed, listed)
+				}
+				if entry.IsDir() {
+					return filepath.SkipDir // Skip the entire hidden directory
+				}
+				return nil // Skip the hidden file
+			}
+			// If the hidden file/directory is in the include patterns or policy, proceed
+			if opts.verbose {
+				opts.logf("Including hidden file/directory: %s\n", path)
+			}
+		}
+
+		// If it's a directory (and not skipped), continue traversing
+		if entry.IsDir() {
+			if opts.verbose {
+				opts.logf("Entering directory: %s\n", path)
+			}
+			return nil
+		}
+
+		// Never include a previous run's output or report file
+		if opts.skipFiles[path] {
+			if opts.verbose {
+				opts.logf("Skipping output file: %s\n", path)
+			}
+			return nil
+		}
+
+		// Symlinks may point anywhere, they are only followed when asked
+		if entry.Type()&fs.ModeSymlink != 0 {
+			if !opts.followSymlinks {
+				if opts.verbose {
+					opts.logf("Skipping symlink (not following symlinks): %s\n", path)
+				}
+				opts.skipped.add(skipSymlink)
+				return nil
+			}
+			target, err := os.Stat(path)
+			if err != nil {
+				if opts.verbose {
+					opts.logf("Skipping broken symlink: %s\n", path)
+				}
+				opts.skipped.add(skipSymlink)
+				return nil
+			}
+			if target.IsDir() {
+				if pastDepthGuard(root, path, rel, opts) {
+					return nil
+				}
+				return walkSymlinkedDir(root, path, visited, visit, opts)
+			}
+		}
+
+		// Only keep the files of the selected code owners
+		if !opts.codeOwners.keeps(path) {
+			if opts.verbose {
+				opts.logf("Skipping file (not owned by the selected owners): %s\n", path)
+			}
+			opts.skipped.add(skipOwner)
+			return nil
+		}
+
+		candidates = append(candidates, path)
+		return nil
+	}
+	if err := walkTree(root, visit); err != nil {
+		return nil, err
+	}
+
+	return classifyFiles(dir, candidates, opts)
+}
+
+// walkSymlinkedDir walks the directory the symlink at path points to with
+// visit, reporting its entries below path. Directories already walked and
+// those holding the link itself are skipped, as following them would loop.
+func walkSymlinkedDir(root string, path string, visited map[string]bool, visit fs.WalkDirFunc, opts *options) error {
+	if opts.maxDepth > 0 && walkDepth(root, path) >= opts.maxDepth {
+		if opts.verbose {
+			opts.logf("Skipping directory (deeper than --max-depth): %s\n", path)
+		}
+		opts.skipped.add(skipTooDeep)
+		return nil
+	}
+
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return err
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	if visited[real] || parent == real || strings.HasPrefix(parent, real+string(filepath.Separator)) {
+		if opts.verbose {
+			opts.logf("Skipping symlink (cycle or already walked): %s -> %s\n", path, real)
+		}
+		opts.skipped.add(skipSymlink)
+		return nil
+	}
+	visited[real] = true
+	if opts.verbose {
+		opts.logf("Following symlinked directory: %s -> %s\n", path, real)
+	}
+
+	return walkTree(real, func(target string, entry fs.DirEntry, err error) error {
+		// The link itself has been checked already
+		if target == real {
+			return err
+		}
+		rel, relErr := filepath.Rel(real, target)
+		if relErr != nil {
+			return relErr
+		}
+		return visit(filepath.Join(path, rel), entry, err)
+	})
+}
+
+// pastDepthGuard reports whether the directory at path is as deep below root
+// as the depth guard allows, recording it by its path rel so the run can warn
+// where the guard stopped the walk
+func pastDepthGuard(root string, path string, rel string, opts *options) bool {
+	if opts.depthGuard <= 0 || walkDepth(root, path) < opts.depthGuard {
+		return false
+	}
+	if opts.verbose {
+		opts.logf("Skipping directory (deeper than --depth-guard): %s\n", path)
+	}
+	opts.skipped.add(skipTooDeep)
+	opts.guarded = append(opts.guarded, filepath.ToSlash(rel))
+	return true
+}
+
+// walkDepth returns how many levels below root the entry at path is, 1 for
+// the entries directly in it
+func walkDepth(root string, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// Number of files classified and read concurrently unless set otherwise.
+// Both are dominated by stat and read calls, so it pays to have more in
+// flight than there are CPUs, especially on network filesystems.
+var defaultConcurrency = 4 * runtime.GOMAXPROCS(0)
+
+// forEachFile calls fn with every index below n from up to opts.concurrency
+// goroutines and returns once all calls are done. Results are expected in
+// slices indexed by i, so the order of the output never depends on timing.
+func forEachFile(n int, opts *options, fn func(i int)) {
+	workers := opts.concurrency
+	if workers <= 0 {
+		workers = defaultConcurrency
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// classifyFiles stats the candidate files in parallel and returns those that
+// pass isEligibleFile, in the order given, with paths relative to dir.
+func classifyFiles(dir string, paths []string, opts *options) ([]fileEntry, error) {
+	eligible := make([]bool, len(paths))
+	errs := make([]error, len(paths))
+
+	forEachFile(len(paths), opts, func(i int) {
+		info, err := os.Lstat(paths[i])
+		// Symlinks only get here when followed, check the file they point to
+		if err == nil && info.Mode()&os.ModeSymlink != 0 {
+			info, err = os.Stat(paths[i])
+		}
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		eligible[i] = isEligibleFile(paths[i], info, opts)
+	})
+
+	var files []fileEntry
+	for i, path := range paths {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if !eligible[i] {
+			continue
+		}
+		relPath, err := relativePath(dir, path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, fileEntry{path: path, relPath: relPath})
+	}
+	return files, nil
+}
+
+// maxSizeFor returns the maximum size in KB of the file at path, from the
+// first size limit matching its name or maxSize
+func (opts *options) maxSizeFor(path string) int {
+	for _, limit := range opts.maxSizes {
+		if matched, _ := filepath.Match(limit.Pattern, filepath.Base(path)); matched {
+			return limit.MaxSize
+		}
+	}
+	return opts.maxSize
+}
+
+// isEligibleFile applies the per-file content checks (size, build tags and
+// binary detection) shared by every way a file can be selected.
+func isEligibleFile(path string, info os.FileInfo, opts *options) bool {
+	defer opts.trace.Begin("classify", path)()
+
+	// Skip files larger than the specified max size, unless they are truncated
+	maxSizeBytes := int64(opts.maxSizeFor(path)) * 1024
+	if info.Size() > maxSizeBytes && opts.truncate == nil {
+		if opts.verbose {
+			opts.logf("Skipping large file (%.2f KB): %s\n", float64(info.Size())/1024, path)
+		}
+		opts.skipped.add(skipTooLarge)
+		return false
+	}
+
+	// Skip generated files, lockfiles and minified code unless asked for
+	if !opts.includeGenerated && isGeneratedFile(path) {
+		if opts.verbose {
+			opts.logf("Skipping generated file: %s\n", path)
+		}
+		opts.skipped.add(skipGenerated)
+		return false
+	}
+
+	// Skip Go files that would not be built under the requested tags
+	if opts.goTags != nil && strings.HasSuffix(info.Name(), ".go") {
+		matches, err := goFileMatchesTags(path, opts.goTags)
+		if err != nil {
+			if opts.verbose {
+				opts.logf("Error checking Go build constraints: %s\n", path)
+			}
+			return false
+		}
+		if !matches {
+			if opts.verbose {
+				opts.logf("Skipping Go file excluded by build tags: %s\n", path)
+			}
+			opts.skipped.add(skipBuildTags)
+			return false
+		}
+	}
+
+	// Compiled protobuf descriptor sets are binary but decode to their schema
+	if opts.decodeDescriptors && isDescriptorFile(path) {
+		content, err := os.ReadFile(path)
+		if err == nil {
+			if _, err = decodeDescriptorSet(content); err == nil {
+				return true
+			}
+		}
+		if opts.verbose {
+			opts.logf("Not a decodable descriptor set: %s\n", path)
+		}
+	}
+
+	// Check if the file is binary
+	isBinary, err := isBinaryFile(path, opts.maxSizeFor(path))
+	if err != nil {
+		if opts.verbose {
+			opts.logf("Error checking if file is binary: %s\n", path)
+		}
+		return false
+	}
+	if isBinary {
+		if opts.verbose {
+			opts.logf("Skipping binary file: %s\n", path)
+		}
+		opts.skipped.add(skipBinary)
+		return false
+	}
+
+	// Only keep the files whose content matches --grep
+	if opts.grep != nil {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if opts.verbose {
+				opts.logf("Error reading file for --grep: %s\n", path)
+			}
+			return false
+		}
+		if !opts.grep.Match(content) {
+			if opts.verbose {
+				opts.logf("Skipping file (no match for --grep): %s\n", path)
+			}
+			opts.skipped.add(skipNoMatch)
+			return false
+		}
+	}
+
+	return true
+}
+
+// relativePath returns the path of file relative to dir, ensuring it starts with "./"
+func relativePath(dir string, path string) (string, error) {
+	relPath, err := filepath.Rel(dir, path)
+	if err != nil {
+		return "", err
+	}
+	// Show decomposed macOS names like everyone else writes them
+	relPath = normalizePath(relPath)
+	// Hidden names start with a dot too, only the parent directory is left bare
+	if relPath != "." && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		relPath = "./" + relPath
+	}
+	return relPath, nil
+}
+
+// collectClosure selects the start files plus every project-local file
+// reachable from them through deps, in breadth-first order. Files outside of
+// dir or matching an exclude pattern are not followed.
+func collectClosure(dir string, starts []string, deps func(path string) ([]string, error), opts *options) ([]fileEntry, error) {
+	var files []fileEntry
+	visited := make(map[string]bool)
+
+	var queue []string
+	for _, start := range starts {
+		// Relative starting points are relative to the collected directory
+		path := start
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.Mode().IsRegular() {
+			return nil, fmt.Errorf("%s is not a regular file", start)
+		}
+		if !visited[path] {
+			visited[path] = true
+			queue = append(queue, path)
+		}
+	}
+
+	for len(queue) > 0 {
+		path := queue[0]
+		queue = queue[1:]
+
+		relPath, err := relativePath(dir, path)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(relPath, "..") {
+			if opts.verbose {
+				opts.logf("Skipping dependency outside of the project: %s\n", path)
+			}
+			continue
+		}
+		if isExcludedPath(relPath, opts) {
+			if opts.verbose {
+				opts.logf("Excluding dependency (matched exclude pattern): %s\n", path)
+			}
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !isEligibleFile(path, info, opts) {
+			continue
+		}
+		files = append(files, fileEntry{path: path, relPath: relPath})
+
+		dependencies, err := deps(path)
+		if err != nil {
+			if opts.verbose {
+				opts.logf("Error reading dependencies of %s: %v\n", path, err)
+			}
+			continue
+		}
+		for _, dependency := range dependencies {
+			if !visited[dependency] {
+				visited[dependency] = true
+				queue = append(queue, dependency)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// noDependencies is the dependency function for a plain list of files
+func noDependencies(path string) ([]string, error) {
+	return nil, nil
+}
+
+// isExcludedPath reports whether the relative path or any of its parent
+// directories matches an exclude pattern
+func isExcludedPath(relPath string, opts *options) bool {
+	parts := strings.Split(strings.TrimPrefix(filepath.ToSlash(relPath), "./"), "/")
+	for i, part := range parts {
+		if part == "." || part == "" {
+			continue
+		}
+		if excluded, err := matchesAnyPatternWithPath(part, strings.Join(parts[:i+1], "/"), opts.excludePatterns); err == nil && excluded {
+			return true
+		}
+	}
+	return false
+}
+
+// combineDependencies merges the results of several dependency functions so a
+// single closure can span more than one language.
+func combineDependencies(funcs ...func(path string) ([]string, error)) func(path string) ([]string, error) {
+	return func(path string) ([]string, error) {
+		var deps []string
+		for _, f := range funcs {
+			found, err := f(path)
+			if err != nil {
+				return nil, err
+			}
+			deps = append(deps, found...)
+		}
+		return deps, nil
+	}
+}
@@ -0,0 +1,246 @@
+package clip4llm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWalkFilesKeepsWalkOrder(t *testing.T) {
+	dir := t.TempDir()
+	var want []string
+	for d := 0; d < 5; d++ {
+		sub := filepath.Join(dir, fmt.Sprintf("pkg%d", d))
+		if err := os.MkdirAll(sub, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for f := 0; f < 40; f++ {
+			name := fmt.Sprintf("file%02d.txt", f)
+			content := []byte("text\n")
+			switch f % 10 {
+			case 3:
+				content = []byte{0x00, 0x01, 0x02} // binary
+			case 7:
+				content = []byte(strings.Repeat("x", 2048)) // over max-size
+			default:
+				want = append(want, fmt.Sprintf("./pkg%d/%s", d, name))
+			}
+			if err := os.WriteFile(filepath.Join(sub, name), content, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".hidden"), []byte("secret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := collectFiles(dir, &options{maxSize: 1, hidden: "skip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, file := range files {
+		got = append(got, filepath.ToSlash(file.relPath))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("collectFiles returned\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWalkFilesGrep(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"billing.go": "svc := NewPaymentService()\n",
+		"payment.go": "type PaymentService struct{}\n",
+		"user.go":    "type User struct{}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := &options{maxSize: 1, hidden: "skip", grep: regexp.MustCompile(`\bPaymentService\b`), skipped: newSkipCounter()}
+	files, err := collectFiles(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, file := range files {
+		got = append(got, file.relPath)
+	}
+	sort.Strings(got)
+	if want := []string{"./payment.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if skipped := opts.skipped.snapshot()[skipNoMatch]; skipped != 2 {
+		t.Errorf("skipped %d files without a match, want 2", skipped)
+	}
+}
+
+func TestWalkFilesMaxDepth(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"top.txt", "a/one.txt", "a/b/two.txt"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("text\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for depth, want := range map[int]int{0: 3, 1: 1, 2: 2, 3: 3} {
+		files, err := collectFiles(dir, &options{maxSize: 1, hidden: "skip", maxDepth: depth})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != want {
+			t.Errorf("maxDepth %d: got %d files, want %d", depth, len(files), want)
+		}
+	}
+}
+
+func TestWalkFilesDepthGuard(t *testing.T) {
+	dir := t.TempDir()
+	deep := dir
+	for i := 0; i < 1000; i++ {
+		deep = filepath.Join(deep, "d")
+		if err := os.Mkdir(deep, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if i == 2 || i == 999 {
+			if err := os.WriteFile(filepath.Join(deep, "f.txt"), []byte("text\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	opts := &options{maxSize: 1, hidden: "skip", depthGuard: 4, skipped: newSkipCounter()}
+	files, err := collectFiles(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].relPath != "./d/d/d/f.txt" {
+		t.Errorf("got %v, want only ./d/d/d/f.txt", files)
+	}
+	if want := []string{"d/d/d/d"}; !reflect.DeepEqual(opts.guarded, want) {
+		t.Errorf("guarded = %v, want %v", opts.guarded, want)
+	}
+
+	opts = &options{maxSize: 1, hidden: "skip"}
+	if files, err = collectFiles(dir, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || len(opts.guarded) != 0 {
+		t.Errorf("without a guard got %d files and guarded %v, want 2 and none", len(files), opts.guarded)
+	}
+}
+
+func TestWalkFilesSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	shared := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(dir, "src", "main.go"), filepath.Join(shared, "lib.go")} {
+		if err := os.WriteFile(path, []byte("package main\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"src/loop":  dir,                                  // cycle back to the root
+		"vendor":    shared,                               // directory outside the root
+		"alias.go":  filepath.Join(dir, "src", "main.go"), // file
+		"broken.go": filepath.Join(dir, "missing.go"),     // dangling
+		"src/again": shared,                               // already walked through vendor
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Skipf("symlinks are not supported: %v", err)
+		}
+	}
+
+	relPaths := func(follow bool) []string {
+		opts := &options{maxSize: 1, hidden: "skip", followSymlinks: follow, skipped: newSkipCounter()}
+		files, err := collectFiles(dir, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, file := range files {
+			paths = append(paths, file.relPath)
+		}
+		sort.Strings(paths)
+		return paths
+	}
+
+	if got := relPaths(false); strings.Join(got, " ") != "./src/main.go" {
+		t.Errorf("without following got %v, want only ./src/main.go", got)
+	}
+	got := strings.Join(relPaths(true), " ")
+	// The walk reaches src/again before vendor, so the shared directory shows up there
+	if got != "./alias.go ./src/again/lib.go ./src/main.go" {
+		t.Errorf("following got %q, want the file link and the outside directory once", got)
+	}
+}
+
+func TestPrepareInfra(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"main.go":                   "package main\n",
+		"deploy/app.yaml":           "apiVersion: apps/v1\nkind: Deployment\n",
+		"config/settings.yaml":      "debug: true\n",
+		".github/workflows/ci.yml":  "on: push\n",
+		"docker-compose.yml":        "services: {}\n",
+		"build/Dockerfile":          "FROM scratch\n",
+		"infra/main.tf":             "terraform {}\n",
+		"k8s/service.yml":           "kind: Service\napiVersion: v1\n",
+		".github/ISSUE_TEMPLATE.md": "template\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := DefaultOptions()
+	opts.Infra = true
+	collector, err := NewCollector(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := collector.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, file := range result.Files {
+		got = append(got, file.RelPath)
+	}
+	want := []string{"./build/Dockerfile", "./docker-compose.yml", "./.github/workflows/ci.yml", "./deploy/app.yaml", "./infra/main.tf", "./k8s/service.yml"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Files = %v, want %v", got, want)
+	}
+}
+
+func TestMaxSizeFor(t *testing.T) {
+	opts := &options{maxSize: 32, maxSizes: []SizeLimit{{Pattern: "*.min.sql", MaxSize: 8}, {Pattern: "*.sql", MaxSize: 256}}}
+	cases := map[string]int{
+		"/src/db/schema.sql":   256,
+		"/src/db/dump.min.sql": 8,
+		"/src/main.go":         32,
+	}
+	for path, want := range cases {
+		if got := opts.maxSizeFor(path); got != want {
+			t.Errorf("maxSizeFor(%q) = %d, want %d", path, got, want)
+		}
+	}
+}
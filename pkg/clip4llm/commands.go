@@ -0,0 +1,622 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Maximum number of trailing lines of --exec output kept in a bug report
+const maxExecOutputLines = 200
+
+// command is a prompt preset run as "clip4llm <name> [flags]"
+type command struct {
+	description string
+	// prepare adjusts the options before files are selected, sets the prompt
+	// preamble and returns any generated sections for the prompt
+	prepare func(dir string, opts *options) ([]section, error)
+}
+
+// The prompt presets selectable as the first argument
+var commands = map[string]*command{
+	"bugreport": {
+		description: "Bug investigation prompt with environment, recent git log, failing command output (--exec) and files",
+		prepare:     prepareBugReport,
+	},
+	"review": {
+		description: "Code review prompt with the diff against --diff-base and the full content of the changed files",
+		prepare:     prepareReview,
+	},
+	"onboard": {
+		description: "Explain-this-repo prompt with README, build files, entry points, package docs and the tree, fit to --budget tokens",
+		prepare:     prepareOnboard,
+	},
+	"commitmsg": {
+		description: "Conventional commit message prompt for the staged diff",
+		prepare:     prepareCommitMsg,
+	},
+	"gentests": {
+		description: "Test writing prompt for a file or package with its existing tests as style examples",
+		prepare:     prepareGenTests,
+	},
+	"refactor": {
+		description: "Refactor prompt with every file referencing --symbol (optionally renaming it to --rename-to)",
+		prepare:     prepareRefactor,
+	},
+	"docgen": {
+		description: "Documentation writing prompt with a package's exported declarations and doc comments, without function bodies",
+		prepare:     prepareDocGen,
+	},
+	"secreview": {
+		description: "Security review prompt with the auth, crypto, input handling and dependency files, secrets redacted",
+		prepare:     prepareSecReview,
+	},
+}
+
+// runCommand runs a program in dir and returns its standard output without the
+// trailing newline
+func runCommand(dir string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s failed: %s", name, msg)
+		}
+		return "", fmt.Errorf("%s failed: %v", name, err)
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// prepareBugReport sets up the bugreport preset: a bug investigation preamble
+// followed by the environment, recent history and failing command output.
+func prepareBugReport(dir string, opts *options) ([]section, error) {
+	opts.preamble = "I am investigating a bug in this project. Below are details about my environment, " +
+		"the recent commit history, the output of the failing command and the relevant source files. " +
+		"Identify the most likely root cause, explain your reasoning with references to specific files " +
+		"and lines, and propose a fix."
+
+	sections := []section{{title: "Environment", content: environmentInfo(dir)}}
+
+	if history, err := runCommand(dir, "git", "log", "-n", "10", "--date=short", "--pretty=format:%h %ad %an %s"); err == nil && history != "" {
+		sections = append(sections, section{title: "Recent Git Log", content: history})
+	} else if opts.verbose {
+		opts.logf("Skipping git log: not a git repository or git unavailable\n")
+	}
+
+	if opts.exec != "" {
+		output, err := runShell(dir, opts.exec)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, section{title: "Command Output: " + opts.exec, content: output})
+	}
+
+	return sections, nil
+}
+
+// environmentInfo describes the OS and the versions of the toolchains the project uses
+func environmentInfo(dir string) string {
+	lines := []string{fmt.Sprintf("OS/Arch: %s/%s", runtime.GOOS, runtime.GOARCH)}
+	if shell := os.Getenv("SHELL"); shell != "" {
+		lines = append(lines, "Shell: "+shell)
+	}
+
+	// Report a toolchain only when the project has a matching manifest
+	toolchains := []struct {
+		manifests []string
+		label     string
+		name      string
+		args      []string
+	}{
+		{[]string{"go.mod"}, "Go", "go", []string{"version"}},
+		{[]string{"package.json"}, "Node.js", "node", []string{"--version"}},
+		{[]string{"package.json"}, "npm", "npm", []string{"--version"}},
+		{[]string{"requirements.txt", "pyproject.toml", "setup.py"}, "Python", "python3", []string{"--version"}},
+		{[]string{"Cargo.toml"}, "Rust", "cargo", []string{"--version"}},
+		{[]string{"pom.xml", "build.gradle", "build.gradle.kts"}, "Java", "java", []string{"-version"}},
+	}
+	for _, toolchain := range toolchains {
+		found := false
+		for _, manifest := range toolchain.manifests {
+			if _, err := os.Stat(filepath.Join(dir, manifest)); err == nil {
+				found = true
+				break
+			}
+		}
+		if !found {
+			continue
+		}
+		cmd := exec.Command(toolchain.name, toolchain.args...)
+		cmd.Dir = dir
+		// Some tools (java -version) report on stderr
+		output, err := cmd.CombinedOutput()
+		version := strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0])
+		if err != nil || version == "" {
+			version = "not found"
+		}
+		lines = append(lines, toolchain.label+": "+version)
+	}
+
+	if branch, err := runCommand(dir, "git", "rev-parse", "--abbrev-ref", "HEAD"); err == nil {
+		lines = append(lines, "Git Branch: "+branch)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// runShell runs a shell command line in dir and returns its combined output
+// (limited to the trailing lines) along with the exit status. A failing exit
+// status is expected for bug reports and is not treated as an error.
+func runShell(dir string, commandLine string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", commandLine)
+	} else {
+		cmd = exec.Command("sh", "-c", commandLine)
+	}
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+
+	exitStatus := "Exit Status: 0"
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return "", fmt.Errorf("failed to run %q: %v", commandLine, err)
+		}
+		exitStatus = fmt.Sprintf("Exit Status: %d", exitErr.ExitCode())
+	}
+
+	lines := strings.Split(strings.TrimRight(string(output), "\n"), "\n")
+	if len(lines) > maxExecOutputLines {
+		omitted := len(lines) - maxExecOutputLines
+		lines = append([]string{fmt.Sprintf("... [%d earlier lines omitted] ...", omitted)}, lines[omitted:]...)
+	}
+
+	return "$ " + commandLine + "\n" + strings.Join(lines, "\n") + "\n\n" + exitStatus, nil
+}
+
+// prepareReview sets up the review preset: a code review preamble, the diff
+// against the base branch and a selection of exactly the changed files.
+func prepareReview(dir string, opts *options) ([]section, error) {
+	base, err := resolveDiffBase(dir, opts.diffBase)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := gitDiff(dir, base)
+	if err != nil {
+		return nil, err
+	}
+	if diff == "" {
+		return nil, fmt.Errorf("no changes found against %s", base)
+	}
+
+	opts.paths, err = gitChangedFiles(dir, base)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.preamble = "Please review the following change. The unified diff is shown first, followed by the " +
+		"full content of every changed file for context. Point out bugs, edge cases, security issues and " +
+		"unclear code, referencing specific files and lines, and suggest concrete improvements. " +
+		"Keep nitpicks separate from issues that should block merging."
+
+	return []section{{title: "Diff", content: diff}}, nil
+}
+
+// Base names of the build and dependency files an onboarding prompt includes
+var onboardBuildFiles = map[string]bool{
+	"go.mod": true, "package.json": true, "tsconfig.json": true, "Makefile": true, "makefile": true,
+	"GNUmakefile": true, "justfile": true, "Taskfile.yml": true, "Dockerfile": true,
+	"docker-compose.yml": true, "docker-compose.yaml": true, "compose.yml": true, "compose.yaml": true,
+	"pyproject.toml": true, "setup.py": true, "setup.cfg": true, "requirements.txt": true,
+	"Cargo.toml": true, "pom.xml": true, "build.gradle": true, "build.gradle.kts": true,
+	"settings.gradle": true, "CMakeLists.txt": true, "meson.build": true, "Gemfile": true, "composer.json": true,
+}
+
+// Patterns of the conventional program entry points an onboarding prompt includes
+var onboardEntryPoints = []string{
+	"main.go", "cmd/*/main.go", "main.py", "app.py", "manage.py", "__main__.py", "*/__main__.py",
+	"index.js", "index.ts", "server.js", "server.ts", "src/main.*", "src/index.*", "src/App.*",
+	"src/lib.rs", "src/main.rs", "Program.cs",
+}
+
+// onboardPriority ranks a file for the onboarding prompt, lower is more
+// important, and reports whether it belongs in the prompt at all.
+func onboardPriority(rel string) (int, bool) {
+	base := path.Base(rel)
+	depth := strings.Count(rel, "/")
+	upper := strings.ToUpper(base)
+
+	switch {
+	case depth == 0 && (strings.HasPrefix(upper, "README") || strings.HasPrefix(upper, "ARCHITECTURE")):
+		return 0, true
+	case depth == 0 && strings.HasPrefix(upper, "CONTRIBUTING"):
+		return 1, true
+	case depth == 0 && onboardBuildFiles[base]:
+		return 2, true
+	}
+
+	for _, pattern := range onboardEntryPoints {
+		if matched, _ := path.Match(pattern, rel); matched {
+			return 3, true
+		}
+	}
+
+	// Package level documentation of the top-level packages
+	if depth <= 2 && (base == "doc.go" || base == "package-info.java") {
+		return 4, true
+	}
+	if depth == 1 && strings.HasPrefix(upper, "README") {
+		return 4, true
+	}
+	return 0, false
+}
+
+// prepareOnboard sets up the onboard preset: the project tree and its most
+// explanatory files, in priority order, trimmed to fit the token budget.
+func prepareOnboard(dir string, opts *options) ([]section, error) {
+	opts.preamble = "I am new to this project and want to understand it. Below are the directory tree, the " +
+		"README, the build files, the entry points and the package documentation. Explain what the project " +
+		"does, how it is structured, how to build, run and test it, and where I should start reading to " +
+		"make my first change."
+
+	candidates, err := collectFiles(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	type ranked struct {
+		rel      string
+		priority int
+		size     int64
+	}
+	var picks []ranked
+	for _, file := range candidates {
+		rel := strings.TrimPrefix(filepath.ToSlash(file.relPath), "./")
+		paths = append(paths, rel)
+		if priority, ok := onboardPriority(rel); ok {
+			info, err := os.Stat(file.path)
+			if err != nil {
+				continue
+			}
+			picks = append(picks, ranked{rel: rel, priority: priority, size: info.Size()})
+		}
+	}
+	sort.SliceStable(picks, func(i, j int) bool {
+		return picks[i].priority < picks[j].priority
+	})
+
+	tree := renderTree(paths, nil, false, nil)
+	remaining := opts.budget - estimateTokens(opts.preamble) - estimateTokens(tree)
+
+	opts.paths = []string{}
+	for _, pick := range picks {
+		// Account for the file header and delimiters around the content
+		tokens := estimateTokens(pick.rel) + int(pick.size+charsPerToken-1)/charsPerToken + 8
+		if tokens > remaining {
+			if opts.verbose {
+				opts.logf("Skipping %s: does not fit the remaining budget of %d tokens\n", pick.rel, remaining)
+			}
+			continue
+		}
+		remaining -= tokens
+		opts.paths = append(opts.paths, pick.rel)
+	}
+
+	return []section{{title: "Project Tree", content: tree}}, nil
+}
+
+// prepareCommitMsg sets up the commitmsg preset: the staged diff alone with a
+// request for a conventional commit message.
+func prepareCommitMsg(dir string, opts *options) ([]section, error) {
+	diff, err := gitStagedDiff(dir)
+	if err != nil {
+		return nil, err
+	}
+	if diff == "" {
+		return nil, fmt.Errorf("no staged changes; stage the changes to describe with git add first")
+	}
+
+	opts.preamble = "Write a commit message for the staged changes below using the Conventional Commits " +
+		"format: a type (feat, fix, docs, refactor, test, chore, ...), an optional scope and a short " +
+		"imperative summary of at most 72 characters, then a blank line and a body explaining what changed " +
+		"and why. Reply with the commit message only."
+
+	// Only the diff is needed, no files are selected
+	opts.paths = []string{}
+
+	return []section{{title: "Staged Diff", content: diff}}, nil
+}
+
+// Maximum number of unrelated test files included as style examples
+const maxStyleExamples = 2
+
+// prepareGenTests sets up the gentests preset for the file or package
+// directory given as argument: the target code, its existing tests, the local
+// files it imports and, when it has no tests yet, nearby tests as style examples.
+func prepareGenTests(dir string, opts *options) ([]section, error) {
+	if len(opts.args) != 1 {
+		return nil, fmt.Errorf("usage: clip4llm gentests <file|package directory>")
+	}
+	// A relative target is relative to the collected directory
+	target := opts.args[0]
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(dir, target)
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, err
+	}
+
+	// The target is the file itself or every non-test source file of the package
+	var targets []string
+	if info.IsDir() {
+		targets, err = packageSourceFiles(target)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		targets = []string{target}
+	}
+
+	selected := make(map[string]bool)
+	opts.paths = []string{}
+	add := func(path string) {
+		if !selected[path] {
+			selected[path] = true
+			opts.paths = append(opts.paths, path)
+		}
+	}
+
+	var targetNames []string
+	for _, path := range targets {
+		add(path)
+		if rel, err := relativePath(dir, path); err == nil {
+			targetNames = append(targetNames, rel)
+		}
+	}
+
+	// Existing tests of the target show what is already covered
+	hasTests := false
+	for _, path := range targets {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			continue
+		}
+		for _, candidate := range testPairCandidates(filepath.ToSlash(rel)) {
+			testPath := filepath.Join(dir, filepath.FromSlash(candidate))
+			if info, err := os.Stat(testPath); err == nil && info.Mode().IsRegular() {
+				add(testPath)
+				hasTests = true
+			}
+		}
+	}
+
+	// Local imports give the model the types and helpers the target relies on
+	deps := combineDependencies(jsDependencies(dir), pyDependencies(pythonRoots(dir)))
+	for _, path := range targets {
+		imports, err := deps(path)
+		if err != nil {
+			continue
+		}
+		for _, imported := range imports {
+			add(imported)
+		}
+	}
+
+	// Without existing tests, borrow the style of other tests in the project
+	if !hasTests {
+		candidates, err := collectFiles(dir, opts)
+		if err != nil {
+			return nil, err
+		}
+		ext := filepath.Ext(targets[0])
+		examples := 0
+		for _, file := range candidates {
+			if examples == maxStyleExamples {
+				break
+			}
+			if isTestFile(file.path) && filepath.Ext(file.path) == ext && !selected[file.path] {
+				add(file.path)
+				examples++
+			}
+		}
+	}
+
+	opts.preamble = "Write thorough unit tests for the target code listed below. Cover the main behavior, " +
+		"edge cases and error handling. Follow the conventions, helpers and style of the existing tests " +
+		"that are included, extend them rather than duplicating what they already cover, and reply with " +
+		"complete test files ready to save."
+
+	return []section{{title: "Test Target", content: strings.Join(targetNames, "\n")}}, nil
+}
+
+// prepareRefactor sets up the refactor preset: every file that references the
+// symbol as a whole word, with a per-file reference count and instructions.
+func prepareRefactor(dir string, opts *options) ([]section, error) {
+	if opts.symbol == "" {
+		return nil, fmt.Errorf("usage: clip4llm refactor --symbol OldName [--rename-to NewName]")
+	}
+	pattern := regexp.MustCompile(`\b` + regexp.QuoteMeta(opts.symbol) + `\b`)
+
+	candidates, err := collectFiles(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var scope []string
+	opts.paths = []string{}
+	for _, file := range candidates {
+		content, err := os.ReadFile(file.path)
+		if err != nil {
+			continue
+		}
+		count := len(pattern.FindAllIndex(content, -1))
+		if count == 0 {
+			continue
+		}
+		opts.paths = append(opts.paths, file.path)
+		scope = append(scope, fmt.Sprintf("%s: %d references", file.relPath, count))
+	}
+	if len(opts.paths) == 0 {
+		return nil, fmt.Errorf("no files reference %s", opts.symbol)
+	}
+
+	if opts.renameTo != "" {
+		opts.preamble = fmt.Sprintf("Rename %s to %s across the code below. ", opts.symbol, opts.renameTo)
+	} else {
+		opts.preamble = fmt.Sprintf("Refactor %s across the code below. ", opts.symbol)
+	}
+	opts.preamble += "The files included are every file that references it. Keep behavior unchanged, update " +
+		"all references, comments and documentation consistently, and reply with the complete updated " +
+		"content of each file that changes, each preceded by its path."
+
+	return []section{{title: "Refactor Scope: " + opts.symbol, content: strings.Join(scope, "\n")}}, nil
+}
+
+// packageSourceFiles returns the non-test source files directly inside dir
+func packageSourceFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && testPairCandidates(entry.Name()) != nil && !isTestFile(entry.Name()) {
+			files = append(files, filepath.Join(dir, entry.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no source files found in %s", dir)
+	}
+	return files, nil
+}
+
+// prepareDocGen sets up the docgen preset for the package directory given as
+// argument: its source files reduced to exported declarations and doc comments.
+func prepareDocGen(dir string, opts *options) ([]section, error) {
+	if len(opts.args) != 1 {
+		return nil, fmt.Errorf("usage: clip4llm docgen <package directory>")
+	}
+	// A relative directory is relative to the collected one
+	target := opts.args[0]
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(dir, target)
+	}
+
+	var err error
+	opts.paths, err = packageSourceFiles(target)
+	if err != nil {
+		return nil, err
+	}
+	opts.declarationsOnly = true
+
+	opts.preamble = "Write documentation for the package below. Only its exported declarations and existing " +
+		"doc comments are shown, function bodies are omitted. Write or improve the doc comment of every " +
+		"exported declaration and add a package overview, following the documentation conventions of the " +
+		"language. Reply with the declarations and their new doc comments."
+
+	return nil, nil
+}
+
+// Path keywords identifying the security relevant areas of a codebase, in
+// review priority order
+var secReviewCategories = []struct {
+	label    string
+	keywords []string
+}{
+	{"auth", []string{"auth", "login", "logout", "session", "password", "passwd", "credential", "token", "jwt", "oauth", "saml", "sso", "permission", "rbac", "acl", "policy", "role"}},
+	{"crypto", []string{"crypt", "cipher", "hash", "hmac", "signature", "signing", "tls", "ssl", "cert", "secret", "keystore", "random"}},
+	{"input handling", []string{"handler", "controller", "route", "router", "endpoint", "api", "middleware", "request", "forms", "upload", "input", "param", "query", "sql", "valid", "sanitiz", "escape", "parse", "serializ", "deserializ", "template"}},
+}
+
+// Base names of the dependency manifests a security review includes
+var secReviewManifests = map[string]bool{
+	"go.mod": true, "package.json": true, "requirements.txt": true, "Pipfile": true, "pyproject.toml": true,
+	"setup.py": true, "Cargo.toml": true, "pom.xml": true, "build.gradle": true, "build.gradle.kts": true,
+	"Gemfile": true, "composer.json": true, "Dockerfile": true,
+}
+
+// secReviewCategory returns the security category of a file judged by its
+// path and reports whether it belongs in the review at all. Test files are
+// left out, they rarely hold the vulnerable code.
+func secReviewCategory(rel string) (int, string, bool) {
+	base := path.Base(rel)
+	if secReviewManifests[base] {
+		return len(secReviewCategories), "dependencies", true
+	}
+	if isTestFile(base) {
+		return 0, "", false
+	}
+
+	lower := strings.ToLower(rel)
+	for i, category := range secReviewCategories {
+		for _, keyword := range category.keywords {
+			if strings.Contains(lower, keyword) {
+				return i, category.label, true
+			}
+		}
+	}
+	return 0, "", false
+}
+
+// prepareSecReview sets up the secreview preset: the auth, crypto, input
+// handling and dependency files in that order, with secrets redacted.
+func prepareSecReview(dir string, opts *options) ([]section, error) {
+	candidates, err := collectFiles(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	type ranked struct {
+		rel      string
+		priority int
+		label    string
+	}
+	var picks []ranked
+	for _, file := range candidates {
+		rel := strings.TrimPrefix(filepath.ToSlash(file.relPath), "./")
+		if priority, label, ok := secReviewCategory(rel); ok {
+			picks = append(picks, ranked{rel: rel, priority: priority, label: label})
+		}
+	}
+	if len(picks) == 0 {
+		return nil, fmt.Errorf("no security relevant files found")
+	}
+	sort.SliceStable(picks, func(i, j int) bool {
+		return picks[i].priority < picks[j].priority
+	})
+
+	var scope []string
+	opts.paths = []string{}
+	for _, pick := range picks {
+		opts.paths = append(opts.paths, pick.rel)
+		scope = append(scope, fmt.Sprintf("%s: %s", pick.rel, pick.label))
+	}
+	opts.redactSecrets = true
+
+	opts.preamble = "Perform a security review of the code below. The files were selected because they handle " +
+		"authentication, cryptography, untrusted input or declare dependencies; secrets have been replaced " +
+		"with [REDACTED] placeholders. Look for vulnerabilities such as injection, broken authentication or " +
+		"authorization, insecure cryptography, unsafe deserialization, missing input validation and " +
+		"dependencies with known issues. For each finding give the file and line, the severity, how it " +
+		"could be exploited and a concrete fix."
+
+	return []section{{title: "Security Review Scope", content: strings.Join(scope, "\n")}}, nil
+}
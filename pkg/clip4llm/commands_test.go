@@ -0,0 +1,322 @@
+package clip4llm
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunShell(t *testing.T) {
+	dir := t.TempDir()
+	got, err := runShell(dir, "echo broken && exit 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "$ echo broken && exit 3\nbroken\n\nExit Status: 3"; got != want {
+		t.Errorf("runShell = %q, want %q", got, want)
+	}
+
+	got, err = runShell(dir, fmt.Sprintf("seq 1 %d", maxExecOutputLines+5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "\n... [5 earlier lines omitted] ...\n6\n") || !strings.HasSuffix(got, "\n\nExit Status: 0") {
+		t.Errorf("runShell did not keep the trailing lines:\n%s", got)
+	}
+}
+
+func TestPrepareBugReport(t *testing.T) {
+	opts := &options{exec: "echo failing test"}
+	sections, err := prepareBugReport(t.TempDir(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.preamble == "" {
+		t.Error("the preamble is not set")
+	}
+	if len(sections) == 0 || sections[0].title != "Environment" || !strings.Contains(sections[0].content, "OS/Arch: ") {
+		t.Fatalf("sections = %+v, want the environment first", sections)
+	}
+	last := sections[len(sections)-1]
+	if last.title != "Command Output: echo failing test" || !strings.Contains(last.content, "failing test\n\nExit Status: 0") {
+		t.Errorf("command output section = %+v", last)
+	}
+}
+
+func TestPrepareReview(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=a@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("main.go", "package main\n")
+	write("util.go", "package main\n")
+	git("init", "--quiet")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "initial")
+	git("branch", "-M", "main")
+	git("checkout", "--quiet", "-b", "feature")
+	write("main.go", "package main\n\nfunc main() {}\n")
+	git("commit", "--quiet", "-am", "add main")
+	write("new.go", "package main\n")
+
+	opts := &options{}
+	sections, err := prepareReview(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"main.go", "new.go"}; !reflect.DeepEqual(opts.paths, want) {
+		t.Errorf("paths = %v, want %v", opts.paths, want)
+	}
+	if len(sections) != 1 || sections[0].title != "Diff" || !strings.Contains(sections[0].content, "+func main() {}") {
+		t.Errorf("sections = %+v, want the diff against main", sections)
+	}
+
+	git("checkout", "--quiet", "main")
+	if _, err := prepareReview(dir, &options{diffBase: "main"}); err == nil || !strings.Contains(err.Error(), "no changes") {
+		t.Errorf("expected an error without changes, got %v", err)
+	}
+}
+
+func TestOnboardPriority(t *testing.T) {
+	cases := []struct {
+		rel      string
+		priority int
+		ok       bool
+	}{
+		{"README.md", 0, true},
+		{"ARCHITECTURE.md", 0, true},
+		{"CONTRIBUTING.md", 1, true},
+		{"go.mod", 2, true},
+		{"cmd/server/main.go", 3, true},
+		{"src/index.ts", 3, true},
+		{"pkg/api/doc.go", 4, true},
+		{"docs/README.md", 4, true},
+		{"docs/guide/README.md", 0, false},
+		{"vendor/lib/go.mod", 0, false},
+		{"pkg/api/handler.go", 0, false},
+	}
+	for _, c := range cases {
+		priority, ok := onboardPriority(c.rel)
+		if priority != c.priority || ok != c.ok {
+			t.Errorf("onboardPriority(%q) = %d, %v, want %d, %v", c.rel, priority, ok, c.priority, c.ok)
+		}
+	}
+}
+
+func TestPrepareOnboard(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"README.md": "# project\n",
+		"go.mod":    "module example.com/project\n",
+		"main.go":   strings.Repeat("// filler\n", 400),
+		"util.go":   "package main\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The budget fits the tree, the README and go.mod but not the large main.go
+	opts := &options{maxSize: 32, hidden: "skip", budget: 400}
+	sections, err := prepareOnboard(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"README.md", "go.mod"}; !reflect.DeepEqual(opts.paths, want) {
+		t.Errorf("paths = %v, want %v", opts.paths, want)
+	}
+	if len(sections) != 1 || sections[0].title != "Project Tree" || !strings.Contains(sections[0].content, "util.go") {
+		t.Errorf("sections = %+v, want the tree of every file", sections)
+	}
+}
+
+func TestPrepareCommitMsg(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "init", "--quiet", dir).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := prepareCommitMsg(dir, &options{}); err == nil || !strings.Contains(err.Error(), "no staged changes") {
+		t.Errorf("expected an error without staged changes, got %v", err)
+	}
+
+	cmd := exec.Command("git", "add", "main.go")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git add: %v\n%s", err, out)
+	}
+	opts := &options{}
+	sections, err := prepareCommitMsg(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.paths == nil || len(opts.paths) != 0 {
+		t.Errorf("paths = %v, want an empty selection", opts.paths)
+	}
+	if len(sections) != 1 || !strings.Contains(sections[0].content, "main.go | 1 +") || !strings.Contains(sections[0].content, "+package main") {
+		t.Errorf("sections = %+v, want the stat and the staged diff", sections)
+	}
+}
+
+func TestPrepareGenTests(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"calc.go":       "package calc\n",
+		"calc_test.go":  "package calc\n",
+		"parse.go":      "package calc\n",
+		"other_test.go": "package calc\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A file with tests brings them along
+	opts := &options{maxSize: 32, hidden: "skip", args: []string{filepath.Join(dir, "calc.go")}}
+	sections, err := prepareGenTests(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(dir, "calc.go"), filepath.Join(dir, "calc_test.go")}; !reflect.DeepEqual(opts.paths, want) {
+		t.Errorf("paths = %v, want %v", opts.paths, want)
+	}
+	if len(sections) != 1 || sections[0].content != "./calc.go" {
+		t.Errorf("sections = %+v, want ./calc.go as the target", sections)
+	}
+
+	// A file without tests borrows the others as style examples
+	opts = &options{maxSize: 32, hidden: "skip", args: []string{filepath.Join(dir, "parse.go")}}
+	if _, err := prepareGenTests(dir, opts); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(dir, "parse.go"), filepath.Join(dir, "calc_test.go"), filepath.Join(dir, "other_test.go")}; !reflect.DeepEqual(opts.paths, want) {
+		t.Errorf("paths = %v, want %v", opts.paths, want)
+	}
+
+	if _, err := prepareGenTests(dir, &options{}); err == nil || !strings.Contains(err.Error(), "usage") {
+		t.Errorf("expected the usage without a target, got %v", err)
+	}
+}
+
+func TestPrepareRefactor(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"billing.go": "svc := NewPaymentService()\nvar _ PaymentService\n",
+		"payment.go": "type PaymentService struct{}\n",
+		"legacy.go":  "type PaymentServiceV1 struct{}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := &options{maxSize: 32, hidden: "skip", symbol: "PaymentService", renameTo: "Payments"}
+	sections, err := prepareRefactor(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(dir, "billing.go"), filepath.Join(dir, "payment.go")}; !reflect.DeepEqual(opts.paths, want) {
+		t.Errorf("paths = %v, want %v", opts.paths, want)
+	}
+	if !strings.HasPrefix(opts.preamble, "Rename PaymentService to Payments ") {
+		t.Errorf("preamble = %q", opts.preamble)
+	}
+	if want := "./billing.go: 1 references\n./payment.go: 1 references"; len(sections) != 1 || sections[0].content != want {
+		t.Errorf("sections = %+v, want the scope %q", sections, want)
+	}
+
+	if _, err := prepareRefactor(dir, &options{maxSize: 32, hidden: "skip", symbol: "Invoice"}); err == nil {
+		t.Error("expected an error when nothing references the symbol")
+	}
+}
+
+func TestPrepareDocGen(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"calc.go", "calc_test.go", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package calc\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := &options{args: []string{dir}}
+	if _, err := prepareDocGen(dir, opts); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(dir, "calc.go")}; !reflect.DeepEqual(opts.paths, want) || !opts.declarationsOnly {
+		t.Errorf("paths = %v, declarationsOnly = %v, want %v and true", opts.paths, opts.declarationsOnly, want)
+	}
+}
+
+func TestSecReviewCategory(t *testing.T) {
+	cases := []struct {
+		rel      string
+		priority int
+		label    string
+		ok       bool
+	}{
+		{"internal/auth/login.go", 0, "auth", true},
+		{"pkg/crypto/hmac.go", 1, "crypto", true},
+		{"api/handlers.go", 2, "input handling", true},
+		{"go.mod", 3, "dependencies", true},
+		{"web/package.json", 3, "dependencies", true},
+		{"internal/auth/login_test.go", 0, "", false},
+		{"docs/intro.md", 0, "", false},
+	}
+	for _, c := range cases {
+		priority, label, ok := secReviewCategory(c.rel)
+		if priority != c.priority || label != c.label || ok != c.ok {
+			t.Errorf("secReviewCategory(%q) = %d, %q, %v, want %d, %q, %v", c.rel, priority, label, ok, c.priority, c.label, c.ok)
+		}
+	}
+}
+
+func TestPrepareSecReview(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":     "module example.com/project\n",
+		"handler.go": "package main\n",
+		"session.go": "package main\n",
+		"main.go":    "package main\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := &options{maxSize: 32, hidden: "skip"}
+	sections, err := prepareSecReview(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"session.go", "handler.go", "go.mod"}; !reflect.DeepEqual(opts.paths, want) {
+		t.Errorf("paths = %v, want %v", opts.paths, want)
+	}
+	if !opts.redactSecrets {
+		t.Error("secreview should redact secrets")
+	}
+	if want := "session.go: auth\nhandler.go: input handling\ngo.mod: dependencies"; len(sections) != 1 || sections[0].content != want {
+		t.Errorf("sections = %+v, want the scope %q", sections, want)
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bytes"
+	"strings"
+)
+
+// quoteRule describes a string literal the comment lexer skips over
+type quoteRule struct {
+	delim     string
+	escapes   bool // a backslash escapes the next character
+	multiline bool // the literal may span lines, otherwise it ends at the newline
+}
+
+// commentSyntax describes the comments and string literals of a language
+// closely enough to remove the comments without touching the strings
+type commentSyntax struct {
+	line       string // opener of a comment running to the end of the line
+	blockStart string
+	blockEnd   string
+	nested     bool        // block comments nest, as in Swift and Rust
+	quotes     []quoteRule // longest delimiters first
+	wordStart  bool        // comments only start after whitespace, as in shell and YAML
+	quoteStart bool        // quotes only start a scalar, as in YAML where don't has no string
+	keep       []string    // line comments kept because they are directives, such as //go:build
+}
+
+// The quotes of C and most of its descendants, which end at the newline
+var cQuotes = []quoteRule{{delim: `"`, escapes: true}, {delim: `'`, escapes: true}}
+
+// Comment syntaxes by the language returned by detectLanguage
+var commentSyntaxes = func() map[string]*commentSyntax {
+	cFamily := &commentSyntax{line: "//", blockStart: "/*", blockEnd: "*/", quotes: cQuotes}
+	nestedTriple := &commentSyntax{line: "//", blockStart: "/*", blockEnd: "*/", nested: true,
+		quotes: append([]quoteRule{{delim: `"""`, escapes: true, multiline: true}}, cQuotes...)}
+	js := &commentSyntax{line: "//", blockStart: "/*", blockEnd: "*/", keep: []string{"/// <"},
+		quotes: append([]quoteRule{{delim: "`", escapes: true, multiline: true}}, cQuotes...)}
+
+	return map[string]*commentSyntax{
+		"go": {line: "//", blockStart: "/*", blockEnd: "*/",
+			keep:   []string{"//go:", "// +build", "//export ", "//line "},
+			quotes: append([]quoteRule{{delim: "`", multiline: true}}, cQuotes...)},
+		"javascript":  js,
+		"typescript":  js,
+		"c":           cFamily,
+		"cpp":         cFamily,
+		"objective-c": cFamily,
+		"java":        cFamily,
+		"csharp":      cFamily,
+		"kotlin":      nestedTriple,
+		"scala":       nestedTriple,
+		"swift":       nestedTriple,
+		"rust":        {line: "//", blockStart: "/*", blockEnd: "*/", nested: true, quotes: cQuotes},
+		"python": {line: "#", keep: []string{"# -*-", "# type:"}, quotes: []quoteRule{
+			{delim: `"""`, escapes: true, multiline: true}, {delim: `'''`, escapes: true, multiline: true},
+			{delim: `"`, escapes: true}, {delim: `'`, escapes: true},
+		}},
+		"shell": {line: "#", wordStart: true, quotes: []quoteRule{
+			{delim: `"`, escapes: true, multiline: true}, {delim: `'`, multiline: true},
+		}},
+		"yaml": {line: "#", wordStart: true, quoteStart: true, quotes: []quoteRule{
+			{delim: `"`, escapes: true, multiline: true}, {delim: `'`, multiline: true},
+		}},
+	}
+}()
+
+// stripComments removes the line and block comments from content when its
+// language is recognized, dropping the lines left empty. It reports whether
+// the language was recognized.
+func stripComments(language string, content []byte) ([]byte, bool) {
+	syntax, ok := commentSyntaxes[language]
+	if !ok {
+		return content, false
+	}
+	// The C preamble of a cgo file is a comment the build depends on
+	if language == "go" && bytes.Contains(content, []byte(`import "C"`)) {
+		return content, false
+	}
+	return syntax.strip(content), true
+}
+
+// strip removes the comments from content with a lightweight lexer that only
+// knows about comments and string literals
+func (s *commentSyntax) strip(content []byte) []byte {
+	var out []byte
+	touched := make(map[int]bool) // output lines that lost a comment
+	line := 0
+	prev := byte('\n') // the last byte copied, for the word start rule
+
+	i := 0
+	// Keep the interpreter line of scripts
+	if bytes.HasPrefix(content, []byte("#!")) {
+		i = len(content)
+		if end := bytes.IndexByte(content, '\n'); end >= 0 {
+			i = end
+		}
+		out = append(out, content[:i]...)
+	}
+
+	for i < len(content) {
+		rest := content[i:]
+		quoteAllowed := !s.quoteStart || isSpace(prev) || strings.IndexByte("[{,", prev) >= 0
+		if quote, ok := s.quoteAt(rest, quoteAllowed); ok {
+			end := quote.end(rest)
+			out = append(out, rest[:end]...)
+			line += bytes.Count(rest[:end], []byte("\n"))
+			prev = rest[end-1]
+			i += end
+			continue
+		}
+
+		if s.line != "" && (!s.wordStart || isSpace(prev)) && bytes.HasPrefix(rest, []byte(s.line)) {
+			end := bytes.IndexByte(rest, '\n')
+			if end < 0 {
+				end = len(rest)
+			}
+			if end > 0 && rest[end-1] == '\r' {
+				end--
+			}
+			if s.keeps(rest[:end]) {
+				out = append(out, rest[:end]...)
+				prev = rest[end-1]
+			} else {
+				touched[line] = true
+			}
+			i += end
+			continue
+		}
+
+		if s.blockStart != "" && bytes.HasPrefix(rest, []byte(s.blockStart)) {
+			end := s.blockCommentEnd(rest)
+			touched[line] = true
+			// Keep the tokens on either side of an inline comment apart
+			if end < len(rest) && !isSpace(prev) && !isSpace(rest[end]) {
+				out = append(out, ' ')
+				prev = ' '
+			}
+			i += end
+			continue
+		}
+
+		out = append(out, rest[0])
+		if rest[0] == '\n' {
+			line++
+		}
+		prev = rest[0]
+		i++
+	}
+
+	// Drop the lines that held nothing but comments and the trailing
+	// whitespace left where a comment was removed
+	lines := bytes.Split(out, []byte("\n"))
+	kept := lines[:0]
+	for n, text := range lines {
+		if touched[n] {
+			cr := bytes.HasSuffix(text, []byte("\r"))
+			text = bytes.TrimRight(text, " \t\r")
+			if len(text) == 0 {
+				continue
+			}
+			if cr {
+				text = append(text, '\r')
+			}
+		}
+		kept = append(kept, text)
+	}
+	return bytes.Join(kept, []byte("\n"))
+}
+
+// quoteAt returns the string literal starting at the beginning of rest, if
+// a literal may start there
+func (s *commentSyntax) quoteAt(rest []byte, allowed bool) (quoteRule, bool) {
+	if !allowed {
+		return quoteRule{}, false
+	}
+	for _, quote := range s.quotes {
+		if bytes.HasPrefix(rest, []byte(quote.delim)) {
+			return quote, true
+		}
+	}
+	return quoteRule{}, false
+}
+
+// end returns the length of the string literal opening rest, the whole of
+// rest when it is not closed
+func (q quoteRule) end(rest []byte) int {
+	for i := len(q.delim); i < len(rest); i++ {
+		switch {
+		case q.escapes && rest[i] == '\\':
+			i++
+		case !q.multiline && rest[i] == '\n':
+			return i
+		case bytes.HasPrefix(rest[i:], []byte(q.delim)):
+			return i + len(q.delim)
+		}
+	}
+	return len(rest)
+}
+
+// blockCommentEnd returns the length of the block comment opening rest
+func (s *commentSyntax) blockCommentEnd(rest []byte) int {
+	depth := 0
+	for i := 0; i < len(rest); {
+		switch {
+		case bytes.HasPrefix(rest[i:], []byte(s.blockStart)) && (i == 0 || s.nested):
+			depth++
+			i += len(s.blockStart)
+		case bytes.HasPrefix(rest[i:], []byte(s.blockEnd)):
+			depth--
+			i += len(s.blockEnd)
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return len(rest)
+}
+
+// keeps reports whether the line comment is a directive that must stay
+func (s *commentSyntax) keeps(comment []byte) bool {
+	for _, prefix := range s.keep {
+		if strings.HasPrefix(string(comment), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSpace reports whether c is whitespace
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
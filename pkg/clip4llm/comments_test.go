@@ -0,0 +1,65 @@
+package clip4llm
+
+import (
+	"testing"
+)
+
+func TestStripComments(t *testing.T) {
+	cases := []struct {
+		path  string
+		input string
+		want  string
+	}{
+		{
+			path: "main.go",
+			input: "//go:build linux\n\n// Package main does things\npackage main\n\n" +
+				"/* block\n   comment */\nvar url = \"http://example.com\" // trailing\n" +
+				"var raw = `// not a comment`\nvar x = a/*inline*/+b\n",
+			want: "//go:build linux\n\npackage main\n\n" +
+				"var url = \"http://example.com\"\n" +
+				"var raw = `// not a comment`\nvar x = a +b\n",
+		},
+		{
+			path:  "app.py",
+			input: "#!/usr/bin/env python\n# comment\nx = '#not' # real\ns = \"\"\"\n# kept in docstring\n\"\"\"\n",
+			want:  "#!/usr/bin/env python\nx = '#not'\ns = \"\"\"\n# kept in docstring\n\"\"\"\n",
+		},
+		{
+			path:  "run.sh",
+			input: "#!/bin/sh\n# setup\necho \"$#\" ${#x} 'a # b' # done\n",
+			want:  "#!/bin/sh\necho \"$#\" ${#x} 'a # b'\n",
+		},
+		{
+			path:  "config.yaml",
+			input: "# top\ntitle: Don't panic # note\nurl: \"a#b\"\n",
+			want:  "title: Don't panic\nurl: \"a#b\"\n",
+		},
+		{
+			path:  "lib.rs",
+			input: "/* outer /* inner */ still comment */\nfn f<'a>(x: &'a str) {} // end\n",
+			want:  "fn f<'a>(x: &'a str) {}\n",
+		},
+		{
+			path:  "index.ts",
+			input: "/// <reference types=\"node\" />\nconst s = `line\n// inside`; // gone\r\n",
+			want:  "/// <reference types=\"node\" />\nconst s = `line\n// inside`;\r\n",
+		},
+	}
+	for _, c := range cases {
+		got, ok := stripComments(detectLanguage(c.path, []byte(c.input)), []byte(c.input))
+		if !ok {
+			t.Errorf("%s: language not recognized", c.path)
+			continue
+		}
+		if string(got) != c.want {
+			t.Errorf("%s: stripComments =\n%q\nwant\n%q", c.path, got, c.want)
+		}
+	}
+
+	if _, ok := stripComments("markdown", []byte("# Title\n")); ok {
+		t.Error("markdown should be left alone")
+	}
+	if got, ok := stripComments("go", []byte("// #include <x.h>\nimport \"C\"\n")); ok || string(got) != "// #include <x.h>\nimport \"C\"\n" {
+		t.Error("cgo files should be left alone")
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bytes"
+)
+
+// Languages whose indentation is syntax that single spaces would break, or
+// whose trailing spaces are a hard line break
+var (
+	keepIndentLanguages   = map[string]bool{"makefile": true, "markdown": true}
+	keepTrailingLanguages = map[string]bool{"markdown": true}
+)
+
+// compactWhitespace trims trailing whitespace and collapses runs of blank
+// lines in content to one. With indent every level of indentation shrinks to
+// a single space, where a level is a tab or the largest number of spaces all
+// indented lines are a multiple of.
+func compactWhitespace(language string, content []byte, indent bool) []byte {
+	lines := bytes.Split(content, []byte("\n"))
+
+	unit := 0
+	if indent && !keepIndentLanguages[language] {
+		unit = indentUnit(lines)
+	}
+
+	compacted := lines[:0]
+	blank := false
+	for _, line := range lines {
+		cr := bytes.HasSuffix(line, []byte("\r"))
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if !keepTrailingLanguages[language] {
+			line = bytes.TrimRight(line, " \t")
+		}
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			if blank {
+				continue
+			}
+			blank = true
+			line = nil
+		} else {
+			blank = false
+			if unit > 0 {
+				line = shrinkIndent(line, unit)
+			}
+		}
+
+		if cr {
+			line = append(line, '\r')
+		}
+		compacted = append(compacted, line)
+	}
+	return bytes.Join(compacted, []byte("\n"))
+}
+
+// indentUnit returns the number of spaces in one level of indentation, the
+// greatest common divisor of the space indentation of all lines after their
+// leading tabs. It returns 1 when no line is indented with spaces.
+func indentUnit(lines [][]byte) int {
+	unit := 0
+	for _, line := range lines {
+		line = bytes.TrimLeft(line, "\t")
+		spaces := len(line) - len(bytes.TrimLeft(line, " "))
+		if spaces == 0 || len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		for b := spaces; b != 0; {
+			unit, b = b, unit%b
+		}
+	}
+	return max(unit, 1)
+}
+
+// shrinkIndent replaces every level of the leading indentation of line with a
+// single space
+func shrinkIndent(line []byte, unit int) []byte {
+	tabs := len(line) - len(bytes.TrimLeft(line, "\t"))
+	rest := line[tabs:]
+	spaces := len(rest) - len(bytes.TrimLeft(rest, " "))
+	levels := tabs + spaces/unit
+	return append(bytes.Repeat([]byte(" "), levels+spaces%unit), rest[spaces:]...)
+}
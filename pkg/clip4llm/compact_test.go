@@ -0,0 +1,29 @@
+package clip4llm
+
+import (
+	"testing"
+)
+
+func TestCompactWhitespace(t *testing.T) {
+	cases := []struct {
+		path   string
+		input  string
+		indent bool
+		want   string
+	}{
+		{"main.go", "package main  \n\n\n\nfunc f() {\n\tif x {\n\t\treturn\t\n\t}\n}\n", false,
+			"package main\n\nfunc f() {\n\tif x {\n\t\treturn\n\t}\n}\n"},
+		{"main.go", "func f() {\n\tif x {\n\t\treturn\n\t}\n}\n", true,
+			"func f() {\n if x {\n  return\n }\n}\n"},
+		{"app.py", "def f():\n    if x:\n        return 1\r\n\r\n\r\n    return 2\n", true,
+			"def f():\n if x:\n  return 1\r\n\r\n return 2\n"},
+		{"align.py", "call(a,\n     b)\n    x = 1\n", true, "call(a,\n     b)\n    x = 1\n"},
+		{"Makefile", "build:\n\tgo build\n", true, "build:\n\tgo build\n"},
+		{"README.md", "line  \nnext\n\n\n", true, "line  \nnext\n"},
+	}
+	for _, c := range cases {
+		if got := string(compactWhitespace(detectLanguage(c.path, []byte(c.input)), []byte(c.input), c.indent)); got != c.want {
+			t.Errorf("compactWhitespace(%s, indent=%v) =\n%q\nwant\n%q", c.path, c.indent, got, c.want)
+		}
+	}
+}
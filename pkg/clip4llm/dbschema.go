@@ -0,0 +1,114 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// schemaDump is the invocation of a dump tool, with the credentials it
+// reads from the environment kept apart from its arguments
+type schemaDump struct {
+	env  []string
+	name string
+	args []string
+}
+
+// dumpDatabaseSchema returns the schema DDL (no data) of the database
+// identified by dsn, using the vendor's own dump tool: pg_dump for
+// postgres:// URLs, mysqldump for mysql:// URLs and sqlite3 for sqlite: URLs
+// or a plain path to a SQLite database file.
+func dumpDatabaseSchema(dsn string) (string, error) {
+	dump, err := schemaDumpCommand(dsn)
+	if err != nil {
+		return "", err
+	}
+	return runSchemaDump(dump.env, dump.name, dump.args...)
+}
+
+// schemaDumpCommand returns the dump tool invocation for dsn. Passwords go
+// through the environment so they do not show up in the process list.
+func schemaDumpCommand(dsn string) (schemaDump, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" || len(u.Scheme) == 1 {
+		// Not a URL (or a Windows drive letter), treat it as a SQLite file
+		return schemaDump{name: "sqlite3", args: []string{dsn, ".schema"}}, nil
+	}
+
+	switch u.Scheme {
+	case "postgres", "postgresql":
+		var env []string
+		if u.User != nil {
+			if password, ok := u.User.Password(); ok {
+				env = append(env, "PGPASSWORD="+password)
+				u.User = url.User(u.User.Username())
+			}
+		}
+		return schemaDump{env: env, name: "pg_dump", args: []string{"--schema-only", "--no-owner", "--no-privileges", u.String()}}, nil
+
+	case "mysql":
+		database := strings.TrimPrefix(u.Path, "/")
+		if database == "" {
+			return schemaDump{}, fmt.Errorf("mysql DSN must name a database")
+		}
+		args := []string{"--no-data", "--skip-comments", "--skip-add-drop-table", "--routines"}
+		if host := u.Hostname(); host != "" {
+			args = append(args, "--host="+host)
+		}
+		if port := u.Port(); port != "" {
+			args = append(args, "--port="+port)
+		}
+		var env []string
+		if u.User != nil {
+			args = append(args, "--user="+u.User.Username())
+			if password, ok := u.User.Password(); ok {
+				env = append(env, "MYSQL_PWD="+password)
+			}
+		}
+		args = append(args, database)
+		return schemaDump{env: env, name: "mysqldump", args: args}, nil
+
+	case "sqlite", "sqlite3", "file":
+		path := u.Opaque
+		if path == "" {
+			path = u.Path
+		}
+		return schemaDump{name: "sqlite3", args: []string{path, ".schema"}}, nil
+	}
+
+	return schemaDump{}, fmt.Errorf("unsupported database scheme %q (expected postgres, mysql or sqlite)", u.Scheme)
+}
+
+// runSchemaDump runs a dump tool and returns its standard output
+func runSchemaDump(env []string, name string, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", fmt.Errorf("%s is required to dump the schema but was not found in PATH", name)
+	}
+
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed: %v: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// redactDSN removes any password from a DSN so it can be shown in the output
+func redactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.User == nil {
+		return dsn
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	return u.String()
+}
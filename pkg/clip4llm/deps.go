@@ -0,0 +1,167 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// dependencyManifest is the parsed list of direct dependencies of one manifest file
+type dependencyManifest struct {
+	name         string
+	dependencies []string
+}
+
+// summarizeDependencies parses the dependency manifests found in dir and
+// returns a compact listing of direct dependencies and their versions.
+func summarizeDependencies(dir string) (string, error) {
+	parsers := []struct {
+		file  string
+		parse func(path string) ([]string, error)
+	}{
+		{"go.mod", parseGoModDependencies},
+		{"package.json", parsePackageJSONDependencies},
+		{"requirements.txt", parseRequirementsDependencies},
+	}
+
+	var manifests []dependencyManifest
+	for _, parser := range parsers {
+		path := filepath.Join(dir, parser.file)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		dependencies, err := parser.parse(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse %s: %v", parser.file, err)
+		}
+		manifests = append(manifests, dependencyManifest{name: parser.file, dependencies: dependencies})
+	}
+
+	if len(manifests) == 0 {
+		return "", fmt.Errorf("no dependency manifest (go.mod, package.json, requirements.txt) found")
+	}
+
+	var builder strings.Builder
+	for i, manifest := range manifests {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(manifest.name + "\n")
+		if len(manifest.dependencies) == 0 {
+			builder.WriteString("  (no direct dependencies)\n")
+		}
+		for _, dependency := range manifest.dependencies {
+			builder.WriteString("  " + dependency + "\n")
+		}
+	}
+	return strings.TrimRight(builder.String(), "\n"), nil
+}
+
+// parseGoModDependencies returns the direct requirements of a go.mod file,
+// skipping those marked // indirect.
+func parseGoModDependencies(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var dependencies []string
+	inRequireBlock := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		var requirement string
+		switch {
+		case inRequireBlock && line == ")":
+			inRequireBlock = false
+			continue
+		case inRequireBlock:
+			requirement = line
+		case line == "require (":
+			inRequireBlock = true
+			continue
+		case strings.HasPrefix(line, "require "):
+			requirement = strings.TrimPrefix(line, "require ")
+		default:
+			continue
+		}
+
+		if strings.Contains(requirement, "// indirect") {
+			continue
+		}
+		if i := strings.Index(requirement, "//"); i >= 0 {
+			requirement = requirement[:i]
+		}
+		if fields := strings.Fields(requirement); len(fields) == 2 {
+			dependencies = append(dependencies, fields[0]+" "+fields[1])
+		}
+	}
+	return dependencies, scanner.Err()
+}
+
+// parsePackageJSONDependencies returns the dependencies and devDependencies of
+// a package.json file, with development dependencies marked as such.
+func parsePackageJSONDependencies(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest struct {
+		Dependencies     map[string]string `json:"dependencies"`
+		DevDependencies  map[string]string `json:"devDependencies"`
+		PeerDependencies map[string]string `json:"peerDependencies"`
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+
+	var dependencies []string
+	appendSorted := func(deps map[string]string, suffix string) {
+		names := make([]string, 0, len(deps))
+		for name := range deps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			dependencies = append(dependencies, name+" "+deps[name]+suffix)
+		}
+	}
+	appendSorted(manifest.Dependencies, "")
+	appendSorted(manifest.PeerDependencies, " (peer)")
+	appendSorted(manifest.DevDependencies, " (dev)")
+	return dependencies, nil
+}
+
+// parseRequirementsDependencies returns the requirement specifiers of a pip
+// requirements.txt file, skipping comments, options and nested requirement files.
+func parseRequirementsDependencies(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var dependencies []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		dependencies = append(dependencies, line)
+	}
+	return dependencies, scanner.Err()
+}
@@ -0,0 +1,45 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSummarizeDependencies(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":           "module example.com/app\n\ngo 1.23\n\nrequire github.com/spf13/cobra v1.8.0\n\nrequire (\n\tgithub.com/fsnotify/fsnotify v1.7.0 // watch support\n\tgolang.org/x/sys v0.20.0 // indirect\n)\n",
+		"package.json":     `{"dependencies": {"react": "^18.2.0", "axios": "^1.6.0"}, "devDependencies": {"vitest": "^1.0.0"}, "peerDependencies": {"react-dom": "^18.0.0"}}`,
+		"requirements.txt": "# web\nflask==3.0.0  # pinned\n-r dev.txt\n\nrequests>=2.31\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := summarizeDependencies(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `go.mod
+  github.com/spf13/cobra v1.8.0
+  github.com/fsnotify/fsnotify v1.7.0
+
+package.json
+  axios ^1.6.0
+  react ^18.2.0
+  react-dom ^18.0.0 (peer)
+  vitest ^1.0.0 (dev)
+
+requirements.txt
+  flask==3.0.0
+  requests>=2.31`
+	if got != want {
+		t.Errorf("summary:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := summarizeDependencies(t.TempDir()); err == nil {
+		t.Error("a directory without manifests was summarized")
+	}
+}
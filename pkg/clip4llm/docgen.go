@@ -0,0 +1,123 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// goDeclarationSkeleton reduces Go source to its package clause and exported
+// declarations with their doc comments, dropping function bodies.
+func goDeclarationSkeleton(content []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Associate comments with nodes so those inside removed code go with it
+	comments := ast.NewCommentMap(fset, file, file.Comments)
+
+	ast.FileExports(file)
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			fn.Body = nil
+		}
+	}
+	file.Comments = comments.Filter(file).Comments()
+
+	var buf bytes.Buffer
+	config := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+	if err := config.Fprint(&buf, fset, file); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Matches a Python def or class statement, capturing its indentation and name
+var pyDeclarationPattern = regexp.MustCompile(`^(\s*)(?:async\s+)?(?:def|class)\s+(\w+)`)
+
+// pyDeclarationSkeleton reduces Python source to its public classes and
+// functions (signature, decorators and docstring) dropping their bodies.
+func pyDeclarationSkeleton(content []byte) []byte {
+	lines := strings.Split(string(content), "\n")
+	var out []string
+	var decorators []string
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "@") {
+			decorators = append(decorators, line)
+			continue
+		}
+
+		match := pyDeclarationPattern.FindStringSubmatch(line)
+		if match == nil {
+			decorators = nil
+			continue
+		}
+		if strings.HasPrefix(match[2], "_") && match[2] != "__init__" {
+			decorators = nil
+			continue
+		}
+
+		out = append(out, decorators...)
+		decorators = nil
+
+		// The signature may span several lines until the closing colon
+		out = append(out, line)
+		for !strings.HasSuffix(strings.TrimSpace(stripPyComment(lines[i])), ":") && i+1 < len(lines) {
+			i++
+			out = append(out, lines[i])
+		}
+
+		// Keep the docstring immediately following the signature
+		j := i + 1
+		for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+			j++
+		}
+		if j < len(lines) {
+			doc := strings.TrimSpace(lines[j])
+			for _, quote := range []string{`"""`, `'''`} {
+				if !strings.HasPrefix(doc, quote) {
+					continue
+				}
+				out = append(out, lines[j])
+				if !(len(doc) >= 6 && strings.HasSuffix(doc, quote)) {
+					for j+1 < len(lines) {
+						j++
+						out = append(out, lines[j])
+						if strings.Contains(lines[j], quote) {
+							break
+						}
+					}
+				}
+				break
+			}
+		}
+
+		indent := match[1]
+		if strings.HasPrefix(strings.TrimSpace(line), "class") {
+			continue
+		}
+		out = append(out, indent+"    ...", "")
+	}
+
+	return []byte(strings.Join(out, "\n") + "\n")
+}
+
+// stripPyComment removes a trailing # comment from a line of Python
+func stripPyComment(line string) string {
+	if i := strings.Index(line, "#"); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
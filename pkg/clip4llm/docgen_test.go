@@ -0,0 +1,84 @@
+package clip4llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGoDeclarationSkeleton(t *testing.T) {
+	source := `package calc
+
+// Add returns the sum of a and b
+func Add(a, b int) int {
+	// inside the body
+	return a + b
+}
+
+func helper() {}
+
+// Calculator keeps a running total
+type Calculator struct {
+	Total int
+	steps int
+}
+`
+	got, err := goDeclarationSkeleton([]byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `package calc
+
+// Add returns the sum of a and b
+func Add(a, b int) int
+
+// Calculator keeps a running total
+type Calculator struct {
+	Total int
+`
+	if !strings.HasPrefix(string(got), want) || strings.Contains(string(got), "steps") {
+		t.Errorf("goDeclarationSkeleton =\n%s\nwant\n%s", got, want)
+	}
+
+	if _, err := goDeclarationSkeleton([]byte("package calc\nfunc {")); err == nil {
+		t.Error("expected an error for invalid Go")
+	}
+}
+
+func TestPyDeclarationSkeleton(t *testing.T) {
+	source := `import os
+
+@cache
+def load(path,
+         mode="r"):  # open it
+    """Load a file."""
+    return open(path, mode)
+
+def _private():
+    pass
+
+class Store:
+    """A key value store."""
+
+    def get(self, key):
+        return self.data[key]
+`
+	got := string(pyDeclarationSkeleton([]byte(source)))
+	want := `@cache
+def load(path,
+         mode="r"):  # open it
+    """Load a file."""
+    ...
+
+class Store:
+    """A key value store."""
+    def get(self, key):
+        ...
+
+`
+	if got != want {
+		t.Errorf("pyDeclarationSkeleton =\n%s\nwant\n%s", got, want)
+	}
+	if strings.Contains(got, "_private") {
+		t.Error("private functions should be dropped")
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Headers kept from every message, in this order
+var emailHeaders = []string{"From", "To", "Cc", "Date", "Subject"}
+
+// Nesting of multipart bodies followed before giving up
+const maxEmailDepth = 5
+
+// isEmailFile reports whether the file at path is an RFC 822 message or an
+// mbox mailbox
+func isEmailFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".eml", ".mbox":
+		return true
+	}
+	return false
+}
+
+// extractEmail reduces the messages in content, a single message or an mbox
+// mailbox, to their main headers and plain text bodies. Attachments are
+// replaced by a line naming them.
+func extractEmail(content []byte) ([]byte, error) {
+	var extracted []string
+	for _, message := range splitMbox(content) {
+		text, err := extractMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		extracted = append(extracted, text)
+	}
+	return []byte(strings.Join(extracted, "\n\n---\n\n") + "\n"), nil
+}
+
+// splitMbox splits a mailbox into its messages on the "From " lines opening
+// each of them. Content without such a line is a single message.
+func splitMbox(content []byte) [][]byte {
+	if !bytes.HasPrefix(content, []byte("From ")) {
+		return [][]byte{content}
+	}
+	var messages [][]byte
+	for _, message := range bytes.Split(content, []byte("\nFrom ")) {
+		// Drop the envelope line, the rest is the message
+		if end := bytes.IndexByte(message, '\n'); end >= 0 {
+			messages = append(messages, message[end+1:])
+		}
+	}
+	return messages
+}
+
+// extractMessage renders the headers and text body of one message
+func extractMessage(raw []byte) (string, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return "", fmt.Errorf("not an email message: %v", err)
+	}
+
+	var out strings.Builder
+	decoder := new(mime.WordDecoder)
+	for _, name := range emailHeaders {
+		value := msg.Header.Get(name)
+		if value == "" {
+			continue
+		}
+		if decoded, err := decoder.DecodeHeader(value); err == nil {
+			value = decoded
+		}
+		fmt.Fprintf(&out, "%s: %s\n", name, value)
+	}
+	out.WriteString("\n")
+
+	var attachments []string
+	body := extractBody(msg.Header, msg.Body, &attachments, 0)
+	out.WriteString(strings.TrimSpace(body))
+	for _, attachment := range attachments {
+		fmt.Fprintf(&out, "\n[attachment omitted: %s]", attachment)
+	}
+	return out.String(), nil
+}
+
+// extractBody returns the plain text of a message body or part, preferring
+// text/plain over text/html among alternatives, and records the names of the
+// attachments left out.
+func extractBody(header map[string][]string, body io.Reader, attachments *[]string, depth int) string {
+	get := func(name string) string {
+		if values := header[name]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+	mediaType, params, err := mime.ParseMediaType(get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+
+	// Attachments are named but never included, even text ones
+	disposition, dispositionParams, _ := mime.ParseMediaType(get("Content-Disposition"))
+	if disposition == "attachment" || (!strings.HasPrefix(mediaType, "text/") && !strings.HasPrefix(mediaType, "multipart/")) {
+		name := dispositionParams["filename"]
+		if name == "" {
+			name = params["name"]
+		}
+		if name == "" {
+			name = "unnamed"
+		}
+		*attachments = append(*attachments, fmt.Sprintf("%s (%s)", name, mediaType))
+		return ""
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxEmailDepth {
+			return ""
+		}
+		reader := multipart.NewReader(body, params["boundary"])
+		var plain, htmlText []string
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			text := extractBody(part.Header, part, attachments, depth+1)
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			if partType == "text/html" {
+				htmlText = append(htmlText, text)
+			} else if text != "" {
+				plain = append(plain, text)
+			}
+		}
+		// Alternatives carry the same text twice, keep the HTML only without plain text
+		if mediaType == "multipart/alternative" && len(plain) > 0 {
+			return strings.Join(plain, "\n\n")
+		}
+		return strings.Join(append(plain, htmlText...), "\n\n")
+	}
+
+	content, err := io.ReadAll(decodeTransfer(get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return ""
+	}
+	// Bodies in a legacy charset become UTF-8 like the rest of the output
+	content, _ = decodeText(content)
+	if mediaType == "text/html" {
+		return htmlToText(string(content))
+	}
+	return string(content)
+}
+
+// decodeTransfer undoes the content transfer encoding of a body
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+	return body
+}
+
+// Patterns reducing an HTML body to text
+var (
+	htmlDropped = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>`)
+	htmlBreaks  = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|tr|h[1-6])>`)
+	htmlTags    = regexp.MustCompile(`<[^>]*>`)
+	blankRuns   = regexp.MustCompile(`\n[ \t]*\n(?:[ \t]*\n)+`)
+)
+
+// htmlToText reduces an HTML body to its text, good enough for the email
+// bodies that come without a plain text alternative
+func htmlToText(body string) string {
+	body = htmlDropped.ReplaceAllString(body, "")
+	body = htmlBreaks.ReplaceAllString(body, "\n")
+	body = htmlTags.ReplaceAllString(body, "")
+	body = html.UnescapeString(body)
+	return blankRuns.ReplaceAllString(body, "\n\n")
+}
@@ -0,0 +1,71 @@
+package clip4llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractEmail(t *testing.T) {
+	message := "From: Jane <jane@example.com>\r\n" +
+		"To: support@example.com\r\n" +
+		"Subject: =?UTF-8?Q?Crash_on_caf=C3=A9?=\r\n" +
+		"Received: from mx.example.com\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=outer\r\n" +
+		"\r\n" +
+		"--outer\r\n" +
+		"Content-Type: multipart/alternative; boundary=inner\r\n" +
+		"\r\n" +
+		"--inner\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"The app crashes =\r\nwhen I click save.\r\n" +
+		"--inner\r\n" +
+		"Content-Type: text/html\r\n" +
+		"\r\n" +
+		"<p>The app crashes when I click save.</p>\r\n" +
+		"--inner--\r\n" +
+		"--outer\r\n" +
+		"Content-Type: application/pdf; name=\"invoice.pdf\"\r\n" +
+		"Content-Disposition: attachment; filename=\"invoice.pdf\"\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		"JVBERi0xLjQK\r\n" +
+		"--outer--\r\n"
+
+	got, err := extractEmail([]byte(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "From: Jane <jane@example.com>\n" +
+		"To: support@example.com\n" +
+		"Subject: Crash on café\n" +
+		"\n" +
+		"The app crashes when I click save.\n" +
+		"[attachment omitted: invoice.pdf (application/pdf)]\n"
+	if string(got) != want {
+		t.Errorf("extractEmail =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestExtractEmailMbox(t *testing.T) {
+	mbox := "From jane@example.com Mon Jan 1 00:00:00 2024\n" +
+		"From: jane@example.com\nSubject: First\n\nHello\n\n" +
+		"From bob@example.com Tue Jan 2 00:00:00 2024\n" +
+		"From: bob@example.com\nSubject: Second\nContent-Type: text/html\n\n<p>Hi &amp; bye</p><script>x()</script>\n"
+
+	got, err := extractEmail([]byte(mbox))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := string(got)
+	for _, want := range []string{"Subject: First\n\nHello", "\n---\n", "Subject: Second\n\nHi & bye"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q in\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "x()") {
+		t.Errorf("script was not dropped:\n%s", text)
+	}
+}
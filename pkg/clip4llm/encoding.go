@@ -0,0 +1,153 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bytes"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// The text encodings detected in file contents
+const (
+	encodingBinary  = ""
+	encodingUTF8    = "UTF-8"
+	encodingUTF16LE = "UTF-16LE"
+	encodingUTF16BE = "UTF-16BE"
+	encodingLatin1  = "ISO-8859-1"
+)
+
+// Byte order marks of the Unicode encodings
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// detectEncoding guesses the text encoding of a sample from the start of a
+// file, returning encodingBinary when it does not look like text. A byte order
+// mark decides, otherwise UTF-16 is recognized by its zero bytes and Latin-1
+// by high bytes that are not valid UTF-8. The sample may end mid-character.
+func detectEncoding(sample []byte) string {
+	switch {
+	case bytes.HasPrefix(sample, bomUTF8):
+		return encodingUTF8
+	case bytes.HasPrefix(sample, bomUTF16LE):
+		return encodingUTF16LE
+	case bytes.HasPrefix(sample, bomUTF16BE):
+		return encodingUTF16BE
+	}
+
+	if encoding := detectUTF16(sample); encoding != encodingBinary {
+		return encoding
+	}
+
+	ascii := true
+	for _, b := range sample {
+		if isControlByte(b) {
+			return encodingBinary
+		}
+		if b >= 0x80 {
+			ascii = false
+		}
+	}
+	if ascii {
+		return encodingUTF8
+	}
+
+	// Allow the sample to cut the last character short
+	valid := sample
+	for i := 1; i < utf8.UTFMax && i <= len(sample); i++ {
+		if utf8.RuneStart(sample[len(sample)-i]) {
+			if !utf8.FullRune(sample[len(sample)-i:]) {
+				valid = sample[:len(sample)-i]
+			}
+			break
+		}
+	}
+	if utf8.Valid(valid) {
+		return encodingUTF8
+	}
+
+	// Latin-1 assigns control characters to 0x80-0x9F, text never has them
+	for _, b := range sample {
+		if b >= 0x80 && b < 0xA0 {
+			return encodingBinary
+		}
+	}
+	return encodingLatin1
+}
+
+// detectUTF16 recognizes UTF-16 text without a byte order mark by the zero
+// high bytes of its ASCII characters, which all fall on one side.
+func detectUTF16(sample []byte) string {
+	pairs := len(sample) / 2
+	if pairs < 2 {
+		return encodingBinary
+	}
+
+	var evenZeros, oddZeros int
+	for i := 0; i+1 < len(sample); i += 2 {
+		if sample[i] == 0 {
+			evenZeros++
+			if isControlByte(sample[i+1]) {
+				return encodingBinary
+			}
+		}
+		if sample[i+1] == 0 {
+			oddZeros++
+			if isControlByte(sample[i]) {
+				return encodingBinary
+			}
+		}
+	}
+
+	// Mostly ASCII text leaves at least half of the high bytes zero
+	switch {
+	case evenZeros == 0 && oddZeros*2 >= pairs:
+		return encodingUTF16LE
+	case oddZeros == 0 && evenZeros*2 >= pairs:
+		return encodingUTF16BE
+	}
+	return encodingBinary
+}
+
+// decodeText converts content in the detected encoding to UTF-8, dropping any
+// byte order mark. Content that is already UTF-8 or binary is returned as is.
+func decodeText(content []byte) ([]byte, string) {
+	encoding := detectEncoding(content)
+	switch encoding {
+	case encodingUTF8:
+		return bytes.TrimPrefix(content, bomUTF8), encoding
+
+	case encodingUTF16LE, encodingUTF16BE:
+		content = bytes.TrimPrefix(content, bomUTF16LE)
+		if encoding == encodingUTF16BE {
+			content = bytes.TrimPrefix(content, bomUTF16BE)
+		}
+		units := make([]uint16, len(content)/2)
+		for i := range units {
+			if encoding == encodingUTF16LE {
+				units[i] = uint16(content[2*i]) | uint16(content[2*i+1])<<8
+			} else {
+				units[i] = uint16(content[2*i])<<8 | uint16(content[2*i+1])
+			}
+		}
+		return []byte(string(utf16.Decode(units))), encoding
+
+	case encodingLatin1:
+		// Every Latin-1 byte is the code point of the same value
+		runes := make([]rune, len(content))
+		for i, b := range content {
+			runes[i] = rune(b)
+		}
+		return []byte(string(runes)), encoding
+	}
+	return content, encoding
+}
+
+// isControlByte reports whether b is an ASCII control character other than
+// the whitespace found in text
+func isControlByte(b byte) bool {
+	return b < 32 && b != '\n' && b != '\r' && b != '\t'
+}
@@ -0,0 +1,38 @@
+package clip4llm
+
+import (
+	"testing"
+)
+
+func TestDecodeText(t *testing.T) {
+	cases := []struct {
+		name     string
+		content  []byte
+		encoding string
+		text     string
+	}{
+		{"ascii", []byte("plain text\n"), encodingUTF8, "plain text\n"},
+		{"utf-8", []byte("naïve ✓\n"), encodingUTF8, "naïve ✓\n"},
+		{"utf-8 bom", []byte("\xEF\xBB\xBFhi\n"), encodingUTF8, "hi\n"},
+		{"utf-16le bom", []byte("\xFF\xFEh\x00\xE9\x00\n\x00"), encodingUTF16LE, "hé\n"},
+		{"utf-16be bom", []byte("\xFE\xFF\x00h\x00\xE9\x00\n"), encodingUTF16BE, "hé\n"},
+		{"utf-16le", []byte("a\x00b\x00c\x00\n\x00"), encodingUTF16LE, "abc\n"},
+		{"latin-1", []byte("caf\xE9 cr\xE8me\n"), encodingLatin1, "café crème\n"},
+		{"binary", []byte{0x7F, 0x45, 0x4C, 0x46, 0x02, 0x01, 0x00, 0x00}, encodingBinary, "\x7FELF\x02\x01\x00\x00"},
+		{"c1 controls", []byte("a\x85\x90b"), encodingBinary, "a\x85\x90b"},
+	}
+	for _, c := range cases {
+		text, encoding := decodeText(c.content)
+		if encoding != c.encoding || string(text) != c.text {
+			t.Errorf("%s: decodeText = %q, %q, want %q, %q", c.name, text, encoding, c.text, c.encoding)
+		}
+	}
+}
+
+func TestDetectEncodingTruncatedSample(t *testing.T) {
+	// A sample cut in the middle of a multi-byte character is still UTF-8
+	sample := []byte("résumé ✓")
+	if got := detectEncoding(sample[:len(sample)-1]); got != encodingUTF8 {
+		t.Errorf("detectEncoding = %q, want %q", got, encodingUTF8)
+	}
+}
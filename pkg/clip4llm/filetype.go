@@ -0,0 +1,155 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Languages of source files keyed by their lowercased extension
+var extensionLanguages = map[string]string{
+	".go": "go", ".py": "python", ".js": "javascript", ".mjs": "javascript", ".cjs": "javascript",
+	".jsx": "javascript", ".ts": "typescript", ".tsx": "typescript", ".java": "java", ".kt": "kotlin",
+	".scala": "scala", ".cs": "csharp", ".rb": "ruby", ".php": "php", ".rs": "rust", ".swift": "swift",
+	".c": "c", ".h": "c", ".cc": "cpp", ".cpp": "cpp", ".hpp": "cpp", ".m": "objective-c",
+	".sh": "shell", ".bash": "shell", ".zsh": "shell", ".ps1": "powershell", ".sql": "sql",
+	".html": "html", ".css": "css", ".scss": "scss", ".vue": "vue", ".svelte": "svelte",
+	".json": "json", ".yaml": "yaml", ".yml": "yaml", ".toml": "toml", ".xml": "xml", ".ini": "ini",
+	".md": "markdown", ".proto": "protobuf", ".graphql": "graphql", ".tf": "terraform",
+	".lua": "lua", ".dart": "dart", ".ex": "elixir", ".exs": "elixir", ".hs": "haskell",
+	".pl": "perl", ".pm": "perl",
+}
+
+// Languages of files recognized by their whole name
+var fileNameLanguages = map[string]string{
+	"dockerfile": "dockerfile", "makefile": "makefile", "gnumakefile": "makefile",
+	"go.mod": "go-module", "cmakelists.txt": "cmake",
+}
+
+// languageOf returns the language of a file judged by its name, or an empty
+// string when it is not recognized
+func languageOf(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	if language, ok := fileNameLanguages[name]; ok {
+		return language
+	}
+	return extensionLanguages[filepath.Ext(name)]
+}
+
+// Labels marking the kind of each file in the directory tree
+var fileKindLabels = map[string]string{"config": "⚙️", "test": "🧪", "doc": "📄", "code": "🧩"}
+
+// Languages of configuration and build files rather than code
+var configLanguages = map[string]bool{
+	"json": true, "yaml": true, "toml": true, "xml": true, "ini": true,
+	"dockerfile": true, "makefile": true, "go-module": true, "cmake": true,
+}
+
+// Extensions and names of documentation beyond markdown
+var docExtensions = map[string]bool{".md": true, ".txt": true, ".rst": true, ".adoc": true}
+var docNames = map[string]bool{"readme": true, "license": true, "changelog": true, "contributing": true, "authors": true, "notice": true}
+
+// fileKind classifies a file by its name as config, test, doc or code, or
+// returns an empty string when it fits none of them
+func fileKind(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	ext := filepath.Ext(name)
+	switch {
+	case isTestFile(path):
+		return "test"
+	case docExtensions[ext] || docNames[strings.TrimSuffix(name, ext)]:
+		return "doc"
+	case configLanguages[languageOf(path)], ext == ".env", ext == ".cfg", ext == ".conf", ext == ".properties",
+		strings.HasPrefix(name, ".") && ext == name:
+		return "config"
+	case languageOf(path) != "":
+		return "code"
+	}
+	return ""
+}
+
+// languageHint recognizes a language by a telltale construct in the content
+type languageHint struct {
+	language string
+	pattern  *regexp.Regexp
+}
+
+// Objective-C directives, shared by headers and implementation files
+var objcPattern = regexp.MustCompile(`(?m)^\s*(?:@interface|@implementation|@protocol|@property|@end\b|#import\b)`)
+
+// Hints for the extensions several languages share, checked in order. The
+// language of the extension stays when none matches.
+var ambiguousExtensions = map[string][]languageHint{
+	".h": {
+		{"objective-c", objcPattern},
+		{"cpp", regexp.MustCompile(`(?m)^\s*(?:class|namespace|template)\b|\bstd::|^\s*(?:public|private|protected):|#include <(?:iostream|string|vector|memory|map)>`)},
+	},
+	".m": {
+		{"objective-c", objcPattern},
+		{"matlab", regexp.MustCompile(`(?m)^\s*(?:function\b|%|end\s*$)|\bdisp\(`)},
+	},
+	".pl": {
+		{"perl", regexp.MustCompile(`(?m)^#!.*perl|\buse\s+(?:strict|warnings)\b|\bmy\s+[$@%]|^\s*sub\s+\w+`)},
+		{"prolog", regexp.MustCompile(`(?m)^(?:[a-z]\w*(?:\(.*\))?\s*)?:-`)},
+	},
+}
+
+// Amount of content inspected to tell ambiguous languages apart
+const languageSniffSize = 64 * 1024
+
+// detectLanguage returns the language of a file like languageOf, looking at
+// the content to tell apart the languages sharing an extension, such as C,
+// C++ and Objective-C headers.
+func detectLanguage(path string, content []byte) string {
+	language := languageOf(path)
+	hints, ok := ambiguousExtensions[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return language
+	}
+	content = content[:min(len(content), languageSniffSize)]
+	for _, hint := range hints {
+		if hint.pattern.Match(content) {
+			return hint.language
+		}
+	}
+	return language
+}
+
+// languageFor returns the language of the file at path from the first
+// override matching its name or path, or else detectLanguage
+func (opts *options) languageFor(path string, content []byte) string {
+	for _, override := range opts.languages {
+		if matched, _ := matchesAnyPatternWithPath(filepath.Base(path), path, []string{override.Pattern}); matched {
+			return override.Language
+		}
+	}
+	return detectLanguage(path, content)
+}
+
+// Function to determine if a file is likely plain text or binary
+func isBinaryFile(path string, maxKB int) (bool, error) {
+	// Open the file
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	// Calculate the maximum number of bytes to read
+	maxBytes := maxKB * 1024
+
+	// Read up to maxBytes to analyze the file content
+	reader := bufio.NewReader(file)
+	buffer := make([]byte, maxBytes)
+	n, err := reader.Read(buffer)
+	if err != nil {
+		return false, err
+	}
+
+	// Treat content that is not text in any recognized encoding as binary
+	return detectEncoding(buffer[:n]) == encodingBinary, nil
+}
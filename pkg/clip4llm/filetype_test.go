@@ -0,0 +1,51 @@
+package clip4llm
+
+import (
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	cases := []struct {
+		path    string
+		content string
+		want    string
+	}{
+		{"util.h", "int add(int a, int b);\n", "c"},
+		{"widget.h", "#pragma once\nnamespace ui {\nclass Widget {};\n}\n", "cpp"},
+		{"View.h", "#import <UIKit/UIKit.h>\n@interface View : UIView\n@end\n", "objective-c"},
+		{"View.m", "#import \"View.h\"\n@implementation View\n@end\n", "objective-c"},
+		{"solve.m", "% Solve the system\nfunction x = solve(A, b)\n  x = A \\ b;\nend\n", "matlab"},
+		{"script.pl", "#!/usr/bin/perl\nuse strict;\nmy $x = 1;\n", "perl"},
+		{"family.pl", "parent(tom, bob).\ngrandparent(X, Z) :- parent(X, Y), parent(Y, Z).\n", "prolog"},
+		{"plain.pl", "print \"hi\";\n", "perl"},
+		{"main.go", "package main\n", "go"},
+	}
+	for _, c := range cases {
+		if got := detectLanguage(c.path, []byte(c.content)); got != c.want {
+			t.Errorf("detectLanguage(%s) = %q, want %q", c.path, got, c.want)
+		}
+	}
+}
+
+func TestLanguageFor(t *testing.T) {
+	opts := &options{languages: []LanguageOverride{
+		{Pattern: "templates/**/*.tpl", Language: "go-template"},
+		{Pattern: "Dockerfile*", Language: "dockerfile"},
+		{Pattern: "*.h", Language: "cpp"},
+	}}
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"./Dockerfile.prod", "dockerfile"},
+		{"./templates/mail/welcome.tpl", "go-template"},
+		{"./other/welcome.tpl", ""},
+		{"./util.h", "cpp"},
+		{"./main.go", "go"},
+	}
+	for _, c := range cases {
+		if got := opts.languageFor(c.path, []byte("int add(int a, int b);\n")); got != c.want {
+			t.Errorf("languageFor(%s) = %q, want %q", c.path, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Extensions of the markdown files front matter is stripped from
+var frontMatterExtensions = map[string]bool{".md": true, ".markdown": true, ".mdx": true}
+
+// isFrontMatterFile reports whether the file at path is markdown that may
+// start with a front matter block
+func isFrontMatterFile(path string) bool {
+	return frontMatterExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// stripFrontMatter removes the YAML (---) or TOML (+++) front matter block at
+// the start of content, keeping the top level keys listed in keep. It reports
+// false when content has no front matter.
+func stripFrontMatter(content []byte, keep []string) ([]byte, bool) {
+	text := strings.TrimPrefix(string(content), "\ufeff")
+	lines := strings.SplitAfter(text, "\n")
+	fence := strings.TrimSpace(lines[0])
+	if fence != "---" && fence != "+++" {
+		return content, false
+	}
+	end := -1
+	for i := 1; i < len(lines); i++ {
+		if line := strings.TrimSpace(lines[i]); line == fence || (fence == "---" && line == "...") {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return content, false
+	}
+
+	// Keep the wanted keys along with the indented lines continuing them
+	var kept []string
+	keeping := false
+	for _, line := range lines[1:end] {
+		if line != "" && line[0] != ' ' && line[0] != '\t' {
+			keeping = keepFrontMatterKey(line, fence, keep)
+		}
+		if keeping {
+			kept = append(kept, line)
+		}
+	}
+
+	body := strings.TrimLeft(strings.Join(lines[end+1:], ""), "\r\n")
+	if len(kept) == 0 {
+		return []byte(body), true
+	}
+	var b strings.Builder
+	b.WriteString(fence + "\n")
+	for _, line := range kept {
+		b.WriteString(strings.TrimRight(line, "\r\n") + "\n")
+	}
+	b.WriteString(fence + "\n\n")
+	b.WriteString(body)
+	return []byte(b.String()), true
+}
+
+// keepFrontMatterKey reports whether the front matter line starts one of the
+// keys in keep, key: for YAML and key = for TOML
+func keepFrontMatterKey(line, fence string, keep []string) bool {
+	separator := ":"
+	if fence == "+++" {
+		separator = "="
+	}
+	key, _, found := strings.Cut(line, separator)
+	if !found {
+		return false
+	}
+	key = strings.Trim(strings.TrimSpace(key), `"'`)
+	for _, want := range keep {
+		if strings.EqualFold(key, want) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package clip4llm
+
+import "testing"
+
+func TestStripFrontMatter(t *testing.T) {
+	yaml := "---\ntitle: Hello\ntags:\n  - go\n  - llm\ndraft: true\n---\n\n# Hello\n"
+	cases := []struct {
+		name    string
+		content string
+		keep    []string
+		want    string
+		ok      bool
+	}{
+		{"yaml", yaml, nil, "# Hello\n", true},
+		{"yaml keep", yaml, []string{"title", "tags"}, "---\ntitle: Hello\ntags:\n  - go\n  - llm\n---\n\n# Hello\n", true},
+		{"toml keep", "+++\ntitle = \"Hello\"\ndate = 2024-01-02\n+++\nBody\n", []string{"date"}, "+++\ndate = 2024-01-02\n+++\n\nBody\n", true},
+		{"no front matter", "# Hello\n---\n", nil, "# Hello\n---\n", false},
+		{"unterminated", "---\ntitle: Hello\n", nil, "---\ntitle: Hello\n", false},
+	}
+	for _, c := range cases {
+		got, ok := stripFrontMatter([]byte(c.content), c.keep)
+		if string(got) != c.want || ok != c.ok {
+			t.Errorf("%s: got %q, %v, want %q, %v", c.name, got, ok, c.want, c.ok)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Name patterns of files that are generated by tools rather than written
+var generatedPatterns = []string{
+	"*.pb.go", "*.pb.gw.go", "*_pb2.py", "*_pb2_grpc.py", "*.pb.cc", "*.pb.h", "*_pb.js", "*_pb.d.ts",
+	"*.min.js", "*.min.mjs", "*.min.css", "*.js.map", "*.mjs.map", "*.css.map",
+	"package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml", "bun.lockb",
+	"go.sum", "Cargo.lock", "Gemfile.lock", "poetry.lock", "Pipfile.lock", "composer.lock", "pubspec.lock",
+	"mix.lock", "flake.lock", "packages.lock.json", "Podfile.lock", "uv.lock",
+}
+
+// Markers of generated content near the top of a file: the Go convention
+// and the @generated tag used across JavaScript, Rust and others
+var generatedMarker = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$|^\s*(?://|#|/?\*+|--)\s*@generated\b`)
+
+// Bytes read from the start of a file to look for markers and minified code
+const generatedSniffSize = 8 * 1024
+
+// A script or stylesheet whose lines are longer than this on average is minified
+const minifiedLineLength = 500
+
+// isGeneratedFile reports whether the file at path looks generated: a known
+// generated name such as a lockfile, a generated code marker near the top or
+// minified JavaScript or CSS.
+func isGeneratedFile(path string) bool {
+	name := filepath.Base(path)
+	for _, pattern := range generatedPatterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	sample := make([]byte, generatedSniffSize)
+	n, err := io.ReadFull(file, sample)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return false
+	}
+	sample = sample[:n]
+
+	if generatedMarker.Match(sample) {
+		return true
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".js", ".mjs", ".cjs", ".css":
+		lines := bytes.Count(sample, []byte("\n")) + 1
+		return len(sample)/lines > minifiedLineLength
+	}
+	return false
+}
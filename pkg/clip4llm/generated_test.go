@@ -0,0 +1,37 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsGeneratedFile(t *testing.T) {
+	dir := t.TempDir()
+	cases := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"api.pb.go", "package api\n", true},
+		{"package-lock.json", "{}\n", true},
+		{"app.min.js", "x\n", true},
+		{"app.js.map", "{}\n", true},
+		{"stringer.go", "// Code generated by \"stringer -type=Kind\"; DO NOT EDIT.\n\npackage kind\n", true},
+		{"schema.ts", "/**\n * @generated by codegen\n */\nexport type A = string\n", true},
+		{"bundle.js", strings.Repeat("var a=1;", 1000) + "\n", true},
+		{"main.go", "package main\n\n// Mentions of @generated or Code generated in prose are fine\n", false},
+		{"app.js", "function f() {\n  return 1\n}\n", false},
+		{"countries.map", "de=Germany\n", false},
+	}
+	for _, c := range cases {
+		path := filepath.Join(dir, c.name)
+		if err := os.WriteFile(path, []byte(c.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got := isGeneratedFile(path); got != c.want {
+			t.Errorf("isGeneratedFile(%s) = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// resolveDiffBase returns the commit a change set should be compared against:
+// the merge base of ref and HEAD so only the branch's own changes show up. An
+// empty ref picks main, falling back to master.
+func resolveDiffBase(dir string, ref string) (string, error) {
+	if ref == "" {
+		for _, candidate := range []string{"main", "master"} {
+			if _, err := runCommand(dir, "git", "rev-parse", "--verify", "--quiet", candidate); err == nil {
+				ref = candidate
+				break
+			}
+		}
+		if ref == "" {
+			return "", fmt.Errorf("no main or master branch found; set the base ref explicitly")
+		}
+	}
+
+	if err := verifyRef(dir, ref); err != nil {
+		return "", err
+	}
+	if base, err := runCommand(dir, "git", "merge-base", ref, "HEAD"); err == nil && base != "" {
+		return base, nil
+	}
+	return ref, nil
+}
+
+// verifyRef returns an error when ref does not name a commit
+func verifyRef(dir string, ref string) error {
+	if _, err := runCommand(dir, "git", "rev-parse", "--verify", "--quiet", ref); err != nil {
+		return fmt.Errorf("unknown git ref %q", ref)
+	}
+	return nil
+}
+
+// gitDiff returns the unified diff between base and the working tree for the
+// files below dir.
+func gitDiff(dir string, base string) (string, error) {
+	return runCommand(dir, "git", "diff", "--relative", base)
+}
+
+// gitChangedFiles returns the paths, relative to dir, of files that differ
+// between base and the working tree and still exist, followed by any new
+// untracked files that are not ignored.
+func gitChangedFiles(dir string, base string) ([]string, error) {
+	// NUL separated, as names outside of ASCII would be quoted otherwise
+	changed, err := runCommand(dir, "git", "diff", "-z", "--relative", "--name-only", "--diff-filter=d", base)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := runCommand(dir, "git", "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, name := range strings.Split(changed+"\x00"+untracked, "\x00") {
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+	return files, nil
+}
+
+// gitStagedDiff returns the diff stat and unified diff of the staged changes
+func gitStagedDiff(dir string) (string, error) {
+	stat, err := runCommand(dir, "git", "diff", "--cached", "--stat")
+	if err != nil || stat == "" {
+		return "", err
+	}
+	diff, err := runCommand(dir, "git", "diff", "--cached")
+	if err != nil {
+		return "", err
+	}
+	return stat + "\n\n" + diff, nil
+}
+
+// gitTrackedFiles returns the absolute paths of the files below dir tracked
+// by git, together with every directory containing one of them.
+func gitTrackedFiles(dir string) (map[string]bool, error) {
+	output, err := runCommand(dir, "git", "ls-files", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	tracked := make(map[string]bool)
+	for _, rel := range strings.Split(output, "\x00") {
+		if rel == "" {
+			continue
+		}
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		for path != dir && !tracked[path] {
+			tracked[path] = true
+			path = filepath.Dir(path)
+		}
+	}
+	return tracked, nil
+}
+
+// gitFileDiff returns the unified diff of a single file between base and the
+// working tree, empty when git has no history for it such as a new file.
+func gitFileDiff(dir string, base string, path string) (string, error) {
+	return runCommand(dir, "git", "diff", base, "--", path)
+}
@@ -0,0 +1,48 @@
+package clip4llm
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGitChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=a@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("café.txt", "old\n")
+	write("same.txt", "same\n")
+	git("init", "--quiet")
+	git("add", ".")
+	git("commit", "--quiet", "-m", "initial")
+	write("café.txt", "new\n")
+	write("naïve notes.md", "untracked\n")
+
+	files, err := gitChangedFiles(dir, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"café.txt", "naïve notes.md"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("gitChangedFiles = %q, want %q", files, want)
+	}
+	for _, name := range files {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+}
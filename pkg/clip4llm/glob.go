@@ -0,0 +1,148 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// matchGlob reports whether the slash-separated path matches the pattern,
+// where a ** segment matches any number (including zero) of directories and
+// every other segment follows path.Match.
+func matchGlob(pattern string, name string) (bool, error) {
+	// Validate the whole pattern up front so errors are not masked by a mismatch
+	for _, segment := range strings.Split(pattern, "/") {
+		if segment != "**" {
+			if _, err := path.Match(segment, ""); err != nil {
+				return false, err
+			}
+		}
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/")), nil
+}
+
+// matchSegments matches path segments against pattern segments
+func matchSegments(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse repeated ** and try every possible number of skipped segments
+			for len(pattern) > 0 && pattern[0] == "**" {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], name[0]); !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// matchesAnyPatternWithPath checks if a file matches any pattern in the list.
+// Patterns without a slash match the base name, patterns with one match the
+// slash-separated path relative to the root and may use ** for any depth.
+// As in .gitignore, a pattern starting with ! negates the earlier ones for the
+// files it matches and the last matching pattern wins. Names and patterns are
+// compared in NFC.
+func matchesAnyPatternWithPath(name string, relPath string, patterns []string) (bool, error) {
+	// Compare names in NFC, whichever form the filesystem or the user wrote them in
+	name = normalizePath(name)
+	relPath = normalizePath(strings.TrimPrefix(filepath.ToSlash(relPath), "./"))
+	result := false
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		pattern = normalizePath(strings.TrimPrefix(pattern, "!"))
+		// A negation only changes the outcome of a file already matched
+		if negated != result {
+			continue
+		}
+		var matched bool
+		var err error
+		if strings.Contains(pattern, "/") {
+			matched, err = matchGlob(strings.TrimPrefix(pattern, "./"), relPath)
+		} else {
+			matched, err = filepath.Match(pattern, name)
+		}
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			result = !negated
+		}
+	}
+	return result, nil
+}
+
+// readPatternFile returns the patterns listed in the file at path, one per
+// line, leaving out blank lines and # comments
+func readPatternFile(path string) ([]string, error) {
+	content, err := os.ReadFile(expandHome(path))
+	if err != nil {
+		return nil, err
+	}
+	var patterns []string
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+	return patterns, nil
+}
+
+// patternMatcher matches paths against a fixed list of patterns and
+// remembers the outcome of every path, so the repeated collections of a
+// long-lived Collector, such as the rebuilds of a watch mode, do not evaluate
+// the patterns again for the entries they already walked. The outcome only
+// depends on the path, nothing goes stale when files change.
+type patternMatcher struct {
+	patterns []string
+	mu       sync.Mutex
+	results  map[string]bool // by slash-separated path relative to the root
+}
+
+// newPatternMatcher returns a matcher for the patterns
+func newPatternMatcher(patterns []string) *patternMatcher {
+	return &patternMatcher{patterns: patterns, results: make(map[string]bool)}
+}
+
+// matches reports whether the entry named name at relPath matches the
+// patterns, as matchesAnyPatternWithPath does. Errors are not remembered, and
+// a nil matcher has no patterns.
+func (m *patternMatcher) matches(name string, relPath string) (bool, error) {
+	if m == nil {
+		return false, nil
+	}
+	key := filepath.ToSlash(relPath)
+	m.mu.Lock()
+	matched, ok := m.results[key]
+	m.mu.Unlock()
+	if ok {
+		return matched, nil
+	}
+	matched, err := matchesAnyPatternWithPath(name, relPath, m.patterns)
+	if err != nil {
+		return false, err
+	}
+	m.mu.Lock()
+	m.results[key] = matched
+	m.mu.Unlock()
+	return matched, nil
+}
@@ -0,0 +1,145 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"src/**/*.test.js", "src/a.test.js", true},
+		{"src/**/*.test.js", "src/a/b/c.test.js", true},
+		{"src/**/*.test.js", "lib/a.test.js", false},
+		{"src/**/*.test.js", "src/a/b/c.js", false},
+		{"**/fixtures", "fixtures", true},
+		{"**/fixtures", "a/b/fixtures", true},
+		{"**/fixtures", "a/fixtures/x", false},
+		{"docs/**", "docs", true},
+		{"docs/**", "docs/a/b.md", true},
+		{"a/**/**/b", "a/b", true},
+		{"a/*/b", "a/x/y/b", false},
+	}
+	for _, c := range cases {
+		got, err := matchGlob(c.pattern, c.name)
+		if err != nil {
+			t.Fatalf("matchGlob(%q, %q) error: %v", c.pattern, c.name, err)
+		}
+		if got != c.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", c.pattern, c.name, got, c.want)
+		}
+	}
+}
+
+func TestMatchesAnyPatternWithPath(t *testing.T) {
+	patterns := []string{"*.md", "./src/**/*.snap"}
+
+	cases := []struct {
+		name    string
+		relPath string
+		want    bool
+	}{
+		{"README.md", "./docs/README.md", true},
+		{"a.snap", "./src/ui/__snapshots__/a.snap", true},
+		{"a.snap", "./lib/a.snap", false},
+		{"main.go", "./main.go", false},
+	}
+	for _, c := range cases {
+		got, err := matchesAnyPatternWithPath(c.name, c.relPath, patterns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Errorf("matchesAnyPatternWithPath(%q, %q) = %v, want %v", c.name, c.relPath, got, c.want)
+		}
+	}
+
+	if _, err := matchesAnyPatternWithPath("a", "a", []string{"src/[/**"}); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
+
+func TestMatchesAnyPatternWithPathNegation(t *testing.T) {
+	patterns := []string{"*.md", "!README.md", "docs/**/*.md"}
+
+	cases := []struct {
+		name    string
+		relPath string
+		want    bool
+	}{
+		{"CHANGELOG.md", "./CHANGELOG.md", true},
+		{"README.md", "./README.md", false},
+		{"README.md", "./docs/README.md", true},
+		{"main.go", "./main.go", false},
+	}
+	for _, c := range cases {
+		got, err := matchesAnyPatternWithPath(c.name, c.relPath, patterns)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != c.want {
+			t.Errorf("matchesAnyPatternWithPath(%q, %q) = %v, want %v", c.name, c.relPath, got, c.want)
+		}
+	}
+
+	if got, _ := matchesAnyPatternWithPath("a.go", "./a.go", []string{"!a.go"}); got {
+		t.Error("a lone negation should not match")
+	}
+}
+
+func TestReadPatternFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patterns.txt")
+	content := "# build output\ndist\n\n  *.min.js  \r\n!vendor/keep.min.js\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readPatternFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"dist", "*.min.js", "!vendor/keep.min.js"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, err := readPatternFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestPatternMatcher(t *testing.T) {
+	m := newPatternMatcher([]string{"*.log", "!keep.log", "build/**"})
+	cases := map[string]bool{
+		"debug.log":     true,
+		"logs/keep.log": false,
+		"build/out/a.o": true,
+		"src/main.go":   false,
+	}
+	// The second round answers from what the first remembered
+	for round := 0; round < 2; round++ {
+		for rel, want := range cases {
+			got, err := m.matches(filepath.Base(rel), rel)
+			if err != nil || got != want {
+				t.Errorf("round %d: matches(%q) = %v, %v, want %v", round, rel, got, err, want)
+			}
+		}
+	}
+	if len(m.results) != len(cases) {
+		t.Errorf("remembered %d paths, want %d", len(m.results), len(cases))
+	}
+
+	// Errors are not remembered
+	bad := newPatternMatcher([]string{"["})
+	if _, err := bad.matches("x", "x"); err == nil || len(bad.results) != 0 {
+		t.Errorf("invalid pattern got err=%v and remembered %d paths", err, len(bad.results))
+	}
+
+	var none *patternMatcher
+	if matched, err := none.matches("x", "x"); matched || err != nil {
+		t.Errorf("nil matcher matched=%v err=%v", matched, err)
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bufio"
+	"go/build/constraint"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Known GOOS values used to interpret _GOOS file name suffixes
+var knownGOOS = map[string]bool{
+	"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+	"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true,
+	"netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+	"windows": true, "zos": true,
+}
+
+// Known GOARCH values used to interpret _GOARCH file name suffixes
+var knownGOARCH = map[string]bool{
+	"386": true, "amd64": true, "arm": true, "arm64": true, "loong64": true, "mips": true,
+	"mipsle": true, "mips64": true, "mips64le": true, "ppc64": true, "ppc64le": true,
+	"riscv64": true, "s390x": true, "wasm": true,
+}
+
+// GOOS values that satisfy the "unix" build constraint
+var unixGOOS = []string{
+	"aix", "android", "darwin", "dragonfly", "freebsd", "hurd", "illumos", "ios",
+	"linux", "netbsd", "openbsd", "solaris",
+}
+
+// GOOS values that imply another, as android builds also satisfy "linux"
+var impliedGOOS = map[string]string{
+	"android": "linux",
+	"illumos": "solaris",
+	"ios":     "darwin",
+}
+
+// goTagSet converts a list of build tags into a lookup set
+func goTagSet(list []string) map[string]bool {
+	tags := make(map[string]bool)
+	for _, tag := range list {
+		tags[tag] = true
+	}
+	return tags
+}
+
+// goTagSatisfied reports whether a single build tag holds for the given tag set.
+// Go release tags (go1.N) and the gc compiler tag are always treated as satisfied.
+func goTagSatisfied(tag string, tags map[string]bool) bool {
+	if tags[tag] {
+		return true
+	}
+	if strings.HasPrefix(tag, "go1.") || tag == "gc" {
+		return true
+	}
+	for goos, implied := range impliedGOOS {
+		if implied == tag && tags[goos] {
+			return true
+		}
+	}
+	if tag == "unix" {
+		for _, goos := range unixGOOS {
+			if tags[goos] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// goTagsConstrain reports whether any of the tags is one of the known values,
+// that is whether the tags pick a GOOS or GOARCH at all
+func goTagsConstrain(tags map[string]bool, known map[string]bool) bool {
+	for tag := range tags {
+		if known[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+// goTagVariants expands the tag set into one set per build configuration it
+// allows. A GOOS or GOARCH left out of the tags is unconstrained, so every
+// known value is tried for it.
+func goTagVariants(tags map[string]bool) []map[string]bool {
+	variants := []map[string]bool{tags}
+	for _, known := range []map[string]bool{knownGOOS, knownGOARCH} {
+		if goTagsConstrain(tags, known) {
+			continue
+		}
+		var expanded []map[string]bool
+		for _, variant := range variants {
+			for value := range known {
+				with := make(map[string]bool, len(variant)+1)
+				for tag := range variant {
+					with[tag] = variant[tag]
+				}
+				with[value] = true
+				expanded = append(expanded, with)
+			}
+		}
+		variants = expanded
+	}
+	return variants
+}
+
+// goFileNameMatchesTags applies the implicit _GOOS, _GOARCH and _GOOS_GOARCH
+// file name constraints used by the go tool. A suffix for a dimension the tags
+// do not constrain always matches, so linux,integration keeps _amd64 files.
+func goFileNameMatchesTags(name string, tags map[string]bool) bool {
+	name = strings.TrimSuffix(name, ".go")
+	name = strings.TrimSuffix(name, "_test")
+
+	parts := strings.Split(name, "_")
+	// A file named only after an OS or architecture (e.g. linux.go) has no constraint
+	if len(parts) < 2 {
+		return true
+	}
+
+	goosMatches := func(goos string) bool {
+		return !goTagsConstrain(tags, knownGOOS) || goTagSatisfied(goos, tags)
+	}
+	goarchMatches := func(goarch string) bool {
+		return !goTagsConstrain(tags, knownGOARCH) || goTagSatisfied(goarch, tags)
+	}
+
+	last := parts[len(parts)-1]
+	if len(parts) >= 3 && knownGOOS[parts[len(parts)-2]] && knownGOARCH[last] {
+		return goosMatches(parts[len(parts)-2]) && goarchMatches(last)
+	}
+	if knownGOOS[last] {
+		return goosMatches(last)
+	}
+	if knownGOARCH[last] {
+		return goarchMatches(last)
+	}
+	return true
+}
+
+// goFileMatchesTags reports whether the Go source file at path would be built
+// under the given tag set, considering both the file name suffixes and any
+// //go:build or legacy // +build constraint lines in the file header.
+func goFileMatchesTags(path string, tags map[string]bool) (bool, error) {
+	name := filepath.Base(path)
+	if !goFileNameMatchesTags(name, tags) {
+		return false, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	var goBuild constraint.Expr
+	var plusBuild []constraint.Expr
+
+	scanner := bufio.NewScanner(file)
+	inBlockComment := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		// Constraints must appear before the package clause, only comments and blank lines may precede them
+		if inBlockComment {
+			if strings.Contains(line, "*/") {
+				inBlockComment = false
+			}
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "/*") {
+			inBlockComment = !strings.Contains(line, "*/")
+			continue
+		}
+		if !strings.HasPrefix(line, "//") {
+			break
+		}
+
+		if constraint.IsGoBuild(line) {
+			expr, err := constraint.Parse(line)
+			if err == nil {
+				goBuild = expr
+			}
+		} else if constraint.IsPlusBuild(line) {
+			expr, err := constraint.Parse(line)
+			if err == nil {
+				plusBuild = append(plusBuild, expr)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+
+	// The file builds if any configuration the tags allow satisfies both its
+	// name and its constraints, a //go:build line taking precedence over any
+	// // +build lines
+	for _, variant := range goTagVariants(tags) {
+		if !goFileNameMatchesTags(name, variant) {
+			continue
+		}
+		ok := func(tag string) bool { return goTagSatisfied(tag, variant) }
+		if goBuild != nil {
+			if goBuild.Eval(ok) {
+				return true, nil
+			}
+			continue
+		}
+		matches := true
+		for _, expr := range plusBuild {
+			if !expr.Eval(ok) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true, nil
+		}
+	}
+	return false, nil
+}
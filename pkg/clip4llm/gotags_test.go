@@ -0,0 +1,105 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGoFileNameMatchesTags(t *testing.T) {
+	tags := goTagSet([]string{"linux", "amd64"})
+	cases := map[string]bool{
+		"main.go":               true,
+		"linux.go":              true,
+		"file_linux.go":         true,
+		"file_windows.go":       false,
+		"file_linux_amd64.go":   true,
+		"file_linux_arm64.go":   false,
+		"file_darwin_test.go":   false,
+		"file_linux_test.go":    true,
+		"some_helper_stuff.go":  true,
+		"file_windows_amd64.go": false,
+	}
+	for name, want := range cases {
+		if got := goFileNameMatchesTags(name, tags); got != want {
+			t.Errorf("goFileNameMatchesTags(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestGoFileNameMatchesTagsUnconstrained(t *testing.T) {
+	tests := []struct {
+		tags []string
+		name string
+		want bool
+	}{
+		// Without a GOARCH any architecture suffix matches
+		{[]string{"linux", "integration"}, "file_amd64.go", true},
+		{[]string{"linux", "integration"}, "file_linux_arm64.go", true},
+		{[]string{"linux", "integration"}, "file_windows_amd64.go", false},
+		// Without a GOOS any OS suffix matches
+		{[]string{"arm64"}, "file_windows_arm64.go", true},
+		{[]string{"arm64"}, "file_darwin_amd64.go", false},
+		// GOOS values implying another satisfy it too
+		{[]string{"android"}, "file_linux.go", true},
+		{[]string{"android"}, "file_android.go", true},
+		{[]string{"linux"}, "file_android.go", false},
+		{[]string{"ios"}, "file_darwin.go", true},
+		{[]string{"illumos", "amd64"}, "file_solaris_amd64.go", true},
+	}
+	for _, tt := range tests {
+		if got := goFileNameMatchesTags(tt.name, goTagSet(tt.tags)); got != tt.want {
+			t.Errorf("goFileNameMatchesTags(%q) with %v = %v, want %v", tt.name, tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestGoFileMatchesTags(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"integration.go":    "//go:build integration\n\npackage foo\n",
+		"unix.go":           "// Copyright header\n\n//go:build unix && !integration\n\npackage foo\n",
+		"legacy.go":         "// +build windows\n\npackage foo\n",
+		"release.go":        "//go:build go1.21\n\npackage foo\n",
+		"late.go":           "package foo\n\n//go:build windows\n",
+		"arch_amd64.go":     "package foo\n",
+		"arch.go":           "//go:build arm64 && !integration\n\npackage foo\n",
+		"anyarch.go":        "//go:build amd64 || arm64\n\npackage foo\n",
+		"mismatch_amd64.go": "//go:build arm64\n\npackage foo\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tags := goTagSet([]string{"linux", "integration"})
+	want := map[string]bool{
+		"integration.go":    true,
+		"unix.go":           false,
+		"legacy.go":         false,
+		"release.go":        true,
+		"late.go":           true,
+		"arch_amd64.go":     true,
+		"arch.go":           false,
+		"anyarch.go":        true,
+		"mismatch_amd64.go": false,
+	}
+	for name, expected := range want {
+		got, err := goFileMatchesTags(filepath.Join(dir, name), tags)
+		if err != nil {
+			t.Fatalf("goFileMatchesTags(%q) error: %v", name, err)
+		}
+		if got != expected {
+			t.Errorf("goFileMatchesTags(%q) = %v, want %v", name, got, expected)
+		}
+	}
+
+	// android builds satisfy a linux constraint
+	if err := os.WriteFile(filepath.Join(dir, "linux.go"), []byte("//go:build linux\n\npackage foo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := goFileMatchesTags(filepath.Join(dir, "linux.go"), goTagSet([]string{"android"})); err != nil || !got {
+		t.Errorf("goFileMatchesTags(%q) with android = %v, %v, want true", "linux.go", got, err)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// lineRange is an inclusive range of line numbers, counted from 1
+type lineRange struct {
+	first, last int
+}
+
+// grepSnippets reduces content to the lines within context lines of the
+// matches of pattern, each run of lines labeled with its range like
+// [lines 12-18]. Overlapping and adjacent runs are merged. It reports false
+// and returns content unchanged when nothing matches.
+func grepSnippets(content []byte, pattern *regexp.Regexp, context int) ([]byte, bool) {
+	matches := pattern.FindAllIndex(content, -1)
+	if len(matches) == 0 {
+		return content, false
+	}
+
+	// A final newline ends the last line rather than starting another one
+	lines := bytes.Split(bytes.TrimSuffix(content, []byte("\n")), []byte("\n"))
+	starts := make([]int, len(lines))
+	for i, offset := 1, 0; i < len(lines); i++ {
+		offset += len(lines[i-1]) + 1
+		starts[i] = offset
+	}
+	lineAt := func(offset int) int {
+		return sort.Search(len(starts), func(i int) bool { return starts[i] > offset })
+	}
+
+	var ranges []lineRange
+	for _, match := range matches {
+		end := max(match[1]-1, match[0])
+		r := lineRange{max(lineAt(match[0])-context, 1), min(lineAt(end)+context, len(lines))}
+		if n := len(ranges); n > 0 && r.first <= ranges[n-1].last+1 {
+			ranges[n-1].last = max(ranges[n-1].last, r.last)
+			continue
+		}
+		ranges = append(ranges, r)
+	}
+
+	var snippets bytes.Buffer
+	for i, r := range ranges {
+		if i > 0 {
+			snippets.WriteString("\n")
+		}
+		fmt.Fprintf(&snippets, "[lines %d-%d]\n", r.first, r.last)
+		for _, line := range lines[r.first-1 : r.last] {
+			snippets.Write(line)
+			snippets.WriteString("\n")
+		}
+	}
+	return snippets.Bytes(), true
+}
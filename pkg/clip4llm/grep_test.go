@@ -0,0 +1,25 @@
+package clip4llm
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestGrepSnippets(t *testing.T) {
+	content := "package billing\n\nimport \"pay\"\n\nfunc a() {\n\tpay.Charge()\n}\n\nfunc b() {}\n\nfunc c() {}\n\nfunc d() {\n\tpay.Refund()\n}\n"
+	got, ok := grepSnippets([]byte(content), regexp.MustCompile(`pay\.\w+`), 1)
+	want := "[lines 5-7]\nfunc a() {\n\tpay.Charge()\n}\n\n[lines 13-15]\nfunc d() {\n\tpay.Refund()\n}\n"
+	if !ok || string(got) != want {
+		t.Errorf("got %v\n%s\nwant\n%s", ok, got, want)
+	}
+
+	// Matches close together share a snippet, which stops at the file edges
+	got, _ = grepSnippets([]byte(content), regexp.MustCompile(`billing|import`), 2)
+	if want := "[lines 1-5]\npackage billing\n\nimport \"pay\"\n\nfunc a() {\n"; string(got) != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	if got, ok := grepSnippets([]byte(content), regexp.MustCompile(`nothing`), 1); ok || string(got) != content {
+		t.Error("content without a match should be left alone")
+	}
+}
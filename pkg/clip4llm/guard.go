@@ -0,0 +1,66 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sensitiveRoots returns the directories that must not be copied wholesale:
+// the filesystem root, the home directory and any configured extras.
+func sensitiveRoots(extra []string) []string {
+	roots := []string{string(filepath.Separator)}
+	if volume := filepath.VolumeName(os.Getenv("SystemDrive")); volume != "" {
+		roots = append(roots, volume+string(filepath.Separator))
+	}
+	home, err := os.UserHomeDir()
+	if err == nil {
+		roots = append(roots, home)
+	}
+	for _, root := range extra {
+		if abs, err := filepath.Abs(os.ExpandEnv(expandHome(root))); err == nil {
+			roots = append(roots, abs)
+		}
+	}
+	return roots
+}
+
+// checkSensitiveDir returns an error when dir is one of the sensitive roots,
+// comparing resolved paths so symlinks and relative paths cannot slip past.
+func checkSensitiveDir(dir string, opts *options) error {
+	if opts.force {
+		return nil
+	}
+	resolved := resolvePath(dir)
+	for _, root := range sensitiveRoots(opts.sensitiveDirs) {
+		if resolved == resolvePath(root) {
+			return fmt.Errorf("refusing to copy %s, it is a sensitive directory; use --force to copy it anyway", dir)
+		}
+	}
+	return nil
+}
+
+// resolvePath returns the absolute, symlink-free form of path when it exists
+func resolvePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		path = real
+	}
+	return filepath.Clean(path)
+}
+
+// expandHome replaces a leading ~ in path with the home directory, as the
+// shell would for a path given on the command line
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~"); ok && (rest == "" || os.IsPathSeparator(rest[0])) {
+		if home, err := os.UserHomeDir(); err == nil {
+			return home + rest
+		}
+	}
+	return path
+}
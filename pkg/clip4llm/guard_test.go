@@ -0,0 +1,73 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSensitiveRoots(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CLIP4LLM_TEST_SECRETS", filepath.Join(home, "vault"))
+
+	roots := sensitiveRoots([]string{"~/.ssh", "$CLIP4LLM_TEST_SECRETS", "~other/x"})
+	for _, want := range []string{string(filepath.Separator), home, filepath.Join(home, ".ssh"), filepath.Join(home, "vault")} {
+		found := false
+		for _, root := range roots {
+			found = found || root == want
+		}
+		if !found {
+			t.Errorf("sensitiveRoots = %v, missing %s", roots, want)
+		}
+	}
+	// Only ~ alone or followed by a separator is the home directory
+	other, err := filepath.Abs("~other/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if roots[len(roots)-1] != other {
+		t.Errorf("sensitiveRoots ended with %s, want ~other/x left relative as %s", roots[len(roots)-1], other)
+	}
+}
+
+func TestCheckSensitiveDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	project := filepath.Join(home, "project")
+	secrets := filepath.Join(home, ".secrets")
+	for _, dir := range []string{project, secrets} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := filepath.Join(t.TempDir(), "home")
+	if err := os.Symlink(home, link); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name      string
+		dir       string
+		sensitive []string
+		force     bool
+		refused   bool
+	}{
+		{name: "filesystem root", dir: string(filepath.Separator), refused: true},
+		{name: "home", dir: home, refused: true},
+		{name: "home with a trailing separator", dir: home + string(filepath.Separator), refused: true},
+		{name: "symlink to home", dir: link, refused: true},
+		{name: "project below home", dir: project},
+		{name: "home entry of --sensitive-dirs", dir: secrets, sensitive: []string{"~/.secrets"}, refused: true},
+		{name: "project not in --sensitive-dirs", dir: project, sensitive: []string{"~/.secrets"}},
+		{name: "forced root", dir: string(filepath.Separator), force: true},
+		{name: "forced home", dir: home, force: true},
+		{name: "forced --sensitive-dirs entry", dir: secrets, sensitive: []string{"~/.secrets"}, force: true},
+	}
+	for _, c := range cases {
+		err := checkSensitiveDir(c.dir, &options{sensitiveDirs: c.sensitive, force: c.force})
+		if (err != nil) != c.refused {
+			t.Errorf("%s: checkSensitiveDir(%s) = %v, want refused=%v", c.name, c.dir, err, c.refused)
+		}
+	}
+}
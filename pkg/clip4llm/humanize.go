@@ -0,0 +1,47 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"fmt"
+	"time"
+)
+
+// HumanSize returns a byte count in the largest unit it reaches, such as
+// "512 B", "2.3 KB" or "4.1 MB"
+func HumanSize(bytes int64) string {
+	if bytes < 1024 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	size := float64(bytes) / 1024
+	for _, unit := range []string{"KB", "MB", "GB"} {
+		// Move up before rounding would show 1024.0 of a unit
+		if size < 1023.95 || unit == "GB" {
+			return fmt.Sprintf("%.1f %s", size, unit)
+		}
+		size /= 1024
+	}
+	return ""
+}
+
+// RelativeTime returns how long before now t was in its largest whole unit,
+// such as "5m ago", "3h ago" or "2y ago". Anything under a minute, or in the
+// future as with clock skew, is "just now".
+func RelativeTime(t time.Time, now time.Time) string {
+	age := now.Sub(t)
+	day := 24 * time.Hour
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age/time.Minute))
+	case age < day:
+		return fmt.Sprintf("%dh ago", int(age/time.Hour))
+	case age < 30*day:
+		return fmt.Sprintf("%dd ago", int(age/day))
+	case age < 365*day:
+		return fmt.Sprintf("%dmo ago", int(age/(30*day)))
+	default:
+		return fmt.Sprintf("%dy ago", int(age/(365*day)))
+	}
+}
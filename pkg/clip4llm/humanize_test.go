@@ -0,0 +1,43 @@
+package clip4llm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHumanSize(t *testing.T) {
+	cases := map[int64]string{
+		0:                  "0 B",
+		1023:               "1023 B",
+		1024:               "1.0 KB",
+		2355:               "2.3 KB",
+		5 << 20:            "5.0 MB",
+		3 << 30:            "3.0 GB",
+		2048 << 30:         "2048.0 GB",
+		1024*1024 - 1:      "1.0 MB",
+		1536 * 1024 * 1024: "1.5 GB",
+	}
+	for bytes, want := range cases {
+		if got := HumanSize(bytes); got != want {
+			t.Errorf("HumanSize(%d) = %q, want %q", bytes, got, want)
+		}
+	}
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[time.Duration]string{
+		-time.Hour:                "just now",
+		30 * time.Second:          "just now",
+		5 * time.Minute:           "5m ago",
+		3*time.Hour + time.Minute: "3h ago",
+		49 * time.Hour:            "2d ago",
+		90 * 24 * time.Hour:       "3mo ago",
+		800 * 24 * time.Hour:      "2y ago",
+	}
+	for age, want := range cases {
+		if got := RelativeTime(now.Add(-age), now); got != want {
+			t.Errorf("RelativeTime(%v ago) = %q, want %q", age, got, want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Directory names that hold one translation file or directory per language
+var localeDirs = map[string]bool{
+	"locales": true, "locale": true, "i18n": true, "translations": true, "lang": true,
+}
+
+// i18nLanguage returns the normalized language of a localization file, judged
+// by its relative path, and reports whether the file is one at all. Gettext
+// templates (.pot) hold the source strings and count as the default language.
+func i18nLanguage(rel string, defaultLang string) (string, bool) {
+	rel = strings.TrimPrefix(filepath.ToSlash(rel), "./")
+	ext := path.Ext(rel)
+	if ext == ".pot" {
+		return normalizeLanguage(defaultLang), true
+	}
+	if ext != ".po" && ext != ".json" {
+		return "", false
+	}
+
+	// The language is the entry directly below the locale directory, such as
+	// locales/fr.json or locale/fr/LC_MESSAGES/app.po
+	parts := strings.Split(rel, "/")
+	for i := len(parts) - 2; i >= 0; i-- {
+		if localeDirs[strings.ToLower(parts[i])] {
+			return normalizeLanguage(strings.TrimSuffix(parts[i+1], ext)), true
+		}
+	}
+
+	// Gettext catalogs outside a locale directory are named after their language
+	if ext == ".po" {
+		return normalizeLanguage(strings.TrimSuffix(path.Base(rel), ext)), true
+	}
+	return "", false
+}
+
+// normalizeLanguage lowercases a language tag and uses - as its separator
+func normalizeLanguage(lang string) string {
+	return strings.ReplaceAll(strings.ToLower(lang), "_", "-")
+}
+
+// filterI18nFiles drops the localization files of every language except the
+// default one.
+func filterI18nFiles(files []fileEntry, opts *options) []fileEntry {
+	defaultLang := normalizeLanguage(opts.i18nDefault)
+
+	var result []fileEntry
+	for _, file := range files {
+		if lang, ok := i18nLanguage(file.relPath, opts.i18nDefault); ok && lang != defaultLang {
+			if opts.verbose {
+				opts.logf("Skipping translation (%s): %s\n", lang, file.relPath)
+			}
+			continue
+		}
+		result = append(result, file)
+	}
+	return result
+}
+
+// i18nKeys reduces a localization file to its message keys. Gettext catalogs
+// keep their msgctxt, msgid and msgid_plural entries, JSON files become the
+// sorted list of dotted key paths.
+func i18nKeys(path string, content []byte) ([]byte, error) {
+	if filepath.Ext(path) == ".json" {
+		var data interface{}
+		if err := json.Unmarshal(content, &data); err != nil {
+			return nil, err
+		}
+		var keys []string
+		flattenJSONKeys("", data, &keys)
+		sort.Strings(keys)
+		return []byte(strings.Join(keys, "\n") + "\n"), nil
+	}
+
+	var out []string
+	translation := false
+	for _, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "msgstr"):
+			translation = true
+			continue
+		case strings.HasPrefix(trimmed, `"`):
+			// Continuation lines belong to the preceding keyword
+			if translation {
+				continue
+			}
+		default:
+			translation = false
+		}
+		out = append(out, line)
+	}
+	return []byte(strings.Join(out, "\n")), nil
+}
+
+// flattenJSONKeys appends the dotted path of every leaf value below prefix
+func flattenJSONKeys(prefix string, value interface{}, keys *[]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			flattenJSONKeys(join(key), child, keys)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flattenJSONKeys(join(strconv.Itoa(i)), child, keys)
+		}
+	default:
+		if prefix != "" {
+			*keys = append(*keys, prefix)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package clip4llm
+
+import (
+	"testing"
+)
+
+func TestI18nLanguage(t *testing.T) {
+	cases := []struct {
+		rel  string
+		lang string
+		ok   bool
+	}{
+		{"./locales/en.json", "en", true},
+		{"./src/locales/pt_BR/common.json", "pt-br", true},
+		{"./locale/fr/LC_MESSAGES/app.po", "fr", true},
+		{"./po/de.po", "de", true},
+		{"./po/app.pot", "en", true},
+		{"./config/settings.json", "", false},
+		{"./locales/README.md", "", false},
+	}
+	for _, c := range cases {
+		lang, ok := i18nLanguage(c.rel, "en")
+		if lang != c.lang || ok != c.ok {
+			t.Errorf("i18nLanguage(%q) = %q, %v, want %q, %v", c.rel, lang, ok, c.lang, c.ok)
+		}
+	}
+}
+
+func TestI18nKeysJSON(t *testing.T) {
+	keys, err := i18nKeys("en.json", []byte(`{"nav": {"home": "Home", "items": ["a", "b"]}, "title": "Hi"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "nav.home\nnav.items.0\nnav.items.1\ntitle\n"
+	if string(keys) != want {
+		t.Errorf("i18nKeys = %q, want %q", keys, want)
+	}
+}
+
+func TestI18nKeysPo(t *testing.T) {
+	po := "#: main.c:12\nmsgid \"Hello\"\nmsgstr \"\"\n\"Bonjour\"\n\nmsgid \"Bye\"\nmsgid_plural \"Byes\"\nmsgstr[0] \"Salut\"\nmsgstr[1] \"Saluts\"\n"
+	keys, err := i18nKeys("fr.po", []byte(po))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "#: main.c:12\nmsgid \"Hello\"\n\nmsgid \"Bye\"\nmsgid_plural \"Byes\"\n"
+	if string(keys) != want {
+		t.Errorf("i18nKeys = %q, want %q", keys, want)
+	}
+}
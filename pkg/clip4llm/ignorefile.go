@@ -0,0 +1,122 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Name of the per-directory file of ignore rules in gitignore syntax
+const ignoreFileName = ".clip4llmignore"
+
+// ignoreRule is one line of a .clip4llmignore file
+type ignoreRule struct {
+	pattern  string // glob in matchGlob syntax
+	negate   bool   // a !pattern bringing back what an earlier rule ignored
+	dirOnly  bool   // a pattern/ only matching directories
+	anchored bool   // matched against the path from the file's directory instead of the name
+}
+
+// ignoreFiles applies the .clip4llmignore files of the directories below
+// root, each to the entries beneath it. Files are read the first time their
+// directory is consulted.
+type ignoreFiles struct {
+	root  string
+	rules map[string][]ignoreRule // rules by directory, nil when it has no file
+	log   io.Writer               // receives the verbose messages, nil for none
+}
+
+// newIgnoreFiles returns the .clip4llmignore files below root
+func newIgnoreFiles(root string, log io.Writer) *ignoreFiles {
+	return &ignoreFiles{root: root, rules: make(map[string][]ignoreRule), log: log}
+}
+
+// parseIgnoreFile returns the rules of a .clip4llmignore file, which follows
+// gitignore: # comments, ! negation, a trailing / for directories only and a
+// leading or inner / anchoring the pattern to the file's directory.
+func parseIgnoreFile(content string) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			// \# and \! start patterns with a literal # or !
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		rule.anchored = strings.Contains(line, "/")
+		rule.pattern = strings.TrimPrefix(line, "/")
+		if rule.pattern != "" {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// rulesFor returns the rules of the .clip4llmignore file in dir
+func (f *ignoreFiles) rulesFor(dir string) []ignoreRule {
+	if rules, ok := f.rules[dir]; ok {
+		return rules
+	}
+	var rules []ignoreRule
+	file := filepath.Join(dir, ignoreFileName)
+	if content, err := os.ReadFile(file); err == nil {
+		rules = parseIgnoreFile(string(content))
+		if f.log != nil {
+			fmt.Fprintf(f.log, "Loaded %d rules from %s\n", len(rules), file)
+		}
+	}
+	f.rules[dir] = rules
+	return rules
+}
+
+// ignored reports whether the .clip4llmignore files of the directories from
+// root down to the entry at path ignore it. As in git, deeper files override
+// the rules of their parents and the last matching rule wins. A nil
+// ignoreFiles ignores nothing.
+func (f *ignoreFiles) ignored(entry string, isDir bool) bool {
+	if f == nil {
+		return false
+	}
+	rel, err := filepath.Rel(f.root, entry)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+
+	result := false
+	dir := f.root
+	for i := range segments {
+		below := path.Join(segments[i:]...)
+		for _, rule := range f.rulesFor(dir) {
+			if rule.negate != result || (rule.dirOnly && !isDir) {
+				continue
+			}
+			var matched bool
+			if rule.anchored {
+				matched, _ = matchGlob(rule.pattern, below)
+			} else {
+				matched, _ = path.Match(rule.pattern, segments[len(segments)-1])
+			}
+			if matched {
+				result = !rule.negate
+			}
+		}
+		dir = filepath.Join(dir, segments[i])
+	}
+	return result
+}
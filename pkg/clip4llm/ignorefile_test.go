@@ -0,0 +1,53 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		".clip4llmignore":     "# fixtures are huge\n*.snap\n/build\ndocs/\n!keep.snap\n\\#notes.txt\n",
+		"app/.clip4llmignore": "!*.snap\ngen/**/*.go\n",
+		"main.go":             "x",
+		"a.snap":              "x",
+		"keep.snap":           "x",
+		"#notes.txt":          "x",
+		"build/out.txt":       "x",
+		"app/build/out.txt":   "x",
+		"app/b.snap":          "x",
+		"app/gen/x/y.go":      "x",
+		"app/docs":            "x",
+		"app/docs.go":         "x",
+		"lib/docs/readme.md":  "x",
+		"lib/app.go":          "x",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := &options{maxSize: 1, hidden: "skip", ignoreFiles: newIgnoreFiles(dir, nil)}
+	found, err := collectFiles(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, file := range found {
+		paths = append(paths, file.relPath)
+	}
+	sort.Strings(paths)
+	want := "./app/b.snap ./app/build/out.txt ./app/docs ./app/docs.go ./keep.snap ./lib/app.go ./main.go"
+	if got := strings.Join(paths, " "); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
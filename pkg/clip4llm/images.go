@@ -0,0 +1,119 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// Markdown image ![alt](path "title") and HTML <img src="path">
+	markdownImage = regexp.MustCompile(`!\[([^\]]*)\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	htmlImage     = regexp.MustCompile(`<img\s[^>]*?src=["']([^"']+)["'][^>]*>`)
+
+	// Image output of a notebook cell, base64 encoded and possibly split by \n
+	notebookImage = regexp.MustCompile(`"(image/(?:png|jpeg|gif))":\s*"([A-Za-z0-9+/=]|\\n)+"`)
+)
+
+// isImageReferenceFile reports whether local images referenced by the file at
+// path are replaced with placeholders
+func isImageReferenceFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown", ".mdx", ".ipynb":
+		return true
+	}
+	return false
+}
+
+// replaceImages replaces the local images referenced by the markdown or
+// notebook at path with a placeholder giving their type, dimensions and size.
+// Remote images keep their link. It returns the number of replaced images.
+func replaceImages(path string, content []byte) ([]byte, int) {
+	count := 0
+	dir := filepath.Dir(path)
+	text := markdownImage.ReplaceAllStringFunc(string(content), func(match string) string {
+		groups := markdownImage.FindStringSubmatch(match)
+		placeholder, ok := imagePlaceholder(dir, groups[2], groups[1])
+		if !ok {
+			return match
+		}
+		count++
+		return placeholder
+	})
+	text = htmlImage.ReplaceAllStringFunc(text, func(match string) string {
+		placeholder, ok := imagePlaceholder(dir, htmlImage.FindStringSubmatch(match)[1], htmlAttribute(match, "alt"))
+		if !ok {
+			return match
+		}
+		count++
+		return placeholder
+	})
+	if strings.EqualFold(filepath.Ext(path), ".ipynb") {
+		text = notebookImage.ReplaceAllStringFunc(text, func(match string) string {
+			mediaType, encoded, _ := strings.Cut(match, ":")
+			encoded = strings.ReplaceAll(strings.Trim(strings.TrimSpace(encoded), `"`), `\n`, "")
+			data, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return match
+			}
+			count++
+			return fmt.Sprintf(`%s: "%s"`, mediaType, describeImage("cell output", "", data))
+		})
+	}
+	return []byte(text), count
+}
+
+// imagePlaceholder describes the image at link, relative to dir, with its alt
+// text. It reports false for remote images, which keep their link.
+func imagePlaceholder(dir, link, alt string) (string, bool) {
+	if u, err := url.Parse(link); err != nil || u.Scheme != "" || strings.HasPrefix(link, "//") {
+		return "", false
+	}
+	link, _, _ = strings.Cut(link, "#")
+	if unescaped, err := url.PathUnescape(link); err == nil {
+		link = unescaped
+	}
+	name := link
+	if alt != "" {
+		name = fmt.Sprintf("%s %q", link, alt)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(link)))
+	if err != nil {
+		return fmt.Sprintf("[image: %s, not found]", name), true
+	}
+	return describeImage(name, filepath.Ext(link), data), true
+}
+
+// describeImage returns the placeholder for the image named name, such as
+// [image: docs/flow.png, PNG 800x600, 24.1 KB]. Formats without a decoder,
+// like SVG, are named after their extension ext.
+func describeImage(name, ext string, data []byte) string {
+	kind := strings.ToUpper(strings.TrimPrefix(ext, "."))
+	if config, format, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		kind = fmt.Sprintf("%s %dx%d", strings.ToUpper(format), config.Width, config.Height)
+	}
+	if kind == "" {
+		kind = "unknown type"
+	}
+	return fmt.Sprintf("[image: %s, %s, %.1f KB]", name, kind, float64(len(data))/1024)
+}
+
+// htmlAttribute returns the value of the attribute named name of the HTML tag
+func htmlAttribute(tag, name string) string {
+	attribute := regexp.MustCompile(`\s` + name + `=["']([^"']*)["']`)
+	if match := attribute.FindStringSubmatch(tag); match != nil {
+		return match[1]
+	}
+	return ""
+}
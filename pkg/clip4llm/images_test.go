@@ -0,0 +1,40 @@
+package clip4llm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplaceImages(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewGray(image.Rect(0, 0, 40, 30))); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "flow.png"), encoded.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := "See ![Login flow](flow.png \"title\") and <img src=\"gone.svg\" alt=\"x\">, not ![logo](https://example.com/logo.png).\n"
+	got, count := replaceImages(filepath.Join(dir, "README.md"), []byte(doc))
+	if count != 2 {
+		t.Errorf("count = %d, want 2", count)
+	}
+	for _, want := range []string{`[image: flow.png "Login flow", PNG 40x30,`, `[image: gone.svg "x", not found]`, "![logo](https://example.com/logo.png)"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("missing %q in %s", want, got)
+		}
+	}
+
+	notebook := `{"outputs": [{"data": {"image/png": "` + base64.StdEncoding.EncodeToString(encoded.Bytes()) + `\n"}}]}`
+	got, count = replaceImages(filepath.Join(dir, "plot.ipynb"), []byte(notebook))
+	if count != 1 || !strings.Contains(string(got), `"image/png": "[image: cell output, PNG 40x30,`) {
+		t.Errorf("notebook output = %s (%d)", got, count)
+	}
+}
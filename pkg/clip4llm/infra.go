@@ -0,0 +1,103 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Hidden files and directories holding infrastructure, walked by --infra even
+// though hidden entries are skipped by default
+var infraHiddenPatterns = []string{
+	".dockerignore", ".github", ".gitlab-ci.yml", ".circleci", ".travis.yml", ".drone.yml", ".buildkite",
+}
+
+// Patterns identifying the infrastructure files of a project, in the order
+// they are included
+var infraCategories = []struct {
+	label    string
+	patterns []string
+}{
+	{"container", []string{"Dockerfile", "Dockerfile.*", "*.Dockerfile", "*.dockerfile", "Containerfile", ".dockerignore"}},
+	{"compose", []string{"docker-compose*.yml", "docker-compose*.yaml", "compose.yml", "compose.yaml", "compose.*.yml", "compose.*.yaml"}},
+	{"ci", []string{
+		".github/workflows/*.yml", ".github/workflows/*.yaml", ".github/actions/**/action.yml", ".github/actions/**/action.yaml",
+		".gitlab-ci.yml", ".circleci/config.yml", ".travis.yml", ".drone.yml", ".buildkite/**", "Jenkinsfile",
+		"azure-pipelines.yml", "bitbucket-pipelines.yml", "cloudbuild.yaml", "buildspec.yml",
+	}},
+	{"deployment", []string{
+		"*.tf", "kustomization.yaml", "kustomization.yml", "Chart.yaml", "values*.yaml", "skaffold.yaml",
+		"Procfile", "fly.toml", "app.yaml", "render.yaml", "serverless.yml", "netlify.toml", "vercel.json", "*.nomad",
+	}},
+}
+
+// The top level keys every Kubernetes manifest has
+var (
+	manifestAPIVersion = regexp.MustCompile(`(?m)^apiVersion:`)
+	manifestKind       = regexp.MustCompile(`(?m)^kind:`)
+)
+
+// prepareInfra restricts the selection to the Dockerfiles, compose files, CI
+// workflows and deployment manifests of dir, in that order
+func prepareInfra(dir string, opts *options) error {
+	opts.includePatterns = append(opts.includePatterns, infraHiddenPatterns...)
+	opts.includeMatcher = newPatternMatcher(opts.includePatterns)
+	candidates, err := collectFiles(dir, opts)
+	if err != nil {
+		return err
+	}
+
+	type ranked struct {
+		rel      string
+		priority int
+	}
+	var picks []ranked
+	for _, file := range candidates {
+		rel := strings.TrimPrefix(filepath.ToSlash(file.relPath), "./")
+		if priority, ok := infraCategory(file.path, rel); ok {
+			picks = append(picks, ranked{rel: rel, priority: priority})
+		}
+	}
+	if len(picks) == 0 {
+		return fmt.Errorf("no infrastructure files found")
+	}
+	sort.SliceStable(picks, func(i, j int) bool {
+		return picks[i].priority < picks[j].priority
+	})
+
+	opts.paths = []string{}
+	for _, pick := range picks {
+		opts.paths = append(opts.paths, pick.rel)
+		if opts.verbose {
+			opts.logf("Infrastructure file (%s): %s\n", infraCategories[pick.priority].label, pick.rel)
+		}
+	}
+	return nil
+}
+
+// infraCategory returns the position of the category of the file at path,
+// rel relative to the walked directory, and reports whether it is an
+// infrastructure file at all. YAML files outside the known names count as
+// deployment manifests when they are Kubernetes resources.
+func infraCategory(filePath string, rel string) (int, bool) {
+	base := path.Base(rel)
+	for i, category := range infraCategories {
+		if matched, err := matchesAnyPatternWithPath(base, rel, category.patterns); err == nil && matched {
+			return i, true
+		}
+	}
+
+	if ext := path.Ext(base); ext == ".yml" || ext == ".yaml" {
+		content, err := os.ReadFile(filePath)
+		if err == nil && manifestAPIVersion.Match(content) && manifestKind.Match(content) {
+			return len(infraCategories) - 1, true
+		}
+	}
+	return 0, false
+}
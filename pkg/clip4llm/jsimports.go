@@ -0,0 +1,113 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Patterns matching the module specifier of ES module imports/exports,
+// CommonJS require calls and dynamic imports
+var jsImportPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bimport\s+(?:[\w*{}\s,$]+?\s+from\s+)?["']([^"'\n]+)["']`),
+	regexp.MustCompile(`\bexport\s+(?:type\s+)?(?:\*(?:\s+as\s+[\w$]+)?|\{[^}]*\})\s+from\s+["']([^"'\n]+)["']`),
+	regexp.MustCompile(`\brequire\s*\(\s*["']([^"'\n]+)["']\s*\)`),
+	regexp.MustCompile(`\bimport\s*\(\s*["']([^"'\n]+)["']\s*\)`),
+}
+
+// Extensions tried, in order, when resolving an extensionless specifier
+var jsResolveExtensions = []string{".ts", ".tsx", ".d.ts", ".js", ".jsx", ".mjs", ".cjs", ".mts", ".cts"}
+
+// Source extensions a compiled specifier may stand in for (import "./a.js" from a.ts)
+var jsExtensionAliases = map[string][]string{
+	".js":  {".ts", ".tsx"},
+	".jsx": {".tsx"},
+	".mjs": {".mts"},
+	".cjs": {".cts"},
+}
+
+// parseJSImports returns the module specifiers imported by the file at path
+func parseJSImports(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var specifiers []string
+	for _, pattern := range jsImportPatterns {
+		for _, match := range pattern.FindAllStringSubmatch(string(content), -1) {
+			specifiers = append(specifiers, match[1])
+		}
+	}
+	return specifiers, nil
+}
+
+// resolveJSImport maps a relative or root-absolute module specifier to a file
+// on disk using Node/TypeScript style resolution. Bare package specifiers are
+// not local and are never resolved.
+func resolveJSImport(dir string, from string, specifier string) (string, bool) {
+	var base string
+	switch {
+	case strings.HasPrefix(specifier, "./"), strings.HasPrefix(specifier, "../"):
+		base = filepath.Join(filepath.Dir(from), filepath.FromSlash(specifier))
+	case strings.HasPrefix(specifier, "/"):
+		base = filepath.Join(dir, filepath.FromSlash(specifier))
+	default:
+		return "", false
+	}
+
+	// Drop any query or hash suffix used by bundlers (e.g. "./worker.js?url")
+	if i := strings.IndexAny(base, "?#"); i >= 0 {
+		base = base[:i]
+	}
+
+	var candidates []string
+	candidates = append(candidates, base)
+	ext := filepath.Ext(base)
+	for _, alias := range jsExtensionAliases[ext] {
+		candidates = append(candidates, strings.TrimSuffix(base, ext)+alias)
+	}
+	for _, resolveExt := range jsResolveExtensions {
+		candidates = append(candidates, base+resolveExt)
+	}
+	for _, resolveExt := range jsResolveExtensions {
+		candidates = append(candidates, filepath.Join(base, "index"+resolveExt))
+	}
+
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err == nil && info.Mode().IsRegular() {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// jsDependencies returns a dependency function listing the local files imported
+// by a JavaScript/TypeScript file, for use with collectClosure.
+func jsDependencies(dir string) func(path string) ([]string, error) {
+	return func(path string) ([]string, error) {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs", ".mts", ".cts", ".vue", ".svelte":
+		default:
+			// Stylesheets, JSON and other imported assets have no imports of their own
+			return nil, nil
+		}
+
+		specifiers, err := parseJSImports(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var deps []string
+		for _, specifier := range specifiers {
+			if resolved, ok := resolveJSImport(dir, path, specifier); ok {
+				deps = append(deps, resolved)
+			}
+		}
+		return deps, nil
+	}
+}
@@ -0,0 +1,53 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestEntryImportClosure(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"src/index.ts":         "import { App } from './app.js';\nimport React from 'react';\nexport * from \"./util\";\n",
+		"src/app.ts":           "const config = require('../config');\nconst page = import('./pages');\n",
+		"src/util.tsx":         "export const util = 1;\n",
+		"src/pages/index.ts":   "import './styles.css';\n",
+		"src/pages/styles.css": "body { margin: 0; }\n",
+		"config.js":            "module.exports = {};\n",
+		"src/unused.ts":        "import { App } from './app';\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := DefaultOptions()
+	opts.Entries = []string{"src/index.ts"}
+	collector, err := NewCollector(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := collector.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, file := range result.Files {
+		got = append(got, file.RelPath)
+	}
+	sort.Strings(got)
+
+	// Compiled .js specifiers, directory indexes and assets resolve, while
+	// packages and files nothing imports are left out
+	want := []string{"./config.js", "./src/app.ts", "./src/index.ts", "./src/pages/index.ts", "./src/pages/styles.css", "./src/util.tsx"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("selected %v, want %v", got, want)
+	}
+}
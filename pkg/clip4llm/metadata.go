@@ -0,0 +1,33 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+)
+
+// fileMetadata returns the note on the size and modification time of the
+// file at path and the line count of its content as included, such as
+// "2.4 KB, 85 lines, modified 3h ago" relative to now, or with raw the exact
+// "2458 bytes, 85 lines, modified 2024-05-01T14:03:00Z"
+func fileMetadata(path string, content []byte, raw bool, now time.Time) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	lines := bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		lines++
+	}
+	unit := "lines"
+	if lines == 1 {
+		unit = "line"
+	}
+	if raw {
+		return fmt.Sprintf("%d bytes, %d %s, modified %s", info.Size(), lines, unit, info.ModTime().Format(time.RFC3339)), nil
+	}
+	return fmt.Sprintf("%s, %d %s, modified %s", HumanSize(info.Size()), lines, unit, RelativeTime(info.ModTime(), now)), nil
+}
@@ -0,0 +1,38 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileMetadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.go")
+	content := []byte(strings.Repeat("x\n", 1023) + "end")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2024, 5, 1, 14, 3, 0, 0, time.Local)
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := fileMetadata(path, content, false, modified.Add(3*time.Hour+20*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "2.0 KB, 1024 lines, modified 3h ago"; got != want {
+		t.Errorf("fileMetadata = %q, want %q", got, want)
+	}
+
+	// Raw notes are exact for scripts
+	got, err = fileMetadata(path, content, true, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "2049 bytes, 1024 lines, modified " + modified.Format(time.RFC3339); got != want {
+		t.Errorf("raw fileMetadata = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// addTestPairs returns the selected files with the conventionally named test
+// file of each source file (and the source file of each test file) inserted
+// directly after it. Paired files bypass the include/exclude patterns but are
+// still subject to the size, build tag and binary checks.
+func addTestPairs(dir string, files []fileEntry, opts *options) []fileEntry {
+	selected := make(map[string]bool)
+	for _, file := range files {
+		selected[file.path] = true
+	}
+
+	var result []fileEntry
+	for _, file := range files {
+		result = append(result, file)
+
+		rel := strings.TrimPrefix(filepath.ToSlash(file.relPath), "./")
+		for _, candidate := range testPairCandidates(rel) {
+			pairPath := filepath.Join(dir, filepath.FromSlash(candidate))
+			if selected[pairPath] {
+				continue
+			}
+
+			info, err := os.Stat(pairPath)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			if !isEligibleFile(pairPath, info, opts) {
+				continue
+			}
+
+			relPath, err := relativePath(dir, pairPath)
+			if err != nil {
+				continue
+			}
+
+			if opts.verbose {
+				opts.logf("Including paired file for %s: %s\n", file.relPath, relPath)
+			}
+			selected[pairPath] = true
+			result = append(result, fileEntry{path: pairPath, relPath: relPath})
+		}
+	}
+
+	return result
+}
+
+// testPairCandidates returns the slash-separated relative paths where the
+// counterpart of rel would live under common naming conventions. For a source
+// file these are its test files and for a test file its source file.
+func testPairCandidates(rel string) []string {
+	dir := path.Dir(rel)
+	base := path.Base(rel)
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	join := func(elem ...string) string {
+		return path.Clean(path.Join(elem...))
+	}
+
+	switch ext {
+	case ".go":
+		if source, ok := strings.CutSuffix(stem, "_test"); ok {
+			return []string{join(dir, source+ext)}
+		}
+		return []string{join(dir, stem+"_test"+ext)}
+
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+		for _, marker := range []string{".test", ".spec"} {
+			if source, ok := strings.CutSuffix(stem, marker); ok {
+				sourceDir := dir
+				if path.Base(dir) == "__tests__" {
+					sourceDir = path.Dir(dir)
+				}
+				return []string{join(sourceDir, source+ext)}
+			}
+		}
+		return []string{
+			join(dir, stem+".test"+ext),
+			join(dir, stem+".spec"+ext),
+			join(dir, "__tests__", stem+".test"+ext),
+		}
+
+	case ".py":
+		if source, ok := strings.CutPrefix(stem, "test_"); ok {
+			candidates := []string{join(dir, source+ext)}
+			if path.Base(dir) == "tests" {
+				candidates = append(candidates, join(path.Dir(dir), source+ext))
+			}
+			return candidates
+		}
+		if source, ok := strings.CutSuffix(stem, "_test"); ok {
+			return []string{join(dir, source+ext)}
+		}
+		return []string{
+			join(dir, "test_"+stem+ext),
+			join(dir, stem+"_test"+ext),
+			join(dir, "tests", "test_"+stem+ext),
+		}
+
+	case ".java", ".kt", ".scala", ".cs":
+		for _, marker := range []string{"Tests", "Test"} {
+			if source, ok := strings.CutSuffix(stem, marker); ok {
+				sourceDir := strings.Replace(dir, "src/test/", "src/main/", 1)
+				return []string{join(sourceDir, source+ext)}
+			}
+		}
+		testDir := strings.Replace(dir, "src/main/", "src/test/", 1)
+		return []string{
+			join(testDir, stem+"Test"+ext),
+			join(testDir, stem+"Tests"+ext),
+		}
+
+	case ".rb":
+		for _, marker := range []string{"_spec", "_test"} {
+			if source, ok := strings.CutSuffix(stem, marker); ok {
+				return []string{join(dir, source+ext)}
+			}
+		}
+		return []string{
+			join(dir, stem+"_spec"+ext),
+			join(dir, stem+"_test"+ext),
+		}
+
+	case ".c", ".cc", ".cpp":
+		if source, ok := strings.CutSuffix(stem, "_test"); ok {
+			return []string{join(dir, source+ext)}
+		}
+		if source, ok := strings.CutPrefix(stem, "test_"); ok {
+			return []string{join(dir, source+ext)}
+		}
+		return []string{
+			join(dir, stem+"_test"+ext),
+			join(dir, "test_"+stem+ext),
+		}
+	}
+
+	return nil
+}
+
+// isTestFile reports whether the file name follows a test naming convention
+func isTestFile(name string) bool {
+	base := path.Base(filepath.ToSlash(name))
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	switch ext {
+	case ".go":
+		return strings.HasSuffix(stem, "_test")
+	case ".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs":
+		return strings.HasSuffix(stem, ".test") || strings.HasSuffix(stem, ".spec")
+	case ".py", ".c", ".cc", ".cpp":
+		return strings.HasPrefix(stem, "test_") || strings.HasSuffix(stem, "_test")
+	case ".java", ".kt", ".scala", ".cs":
+		return strings.HasSuffix(stem, "Test") || strings.HasSuffix(stem, "Tests")
+	case ".rb":
+		return strings.HasSuffix(stem, "_spec") || strings.HasSuffix(stem, "_test")
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTestPairCandidates(t *testing.T) {
+	tests := []struct {
+		rel  string
+		want []string
+	}{
+		{"pkg/render.go", []string{"pkg/render_test.go"}},
+		{"pkg/render_test.go", []string{"pkg/render.go"}},
+		{"src/app.ts", []string{"src/app.test.ts", "src/app.spec.ts", "src/__tests__/app.test.ts"}},
+		{"src/__tests__/app.test.ts", []string{"src/app.ts"}},
+		{"billing.py", []string{"test_billing.py", "billing_test.py", "tests/test_billing.py"}},
+		{"tests/test_billing.py", []string{"tests/billing.py", "billing.py"}},
+		{"src/main/java/App.java", []string{"src/test/java/AppTest.java", "src/test/java/AppTests.java"}},
+		{"src/test/java/AppTest.java", []string{"src/main/java/App.java"}},
+		{"README.md", nil},
+	}
+	for _, tt := range tests {
+		if got := testPairCandidates(tt.rel); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("testPairCandidates(%q) = %v, want %v", tt.rel, got, tt.want)
+		}
+	}
+}
+
+func TestWithTests(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"render.go":           "package main\n",
+		"render_test.go":      "package main\n",
+		"parse.go":            "package main\n",
+		"tests/test_app.py":   "import app\n",
+		"app.py":              "print('app')\n",
+		"docs/render_test.go": "package docs\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := DefaultOptions()
+	opts.Args = []string{"render.go", "parse.go", "tests/test_app.py"}
+	opts.WithTests = true
+	collector, err := NewCollector(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := collector.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, file := range result.Files {
+		got = append(got, file.RelPath)
+	}
+
+	// Each pair follows its counterpart and files without one stand alone
+	want := []string{"./render.go", "./render_test.go", "./parse.go", "./tests/test_app.py", "./app.py"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("selected %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// preparePatch reads the saved patch at path, selects the files it changes at
+// their local state and returns the patch as a section ahead of them.
+func preparePatch(dir string, path string, opts *options) ([]section, error) {
+	content, err := os.ReadFile(expandHome(path))
+	if err != nil {
+		return nil, err
+	}
+	patch := string(content)
+
+	changed := patchFiles(patch)
+	if len(changed) == 0 {
+		return nil, fmt.Errorf("%s does not change any files (expected a unified diff)", path)
+	}
+
+	// Files the patch creates do not exist locally yet, only the patch shows them
+	opts.paths = []string{}
+	for _, rel := range changed {
+		abs := filepath.Join(dir, filepath.FromSlash(rel))
+		if info, err := os.Stat(abs); err != nil || !info.Mode().IsRegular() {
+			if opts.verbose {
+				opts.logf("Skipping file not found locally (new in the patch?): %s\n", rel)
+			}
+			continue
+		}
+		opts.paths = append(opts.paths, abs)
+	}
+	if opts.verbose {
+		opts.logf("Files changed by %s: %v\n", path, changed)
+	}
+
+	return []section{{title: "Patch: " + filepath.Base(path), content: strings.TrimRight(patch, "\r\n")}}, nil
+}
+
+// patchFiles returns the slash-separated paths of the files a unified diff,
+// such as a saved GitHub pull request patch, changes in the order they appear.
+// Deleted files are left out, as are duplicates from a patch series.
+func patchFiles(patch string) []string {
+	var files []string
+	seen := make(map[string]bool)
+	var oldPath string
+	oldLines, newLines := 0, 0 // lines left in the current hunk
+	for _, line := range strings.Split(patch, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		// Skip the hunk bodies, a removed "-- comment" is not a header
+		if oldLines > 0 || newLines > 0 {
+			switch {
+			case strings.HasPrefix(line, "-"):
+				oldLines--
+			case strings.HasPrefix(line, "+"):
+				newLines--
+			case strings.HasPrefix(line, "\\"):
+			default:
+				oldLines--
+				newLines--
+			}
+			continue
+		}
+		if match := hunkHeader.FindStringSubmatch(line); match != nil {
+			oldLines, newLines = hunkLength(match[1]), hunkLength(match[2])
+			continue
+		}
+
+		if rest, ok := strings.CutPrefix(line, "--- "); ok {
+			oldPath = patchPath(rest)
+			continue
+		}
+		rest, ok := strings.CutPrefix(line, "+++ ")
+		if !ok {
+			continue
+		}
+		newPath := patchPath(rest)
+		if newPath == "/dev/null" {
+			continue
+		}
+		// git prefixes the old and new paths with a/ and b/
+		if strings.HasPrefix(newPath, "b/") && (strings.HasPrefix(oldPath, "a/") || oldPath == "/dev/null") {
+			newPath = newPath[len("b/"):]
+		}
+		if !seen[newPath] {
+			seen[newPath] = true
+			files = append(files, newPath)
+		}
+	}
+	return files
+}
+
+// hunkHeader matches the @@ -start,count +start,count @@ line opening a hunk
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,(\d+))? \+\d+(?:,(\d+))? @@`)
+
+// hunkLength returns the line count of a hunk range, which is 1 when omitted
+func hunkLength(count string) int {
+	if count == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(count)
+	return n
+}
+
+// patchPath returns the path of a ---/+++ header without the timestamp diff
+// may append and with git's quoting of unusual names undone
+func patchPath(header string) string {
+	if strings.HasPrefix(header, `"`) {
+		if end := strings.LastIndex(header, `"`); end > 0 {
+			if unquoted, err := strconv.Unquote(header[:end+1]); err == nil {
+				return unquoted
+			}
+		}
+	}
+	if tab := strings.IndexByte(header, '\t'); tab >= 0 {
+		header = header[:tab]
+	}
+	return strings.TrimSpace(header)
+}
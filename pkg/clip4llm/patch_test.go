@@ -0,0 +1,39 @@
+package clip4llm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPatchFiles(t *testing.T) {
+	patch := "From 1234 Mon Sep 17 00:00:00 2001\n" +
+		"Subject: [PATCH] Change things\n" +
+		"---\n" +
+		" main.go | 2 +-\n" +
+		"\n" +
+		"diff --git a/main.go b/main.go\n" +
+		"--- a/main.go\n" +
+		"+++ b/main.go\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		" keep\n" +
+		"--- not a header\n" +
+		"+++ not a header either\n" +
+		"diff --git a/docs/new.md b/docs/new.md\n" +
+		"--- /dev/null\n" +
+		"+++ b/docs/new.md\n" +
+		"diff --git a/gone.go b/gone.go\n" +
+		"--- a/gone.go\n" +
+		"+++ /dev/null\n" +
+		"diff --git \"a/sp ace.go\" \"b/sp ace.go\"\n" +
+		"--- \"a/sp ace.go\"\n" +
+		"+++ \"b/sp ace.go\"\n" +
+		"--- lib/util.c\t2024-01-01 00:00:00\n" +
+		"+++ lib/util.c\t2024-01-02 00:00:00\n" +
+		"--- a/main.go\n" +
+		"+++ b/main.go\n"
+
+	want := []string{"main.go", "docs/new.md", "sp ace.go", "lib/util.c"}
+	if got := patchFiles(patch); !reflect.DeepEqual(got, want) {
+		t.Errorf("patchFiles = %q, want %q", got, want)
+	}
+}
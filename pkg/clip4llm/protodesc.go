@@ -0,0 +1,167 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// isDescriptorFile reports whether the file name is that of a compiled
+// protobuf descriptor set
+func isDescriptorFile(name string) bool {
+	ext := filepath.Ext(name)
+	return ext == ".pb" || ext == ".desc"
+}
+
+// decodeDescriptorSet renders a compiled protobuf FileDescriptorSet (as
+// written by protoc --descriptor_set_out) back into .proto schema text.
+func decodeDescriptorSet(content []byte) ([]byte, error) {
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(content, &set); err != nil {
+		return nil, err
+	}
+	if len(set.File) == 0 {
+		return nil, fmt.Errorf("no file descriptors found")
+	}
+
+	var builder strings.Builder
+	for i, file := range set.File {
+		// Arbitrary binary data may decode without error, a real descriptor names its files
+		if file.GetName() == "" {
+			return nil, fmt.Errorf("not a descriptor set")
+		}
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		writeProtoFile(&builder, file)
+	}
+	return []byte(builder.String()), nil
+}
+
+// writeProtoFile writes the schema of a single .proto file
+func writeProtoFile(builder *strings.Builder, file *descriptorpb.FileDescriptorProto) {
+	syntax := file.GetSyntax()
+	if syntax == "" {
+		syntax = "proto2"
+	}
+	fmt.Fprintf(builder, "// %s\nsyntax = %q;\n", file.GetName(), syntax)
+	if file.GetPackage() != "" {
+		fmt.Fprintf(builder, "package %s;\n", file.GetPackage())
+	}
+	for _, dependency := range file.Dependency {
+		fmt.Fprintf(builder, "import %q;\n", dependency)
+	}
+
+	for _, enum := range file.EnumType {
+		builder.WriteString("\n")
+		writeProtoEnum(builder, enum, "")
+	}
+	for _, message := range file.MessageType {
+		builder.WriteString("\n")
+		writeProtoMessage(builder, message, "", syntax)
+	}
+	for _, service := range file.Service {
+		builder.WriteString("\n")
+		fmt.Fprintf(builder, "service %s {\n", service.GetName())
+		for _, method := range service.Method {
+			input, output := protoTypeName(method.GetInputType()), protoTypeName(method.GetOutputType())
+			if method.GetClientStreaming() {
+				input = "stream " + input
+			}
+			if method.GetServerStreaming() {
+				output = "stream " + output
+			}
+			fmt.Fprintf(builder, "  rpc %s(%s) returns (%s);\n", method.GetName(), input, output)
+		}
+		builder.WriteString("}\n")
+	}
+}
+
+// writeProtoEnum writes an enum definition at the given indentation
+func writeProtoEnum(builder *strings.Builder, enum *descriptorpb.EnumDescriptorProto, indent string) {
+	fmt.Fprintf(builder, "%senum %s {\n", indent, enum.GetName())
+	for _, value := range enum.Value {
+		fmt.Fprintf(builder, "%s  %s = %d;\n", indent, value.GetName(), value.GetNumber())
+	}
+	fmt.Fprintf(builder, "%s}\n", indent)
+}
+
+// writeProtoMessage writes a message definition with its nested types at the
+// given indentation
+func writeProtoMessage(builder *strings.Builder, message *descriptorpb.DescriptorProto, indent string, syntax string) {
+	fmt.Fprintf(builder, "%smessage %s {\n", indent, message.GetName())
+
+	// Map fields are represented by generated nested entry messages
+	mapEntries := make(map[string]*descriptorpb.DescriptorProto)
+	for _, nested := range message.NestedType {
+		if nested.GetOptions().GetMapEntry() {
+			mapEntries[nested.GetName()] = nested
+		}
+	}
+
+	for _, enum := range message.EnumType {
+		writeProtoEnum(builder, enum, indent+"  ")
+	}
+	for _, nested := range message.NestedType {
+		if mapEntries[nested.GetName()] == nil {
+			writeProtoMessage(builder, nested, indent+"  ", syntax)
+		}
+	}
+
+	writtenOneofs := make(map[int32]bool)
+	for _, field := range message.Field {
+		if field.OneofIndex != nil && !field.GetProto3Optional() {
+			index := field.GetOneofIndex()
+			if writtenOneofs[index] {
+				continue
+			}
+			writtenOneofs[index] = true
+			fmt.Fprintf(builder, "%s  oneof %s {\n", indent, message.OneofDecl[index].GetName())
+			for _, member := range message.Field {
+				if member.OneofIndex != nil && member.GetOneofIndex() == index {
+					fmt.Fprintf(builder, "%s    %s %s = %d;\n", indent, protoFieldType(member, mapEntries), member.GetName(), member.GetNumber())
+				}
+			}
+			fmt.Fprintf(builder, "%s  }\n", indent)
+			continue
+		}
+
+		label := ""
+		fieldType := protoFieldType(field, mapEntries)
+		switch {
+		case strings.HasPrefix(fieldType, "map<"):
+		case field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED:
+			label = "repeated "
+		case field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REQUIRED:
+			label = "required "
+		case field.GetProto3Optional() || syntax == "proto2":
+			label = "optional "
+		}
+		fmt.Fprintf(builder, "%s  %s%s %s = %d;\n", indent, label, fieldType, field.GetName(), field.GetNumber())
+	}
+
+	fmt.Fprintf(builder, "%s}\n", indent)
+}
+
+// protoFieldType returns the schema type of a field, resolving map entries
+func protoFieldType(field *descriptorpb.FieldDescriptorProto, mapEntries map[string]*descriptorpb.DescriptorProto) string {
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_ENUM, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		name := protoTypeName(field.GetTypeName())
+		if entry := mapEntries[name[strings.LastIndex(name, ".")+1:]]; entry != nil && len(entry.Field) == 2 {
+			return fmt.Sprintf("map<%s, %s>", protoFieldType(entry.Field[0], nil), protoFieldType(entry.Field[1], nil))
+		}
+		return name
+	}
+	return strings.ToLower(strings.TrimPrefix(field.GetType().String(), "TYPE_"))
+}
+
+// protoTypeName returns a fully qualified type reference without its leading dot
+func protoTypeName(name string) string {
+	return strings.TrimPrefix(name, ".")
+}
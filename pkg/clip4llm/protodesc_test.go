@@ -0,0 +1,97 @@
+package clip4llm
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestDecodeDescriptorSet(t *testing.T) {
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{Name: proto.String(name), Number: proto.Int32(number), Type: typ.Enum(), Label: label.Enum()}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+
+	email := field("email", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, "")
+	email.OneofIndex = proto.Int32(0)
+	phone := field("phone", 4, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, "")
+	phone.OneofIndex = proto.Int32(0)
+
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:    proto.String("users.proto"),
+		Package: proto.String("acme.users"),
+		Syntax:  proto.String("proto3"),
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name:  proto.String("Role"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{{Name: proto.String("ROLE_UNSPECIFIED"), Number: proto.Int32(0)}},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("User"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("id", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64, optional, ""),
+				field("roles", 2, descriptorpb.FieldDescriptorProto_TYPE_ENUM, repeated, ".acme.users.Role"),
+				email,
+				phone,
+				field("labels", 5, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, repeated, ".acme.users.User.LabelsEntry"),
+			},
+			NestedType: []*descriptorpb.DescriptorProto{{
+				Name:    proto.String("LabelsEntry"),
+				Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("key", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+					field("value", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+				},
+			}},
+			OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("contact")}},
+		}},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Users"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:            proto.String("Watch"),
+				InputType:       proto.String(".acme.users.User"),
+				OutputType:      proto.String(".acme.users.User"),
+				ServerStreaming: proto.Bool(true),
+			}},
+		}},
+	}}}
+
+	content, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := decodeDescriptorSet(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"syntax = \"proto3\";",
+		"package acme.users;",
+		"  ROLE_UNSPECIFIED = 0;",
+		"  int64 id = 1;",
+		"  repeated acme.users.Role roles = 2;",
+		"  oneof contact {\n    string email = 3;\n    string phone = 4;\n  }",
+		"  map<string, string> labels = 5;",
+		"  rpc Watch(acme.users.User) returns (stream acme.users.User);",
+	} {
+		if !strings.Contains(string(schema), want) {
+			t.Errorf("schema missing %q:\n%s", want, schema)
+		}
+	}
+	if strings.Contains(string(schema), "LabelsEntry {") {
+		t.Errorf("map entry rendered as a message:\n%s", schema)
+	}
+}
+
+func TestDecodeDescriptorSetRejectsOtherData(t *testing.T) {
+	if _, err := decodeDescriptorSet([]byte{0xff, 0xfe, 0x00, 0x01}); err == nil {
+		t.Error("expected an error for data that is not a descriptor set")
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Matches "from module import names", including parenthesized multi-line name lists
+var pyFromImportPattern = regexp.MustCompile(`(?m)^[ \t]*from[ \t]+([.\w]+)[ \t]+import[ \t]+(\([^)]*\)|[^\n#]+)`)
+
+// Matches "import a.b, c as d"
+var pyImportPattern = regexp.MustCompile(`(?m)^[ \t]*import[ \t]+([^\n#]+)`)
+
+// pythonRoots returns the directories searched for top-level Python packages
+func pythonRoots(dir string) []string {
+	roots := []string{dir}
+	if info, err := os.Stat(filepath.Join(dir, "src")); err == nil && info.IsDir() {
+		roots = append(roots, filepath.Join(dir, "src"))
+	}
+	return roots
+}
+
+// resolvePyModulePath maps a dotted module name to its module file or package
+// __init__.py below base.
+func resolvePyModulePath(base string, module string) (string, bool) {
+	modulePath := filepath.Join(base, filepath.FromSlash(strings.ReplaceAll(module, ".", "/")))
+	for _, candidate := range []string{modulePath + ".py", filepath.Join(modulePath, "__init__.py")} {
+		info, err := os.Stat(candidate)
+		if err == nil && info.Mode().IsRegular() {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// resolvePyModule finds the file for a dotted module name in any of the roots
+func resolvePyModule(roots []string, module string) (string, error) {
+	for _, root := range roots {
+		if path, ok := resolvePyModulePath(root, module); ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("python module %s not found", module)
+}
+
+// pyDependencies returns a dependency function listing the project-local
+// modules imported by a Python file, for use with collectClosure.
+func pyDependencies(roots []string) func(path string) ([]string, error) {
+	return func(path string) ([]string, error) {
+		if filepath.Ext(path) != ".py" {
+			return nil, nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		var deps []string
+		addModule := func(base string, module string) bool {
+			resolved, ok := resolvePyModulePath(base, module)
+			if ok {
+				deps = append(deps, resolved)
+			}
+			return ok
+		}
+		addAbsolute := func(module string) bool {
+			for _, root := range roots {
+				if addModule(root, module) {
+					return true
+				}
+			}
+			return false
+		}
+
+		for _, match := range pyImportPattern.FindAllStringSubmatch(string(content), -1) {
+			for _, name := range strings.Split(match[1], ",") {
+				fields := strings.Fields(name)
+				if len(fields) > 0 {
+					addAbsolute(fields[0])
+				}
+			}
+		}
+
+		for _, match := range pyFromImportPattern.FindAllStringSubmatch(string(content), -1) {
+			module := match[1]
+			names := strings.Trim(strings.TrimSpace(match[2]), "()")
+
+			// Resolve the package the import is relative to
+			var base string
+			relative := strings.HasPrefix(module, ".")
+			if relative {
+				level := len(module) - len(strings.TrimLeft(module, "."))
+				module = module[level:]
+				base = filepath.Dir(path)
+				for i := 1; i < level; i++ {
+					base = filepath.Dir(base)
+				}
+			}
+
+			resolve := func(name string) bool {
+				if relative {
+					if name == "" {
+						return false
+					}
+					return addModule(base, name)
+				}
+				return addAbsolute(name)
+			}
+
+			resolve(module)
+
+			// Imported names may themselves be submodules (from pkg import mod)
+			for _, name := range strings.Split(names, ",") {
+				fields := strings.Fields(name)
+				if len(fields) == 0 || fields[0] == "*" {
+					continue
+				}
+				submodule := fields[0]
+				if module != "" {
+					submodule = module + "." + submodule
+				}
+				resolve(submodule)
+			}
+		}
+
+		return deps, nil
+	}
+}
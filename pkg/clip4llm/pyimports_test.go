@@ -0,0 +1,63 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestPyModuleClosure(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"src/app/__init__.py":               "",
+		"src/app/services/__init__.py":      "",
+		"src/app/services/billing.py":       "import os, app.models\nfrom . import invoices\nfrom ..util import (\n    money,\n)\n",
+		"src/app/services/invoices.py":      "from app.models import Invoice  # the model\n",
+		"src/app/services/notifications.py": "import smtplib\n",
+		"src/app/models.py":                 "import datetime\n",
+		"src/app/util/__init__.py":          "from .money import Money\n",
+		"src/app/util/money.py":             "from decimal import Decimal\n",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	opts := DefaultOptions()
+	opts.PyModules = []string{"app.services.billing"}
+	collector, err := NewCollector(dir, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := collector.Collect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, file := range result.Files {
+		got = append(got, file.RelPath)
+	}
+	sort.Strings(got)
+
+	// Absolute, relative and parenthesized imports resolve below src, while the
+	// standard library and sibling modules nothing imports are left out
+	want := []string{"./src/app/models.py", "./src/app/services/billing.py", "./src/app/services/invoices.py", "./src/app/util/__init__.py", "./src/app/util/money.py"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("selected %v, want %v", got, want)
+	}
+
+	opts.PyModules = []string{"app.missing"}
+	collector, err = NewCollector(dir, opts)
+	if err == nil {
+		_, err = collector.Collect()
+	}
+	if err == nil {
+		t.Error("unknown module app.missing was accepted")
+	}
+}
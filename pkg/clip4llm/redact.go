@@ -0,0 +1,102 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+)
+
+// redactionRule replaces every match of pattern with a [REDACTED:name]
+// placeholder. With a minimum entropy only the last capture group is replaced,
+// and only when it looks random enough to be a secret.
+type redactionRule struct {
+	name       string
+	pattern    *regexp.Regexp
+	minEntropy float64 // bits per character, 0 replaces every match
+}
+
+// The built-in rules for well known secret formats
+var builtinRedactionRules = []redactionRule{
+	{name: "private-key", pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{name: "aws-key", pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "bearer-token", pattern: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]{16,}=*`)},
+	// .env style KEY=VALUE lines whose value is long and random looking
+	{name: "env-secret", pattern: regexp.MustCompile(`(?m)^[ \t]*(?:export[ \t]+)?[A-Za-z_][A-Za-z0-9_]*[ \t]*=[ \t]*["']?([^\s"'#]{16,})`), minEntropy: 3.5},
+}
+
+// redactionRules returns the built-in rules followed by the custom rules,
+// given as regular expressions by name, sorted by name.
+func redactionRules(custom map[string]string) ([]redactionRule, error) {
+	rules := append([]redactionRule{}, builtinRedactionRules...)
+
+	var names []string
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pattern, err := regexp.Compile(custom[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction rule %s: %v", name, err)
+		}
+		rules = append(rules, redactionRule{name: name, pattern: pattern})
+	}
+	return rules, nil
+}
+
+// redactSecrets replaces the secrets matched by rules in content with placeholders
+// and returns the redacted content with the number of replacements made.
+func redactSecrets(content []byte, rules []redactionRule) ([]byte, int) {
+	count := 0
+	for _, rule := range rules {
+		placeholder := []byte("[REDACTED:" + rule.name + "]")
+
+		if rule.minEntropy == 0 {
+			content = rule.pattern.ReplaceAllFunc(content, func([]byte) []byte {
+				count++
+				return placeholder
+			})
+			continue
+		}
+
+		// Replace only the captured value, keeping the key around it
+		var out []byte
+		last := 0
+		for _, match := range rule.pattern.FindAllSubmatchIndex(content, -1) {
+			start, end := match[len(match)-2], match[len(match)-1]
+			if start < 0 || bytes.HasPrefix(content[start:end], []byte("[REDACTED:")) ||
+				shannonEntropy(content[start:end]) < rule.minEntropy {
+				continue
+			}
+			out = append(out, content[last:start]...)
+			out = append(out, placeholder...)
+			last = end
+			count++
+		}
+		if out != nil {
+			content = append(out, content[last:]...)
+		}
+	}
+	return content, count
+}
+
+// shannonEntropy returns the entropy of value in bits per character
+func shannonEntropy(value []byte) float64 {
+	if len(value) == 0 {
+		return 0
+	}
+	counts := make(map[byte]int)
+	for _, b := range value {
+		counts[b]++
+	}
+	entropy := 0.0
+	for _, n := range counts {
+		p := float64(n) / float64(len(value))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
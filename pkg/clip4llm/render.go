@@ -0,0 +1,448 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Define the default max total size limit in bytes (1MB = 1,048,576 bytes)
+const defaultMaxTotalSize = 1 * 1024 * 1024 // 1MB in bytes
+
+// renderedFile is the formatted output of one selected file
+type renderedFile struct {
+	relPath string
+	index   int    // position among the documents, numbering the xml output
+	note    string // note about the whole file, such as its blame summary
+	content string // transformed content, kept for splitting over parts
+	text    string
+}
+
+// document is a section or file about to be rendered
+type document struct {
+	title   string // heading of a section
+	path    string // relative path of a file, empty for a section
+	note    string // about the file, such as where a file split over parts continues
+	content string
+}
+
+// jsonDocument is the --format json representation of a section or file
+type jsonDocument struct {
+	Path     string `json:"path,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Note     string `json:"note,omitempty"`
+	Size     int    `json:"size"`
+	Language string `json:"language,omitempty"`
+	Content  string `json:"content"`
+}
+
+// layout is the rendered output of a run before the output limit is applied
+type layout struct {
+	preamble  string // preamble text placed before the documents
+	prefix    string // text opening the documents
+	separator string // text between documents
+	suffix    string // text closing the documents
+	head      []string
+	files     []renderedFile
+	trailers  []section
+	opts      *options
+}
+
+// renderLayout renders the preamble, the sections and the content of each
+// selected file into the output format.
+func renderLayout(files []fileEntry, sections []section, opts *options) *layout {
+	l := &layout{opts: opts}
+
+	// The text around and between the documents of each format
+	switch opts.format {
+	case "xml":
+		l.prefix, l.suffix = "<documents>\n", "</documents>\n"
+	case "json":
+		l.prefix, l.separator, l.suffix = "[\n", ",\n", "\n]\n"
+	}
+
+	if opts.preamble != "" {
+		if opts.format == "json" {
+			l.head = append(l.head, renderDocument(0, document{title: "Preamble", content: opts.preamble}, opts))
+		} else {
+			l.preamble = opts.preamble + "\n"
+		}
+	}
+	for _, s := range sections {
+		// Generated sections such as command output can leak secrets just like files
+		if opts.redactSecrets {
+			redacted, _ := redactSecrets([]byte(s.content), opts.redactRules)
+			s.content = string(redacted)
+		}
+		if s.trailing {
+			l.trailers = append(l.trailers, s)
+			continue
+		}
+		l.head = append(l.head, renderDocument(len(l.head)+1, document{title: s.title, content: s.content}, opts))
+	}
+
+	// Read and transform the files in parallel, numbering them in order after
+	contents := make([][]byte, len(files))
+	read := make([]bool, len(files))
+	notes := make([]string, len(files))
+	forEachFile(len(files), opts, func(i int) {
+		file := files[i]
+
+		// Read the content of the file, truncated when it is over the max size
+		endRead := opts.trace.Begin("read", file.path)
+		content, err := readFileContent(file.path, opts)
+		endRead()
+		if err != nil {
+			if opts.verbose {
+				opts.logf("Failed to read file: %s\n", file.path)
+			}
+			return
+		}
+
+		// The format phase itself is timed as a whole by the caller
+		endFormat := opts.trace.Begin("", file.path)
+		contents[i] = transformContent(file.relPath, content, opts)
+		endFormat()
+		read[i] = true
+
+		// Note how big and how recent the file is, and who wrote it
+		var note []string
+		if opts.metadata {
+			if metadata, err := fileMetadata(file.path, contents[i], opts.raw, time.Now()); err == nil {
+				note = append(note, metadata)
+			}
+		}
+		if opts.blameSummary {
+			summary, err := blameSummary(file.path)
+			if err == nil {
+				note = append(note, summary)
+			} else if opts.verbose {
+				opts.logf("No blame summary for %s: %v\n", file.relPath, err)
+			}
+		}
+		notes[i] = strings.Join(note, ", ")
+	})
+
+	for i, file := range files {
+		if !read[i] {
+			continue
+		}
+		content := string(contents[i])
+		if opts.format == "delimited" {
+			if _, collides := fenceFor(opts.delimiter, content); collides {
+				opts.collisions = append(opts.collisions, file.relPath)
+			}
+		}
+		index := len(l.head) + len(l.files) + 1
+		fileContent := renderDocument(index, document{path: file.relPath, note: notes[i], content: content}, opts)
+		l.files = append(l.files, renderedFile{relPath: file.relPath, index: index, note: notes[i], content: content, text: fileContent})
+	}
+
+	return l
+}
+
+// renderTrailers renders the trailing sections numbered from start
+func (l *layout) renderTrailers(start int) []string {
+	var footer []string
+	for i, s := range l.trailers {
+		footer = append(footer, renderDocument(start+i+1, document{title: s.title, content: s.content}, l.opts))
+	}
+	return footer
+}
+
+// assemble joins rendered documents into one self-contained output
+func (l *layout) assemble(preamble string, docs []string) string {
+	return preamble + l.prefix + strings.Join(docs, l.separator) + l.suffix
+}
+
+// buildOutput assembles the preamble, the sections and the content of each
+// selected file into the output format and enforces the output limit: the
+// --max-tokens budget when one is set, otherwise the --max-total-size limit.
+func buildOutput(files []fileEntry, sections []section, opts *options) (string, error) {
+	l := renderLayout(files, sections, opts)
+
+	// Trailing sections are numbered after the files that are kept
+	fixed := l.assemble(l.preamble, append(append([]string{}, l.head...), l.renderTrailers(len(l.head)+len(l.files))...))
+	rendered, err := enforceOutputLimit(fixed, l.files, opts)
+	if err != nil {
+		return "", err
+	}
+
+	docs := append([]string{}, l.head...)
+	for _, file := range rendered {
+		docs = append(docs, file.text)
+	}
+	docs = append(docs, l.renderTrailers(len(docs))...)
+	return l.assemble(l.preamble, docs), nil
+}
+
+// buildChunks splits the output into self-contained parts that each fit the
+// output limit, keeping documents whole where they fit in a part of their own.
+// A file too large for any part fills the rest of the current part and
+// continues in the next ones, split at line boundaries. The preamble and
+// leading sections open the first part and the trailing sections close the
+// last one.
+func buildChunks(files []fileEntry, sections []section, opts *options) ([]string, error) {
+	l := renderLayout(files, sections, opts)
+
+	limit, unit := opts.maxTotalSize, "bytes"
+	measure := func(text string) int { return len(text) }
+	if opts.maxTokens > 0 {
+		limit, unit = opts.maxTokens, opts.tokenizer.name+" tokens"
+		measure = opts.tokenizer.estimate
+	}
+
+	// Only files may be split, the sections always stay whole
+	type chunkDoc struct {
+		text string
+		file *renderedFile
+	}
+	var docs []chunkDoc
+	for _, text := range l.head {
+		docs = append(docs, chunkDoc{text: text})
+	}
+	for i := range l.files {
+		docs = append(docs, chunkDoc{text: l.files[i].text, file: &l.files[i]})
+	}
+	for _, text := range l.renderTrailers(len(l.head) + len(l.files)) {
+		docs = append(docs, chunkDoc{text: text})
+	}
+
+	// Reserve room for the part marker and the wrapping of every part, split
+	// files can take up to a part per line
+	maxParts := len(docs)
+	for _, file := range l.files {
+		maxParts += strings.Count(file.content, "\n")
+	}
+	overhead := measure(l.assemble(fmt.Sprintf("Part %d of %d\n", maxParts, maxParts), nil))
+
+	var parts [][]string
+	var current []string
+	used := measure(l.preamble) + overhead
+	for _, doc := range docs {
+		size := measure(doc.text) + measure(l.separator)
+		if used+size > limit && doc.file != nil && overhead+size > limit {
+			// Too large for any part, fill the rest of this one and continue in the next
+			pieces := splitFile(doc.file, len(parts)+1, limit-used, limit-overhead, func(text string) int {
+				return measure(text) + measure(l.separator)
+			}, opts)
+			if pieces == nil {
+				return nil, fmt.Errorf("a single line of %s exceeds the limit of %d %s and cannot be split into parts", doc.file.relPath, limit, unit)
+			}
+			if pieces[0] == "" {
+				pieces = pieces[1:]
+				parts = append(parts, current)
+				current = nil
+			}
+			for _, piece := range pieces[:len(pieces)-1] {
+				parts = append(parts, append(current, piece))
+				current = nil
+			}
+			last := pieces[len(pieces)-1]
+			current, used = []string{last}, overhead+measure(last)+measure(l.separator)
+			continue
+		}
+		if used+size > limit && len(current) > 0 {
+			parts = append(parts, current)
+			current, used = nil, overhead
+		}
+		if used+size > limit {
+			return nil, fmt.Errorf("a single document exceeds the limit of %d %s and cannot be split into parts", limit, unit)
+		}
+		current = append(current, doc.text)
+		used += size
+	}
+	if len(current) > 0 || len(parts) == 0 {
+		parts = append(parts, current)
+	}
+
+	var chunks []string
+	for i, part := range parts {
+		preamble := ""
+		if i == 0 {
+			preamble = l.preamble
+		}
+		// A JSON part must stay a plain array, the other formats name the part
+		if len(parts) > 1 && opts.format != "json" {
+			preamble = fmt.Sprintf("Part %d of %d\n", i+1, len(parts)) + preamble
+		}
+		chunks = append(chunks, l.assemble(preamble, part))
+	}
+	return chunks, nil
+}
+
+// splitFile renders the file as pieces split at line boundaries, the first
+// one taking the room left in part and every other one up to room in a part
+// of its own. Each piece notes its lines and the parts it continues from and
+// in. The first piece is empty when not even one line fits the room left,
+// and nil is returned when a single line is too large for any part.
+func splitFile(file *renderedFile, part int, left int, room int, measure func(string) int, opts *options) []string {
+	lines := strings.SplitAfter(file.content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	render := func(start, end, part int, last bool) string {
+		note := fmt.Sprintf("lines %d-%d of %d", start+1, end, len(lines))
+		if file.note != "" {
+			note = file.note + ", " + note
+		}
+		if start > 0 {
+			note += fmt.Sprintf(", continued from part %d", part-1)
+		}
+		if !last {
+			note += fmt.Sprintf(", continued in part %d", part+1)
+		}
+		return renderDocument(file.index, document{path: file.relPath, note: note, content: strings.Join(lines[start:end], "")}, opts)
+	}
+
+	var pieces []string
+	for start := 0; start < len(lines); part++ {
+		// The rest fits whole and closes the file
+		if text := render(start, len(lines), part, true); measure(text) <= left {
+			return append(pieces, text)
+		}
+		// Find the most lines that fit with a continuation note
+		fits := sort.Search(len(lines)-start, func(n int) bool {
+			return measure(render(start, start+n+1, part, false)) > left
+		})
+		if fits == 0 {
+			if len(pieces) > 0 || left == room {
+				return nil
+			}
+			// Nothing fits in the current part, start over in the next one
+			pieces = append(pieces, "")
+			left = room
+			continue
+		}
+		pieces = append(pieces, render(start, start+fits, part, false))
+		start += fits
+		left = room
+	}
+	return pieces
+}
+
+// Escapes the characters with a meaning in XML text
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// renderDocument formats a section or file for the output format: a titled,
+// delimited block by default, a numbered <document> element for xml or an
+// object for json.
+func renderDocument(index int, doc document, opts *options) string {
+	switch opts.format {
+	case "xml":
+		source := doc.path
+		if source == "" {
+			source = doc.title
+		}
+		if doc.note != "" {
+			source += " (" + doc.note + ")"
+		}
+		return fmt.Sprintf("<document index=\"%d\">\n<source>%s</source>\n<document_contents>\n%s\n</document_contents>\n</document>\n",
+			index, xmlEscaper.Replace(source), xmlEscaper.Replace(strings.TrimSuffix(doc.content, "\n")))
+
+	case "json":
+		// Keep <, > and & readable instead of escaping them for HTML
+		var encoded strings.Builder
+		encoder := json.NewEncoder(&encoded)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("  ", "  ")
+		encoder.Encode(jsonDocument{
+			Path:     doc.path,
+			Title:    doc.title,
+			Note:     doc.note,
+			Size:     len(doc.content),
+			Language: opts.languageFor(doc.path, []byte(doc.content)),
+			Content:  doc.content,
+		})
+		return "  " + strings.TrimSuffix(encoded.String(), "\n")
+	}
+
+	title := doc.title
+	if doc.path != "" {
+		title = "File: " + doc.path
+	}
+	if doc.note != "" {
+		title += " (" + doc.note + ")"
+	}
+	fence, _ := fenceFor(opts.delimiter, doc.content)
+	return fmt.Sprintf("\n%s\n\n%s\n%s\n%s\n\n", title, fence, doc.content, fence)
+}
+
+// fenceFor returns the delimiter wrapping content. A markdown fence of
+// backticks or tildes is lengthened past the longest run of them starting a
+// line of content, so nested fences cannot close it early. Other delimiters
+// are kept, reporting true when content contains them.
+func fenceFor(delimiter string, content string) (string, bool) {
+	var fenceChar string
+	if len(delimiter) >= 3 && (strings.Trim(delimiter, "`") == "" || strings.Trim(delimiter, "~") == "") {
+		fenceChar = delimiter[:1]
+	}
+	if fenceChar == "" {
+		return delimiter, strings.Contains(content, delimiter)
+	}
+
+	longest := 0
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimLeft(line, " \t")
+		if run := len(line) - len(strings.TrimLeft(line, fenceChar)); run > longest {
+			longest = run
+		}
+	}
+	if longest < len(delimiter) {
+		return delimiter, false
+	}
+	return strings.Repeat(fenceChar, longest+1), false
+}
+
+// enforceOutputLimit checks the output against the token budget or the size
+// limit. When over the token budget in trim mode the trailing files are
+// dropped until the output fits, otherwise an error is returned.
+func enforceOutputLimit(header string, files []renderedFile, opts *options) ([]renderedFile, error) {
+	if opts.maxTokens <= 0 {
+		totalSize := len(header)
+		for _, file := range files {
+			totalSize += len(file.text)
+		}
+		// Check if the total size exceeds the size limit
+		if totalSize > opts.maxTotalSize {
+			return nil, fmt.Errorf("total output size of %d bytes exceeds the --max-total-size limit of %d bytes; content not copied", totalSize, opts.maxTotalSize)
+		}
+		return files, nil
+	}
+
+	model := opts.tokenizer
+	total := model.estimate(header)
+	if total > opts.maxTokens {
+		return nil, fmt.Errorf("estimated %d %s tokens before any file exceeds the --max-tokens budget of %d", total, model.name, opts.maxTokens)
+	}
+
+	for i, file := range files {
+		tokens := model.estimate(file.text)
+		if total+tokens <= opts.maxTokens {
+			total += tokens
+			continue
+		}
+
+		if opts.maxTokensAction != "trim" {
+			for _, rest := range files[i+1:] {
+				tokens += model.estimate(rest.text)
+			}
+			return nil, fmt.Errorf("estimated output of %d %s tokens exceeds the --max-tokens budget of %d; content not copied", total+tokens, model.name, opts.maxTokens)
+		}
+
+		if opts.verbose {
+			for _, dropped := range files[i:] {
+				opts.logf("Trimming file to fit the token budget: %s\n", dropped.relPath)
+			}
+		}
+		opts.logf("Trimmed %d of %d files to fit the budget of %d %s tokens.\n", len(files)-i, len(files), opts.maxTokens, model.name)
+		return files[:i], nil
+	}
+
+	return files, nil
+}
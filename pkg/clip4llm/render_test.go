@@ -0,0 +1,197 @@
+package clip4llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRenderDocumentXML(t *testing.T) {
+	opts := &options{format: "xml"}
+	got := renderDocument(2, document{path: "./a&b.go", content: "if a < b && c > d {}\n"}, opts)
+	want := "<document index=\"2\">\n<source>./a&amp;b.go</source>\n<document_contents>\nif a &lt; b &amp;&amp; c &gt; d {}\n</document_contents>\n</document>\n"
+	if got != want {
+		t.Errorf("renderDocument = %q, want %q", got, want)
+	}
+}
+
+func TestRenderDocumentDelimited(t *testing.T) {
+	opts := &options{format: "delimited", delimiter: "```"}
+	got := renderDocument(1, document{path: "./main.go", content: "package main\n"}, opts)
+	want := "\nFile: ./main.go\n\n```\npackage main\n\n```\n\n"
+	if got != want {
+		t.Errorf("renderDocument = %q, want %q", got, want)
+	}
+}
+
+func TestBuildOutputJSON(t *testing.T) {
+	opts := &options{format: "json", preamble: "Explain this.", maxTotalSize: defaultMaxTotalSize}
+	output, err := buildOutput(nil, []section{{title: "Tree", content: "a <b>"}}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var docs []jsonDocument
+	if err := json.Unmarshal([]byte(output), &docs); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, output)
+	}
+	if len(docs) != 2 || docs[0].Title != "Preamble" || docs[1].Content != "a <b>" || docs[1].Size != 5 {
+		t.Errorf("unexpected documents: %+v", docs)
+	}
+}
+
+func TestBuildOutputMaxTotalSize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+	if err := os.WriteFile(path, []byte(strings.Repeat("x", 2048)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := []fileEntry{{path: path, relPath: "./a.txt"}}
+
+	opts := &options{format: "delimited", delimiter: "```", maxTotalSize: 4096}
+	if _, err := buildOutput(files, nil, opts); err != nil {
+		t.Fatalf("output under the limit failed: %v", err)
+	}
+
+	opts.maxTotalSize = 1024
+	if _, err := buildOutput(files, nil, opts); err == nil || !strings.Contains(err.Error(), "--max-total-size") {
+		t.Errorf("expected the --max-total-size limit to be enforced, got %v", err)
+	}
+}
+
+func TestBuildChunks(t *testing.T) {
+	dir := t.TempDir()
+	var files []fileEntry
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.Repeat("word ", 40)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, fileEntry{path: path, relPath: "./" + name})
+	}
+
+	model, _ := lookupTokenizer("cl100k_base")
+	opts := &options{format: "delimited", delimiter: "```", preamble: "Intro", maxTokens: 100, tokenizer: model}
+	chunks, err := buildChunks(files, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if tokens := model.estimate(chunk); tokens > opts.maxTokens {
+			t.Errorf("chunk %d has %d tokens, over the budget of %d", i+1, tokens, opts.maxTokens)
+		}
+	}
+	if !strings.HasPrefix(chunks[0], "Part 1 of 3\nIntro\n") || strings.Contains(chunks[1], "Intro") {
+		t.Errorf("preamble should open only the first part:\n%s", chunks[0])
+	}
+
+	opts.maxTokens = 20
+	if _, err := buildChunks(files, nil, opts); err == nil {
+		t.Error("expected an error when a single file exceeds the budget")
+	}
+}
+
+func TestBuildChunksSplitsLargeFiles(t *testing.T) {
+	dir := t.TempDir()
+	var lines []string
+	for i := 1; i <= 60; i++ {
+		lines = append(lines, fmt.Sprintf("line %02d of the large file", i))
+	}
+	large := strings.Join(lines, "\n") + "\n"
+	var files []fileEntry
+	for name, content := range map[string]string{"a.txt": "small\n", "b.txt": large} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, fileEntry{path: path, relPath: "./" + name})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].relPath < files[j].relPath })
+
+	opts := &options{format: "delimited", delimiter: "```", maxTotalSize: 700}
+	chunks, err := buildChunks(files, nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) < 3 {
+		t.Fatalf("got %d chunks, want the large file split over several", len(chunks))
+	}
+
+	var joined strings.Builder
+	for i, chunk := range chunks {
+		if len(chunk) > opts.maxTotalSize {
+			t.Errorf("chunk %d has %d bytes, over the limit of %d", i+1, len(chunk), opts.maxTotalSize)
+		}
+		if i < len(chunks)-1 && !strings.Contains(chunk, fmt.Sprintf("continued in part %d)", i+2)) {
+			t.Errorf("chunk %d does not point to the next part:\n%s", i+1, chunk)
+		}
+		if i > 0 && !strings.Contains(chunk, fmt.Sprintf("continued from part %d", i)) {
+			t.Errorf("chunk %d does not point to the previous part:\n%s", i+1, chunk)
+		}
+		joined.WriteString(chunk)
+	}
+	if !strings.Contains(chunks[0], "File: ./a.txt\n") || !strings.Contains(chunks[0], "File: ./b.txt (lines 1-") {
+		t.Errorf("the large file should start in the first part after the small one:\n%s", chunks[0])
+	}
+	for _, line := range lines {
+		if strings.Count(joined.String(), line+"\n") != 1 {
+			t.Errorf("%q should appear exactly once across the parts", line)
+		}
+	}
+}
+
+func TestRenderLayoutKeepsOrderWhenConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	var files []fileEntry
+	for i := 0; i < 50; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("f%02d.txt", i))
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", 50-i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, fileEntry{path: path, relPath: filepath.Base(path)})
+	}
+	files = append(files[:10], append([]fileEntry{{path: filepath.Join(dir, "missing.txt"), relPath: "missing.txt"}}, files[10:]...)...)
+
+	var outputs []string
+	for _, concurrency := range []int{1, 16} {
+		opts := &options{format: "xml", maxTotalSize: defaultMaxTotalSize, concurrency: concurrency}
+		output, err := buildOutput(files, nil, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, output)
+	}
+	if outputs[0] != outputs[1] {
+		t.Error("output differs between sequential and concurrent reads")
+	}
+	if strings.Contains(outputs[1], "missing.txt") || !strings.Contains(outputs[1], "<document index=\"50\">\n<source>f49.txt</source>") {
+		t.Errorf("unreadable files should be skipped without a gap in the numbering:\n%s", outputs[1])
+	}
+}
+
+func TestFenceFor(t *testing.T) {
+	cases := []struct {
+		delimiter, content, fence string
+		collides                  bool
+	}{
+		{"```", "package main\n", "```", false},
+		{"```", "# Doc\n\n```go\nx\n```\n", "````", false},
+		{"```", "  `````\n", "``````", false},
+		{"~~~", "```\n~~~~\n", "~~~~~", false},
+		{"<<<END>>>", "a <<<END>>> b\n", "<<<END>>>", true},
+		{"<<<END>>>", "```\n", "<<<END>>>", false},
+	}
+	for _, c := range cases {
+		fence, collides := fenceFor(c.delimiter, c.content)
+		if fence != c.fence || collides != c.collides {
+			t.Errorf("fenceFor(%q, %q) = %q, %v, want %q, %v", c.delimiter, c.content, fence, collides, c.fence, c.collides)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ReportSchemaVersion is the version of the run report format, incremented
+// on any change to it
+const ReportSchemaVersion = 1
+
+// ReportSchema is the JSON Schema describing the run report
+//
+//go:embed schema/report.v1.json
+var ReportSchema string
+
+// Report is the summary of a run written by --report-json. It follows
+// schema/report.v1.json, fields may only change along with the version.
+type Report struct {
+	SchemaVersion int          `json:"schema_version"`
+	Directory     string       `json:"directory"`
+	Format        string       `json:"format"`
+	Delivery      string       `json:"delivery"`
+	Tokenizer     string       `json:"tokenizer"`
+	MaxTokens     int          `json:"max_tokens"`
+	Files         []ReportFile `json:"files"`
+	Totals        ReportTotals `json:"totals"`
+}
+
+// ReportFile is the size of one selected file in the output
+type ReportFile struct {
+	Path     string `json:"path"`
+	Bytes    int    `json:"bytes"`
+	Tokens   int    `json:"tokens"`
+	Language string `json:"language,omitempty"`
+}
+
+// ReportTotals is the size of the complete output
+type ReportTotals struct {
+	Files  int `json:"files"`
+	Bytes  int `json:"bytes"`
+	Tokens int `json:"tokens"`
+}
+
+// buildReport summarizes a run whose output went to delivery
+func buildReport(dir string, files []fileEntry, output string, delivery string, opts *options) Report {
+	report := Report{
+		SchemaVersion: ReportSchemaVersion,
+		Directory:     dir,
+		Format:        opts.format,
+		Delivery:      delivery,
+		Tokenizer:     opts.tokenizer.name,
+		MaxTokens:     opts.maxTokens,
+		Files:         []ReportFile{},
+		Totals: ReportTotals{
+			Files:  len(files),
+			Bytes:  len(output),
+			Tokens: opts.tokenizer.estimate(output),
+		},
+	}
+	for _, file := range files {
+		content, err := readFileContent(file.path, opts)
+		if err != nil {
+			continue
+		}
+		content = transformContent(file.relPath, content, opts)
+		report.Files = append(report.Files, ReportFile{
+			Path:     file.relPath,
+			Bytes:    len(content),
+			Tokens:   opts.tokenizer.estimate(string(content)),
+			Language: opts.languageFor(file.relPath, content),
+		})
+	}
+	return report
+}
+
+// WriteReport writes the report as indented JSON to path
+func WriteReport(path string, report Report) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// ValidateReport checks that content is a run report of the supported schema
+// version with every required field present and no unknown fields.
+func ValidateReport(content []byte) error {
+	// Check the version first so an old or newer report gets a clear message
+	var version struct {
+		SchemaVersion *int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(content, &version); err != nil {
+		return fmt.Errorf("not valid JSON: %v", err)
+	}
+	if version.SchemaVersion == nil {
+		return fmt.Errorf("missing schema_version")
+	}
+	if *version.SchemaVersion != ReportSchemaVersion {
+		return fmt.Errorf("unsupported schema_version %d (expected %d)", *version.SchemaVersion, ReportSchemaVersion)
+	}
+
+	// Every required field must be present, checked before decoding fills in zero values
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(content, &fields); err != nil {
+		return fmt.Errorf("not a JSON object: %v", err)
+	}
+	for _, name := range []string{"directory", "format", "delivery", "tokenizer", "files", "totals"} {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("missing %s", name)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	var report Report
+	if err := decoder.Decode(&report); err != nil {
+		return err
+	}
+
+	switch report.Format {
+	case "delimited", "xml", "json":
+	default:
+		return fmt.Errorf("invalid format %q", report.Format)
+	}
+	switch report.Delivery {
+	case "clipboard", "stdout", "file", "none":
+	default:
+		return fmt.Errorf("invalid delivery %q", report.Delivery)
+	}
+	if report.MaxTokens < 0 || report.Totals.Files < 0 || report.Totals.Bytes < 0 || report.Totals.Tokens < 0 {
+		return fmt.Errorf("negative size in totals or max_tokens")
+	}
+	for i, file := range report.Files {
+		if file.Path == "" || file.Bytes < 0 || file.Tokens < 0 {
+			return fmt.Errorf("invalid entry %d in files", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package clip4llm
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReportRoundTrip(t *testing.T) {
+	model, _ := lookupTokenizer("cl100k_base")
+	opts := &options{format: "xml", tokenizer: model, maxTokens: 1000}
+	report := buildReport("/project", nil, "<documents>\n</documents>\n", "stdout", opts)
+
+	path := filepath.Join(t.TempDir(), "report.json")
+	if err := WriteReport(path, report); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateReport(content); err != nil {
+		t.Errorf("a written report should validate: %v", err)
+	}
+}
+
+func TestValidateReportRejects(t *testing.T) {
+	valid := `{"schema_version": 1, "directory": "/p", "format": "json", "delivery": "none", "tokenizer": "llama", "max_tokens": 0, "files": [], "totals": {"files": 0, "bytes": 0, "tokens": 0}}`
+	if err := ValidateReport([]byte(valid)); err != nil {
+		t.Fatalf("valid report rejected: %v", err)
+	}
+
+	cases := map[string]string{
+		"missing version":  strings.Replace(valid, `"schema_version": 1, `, "", 1),
+		"newer version":    strings.Replace(valid, `"schema_version": 1`, `"schema_version": 2`, 1),
+		"missing field":    strings.Replace(valid, `"tokenizer": "llama", `, "", 1),
+		"unknown field":    strings.Replace(valid, `"files": []`, `"files": [], "extra": true`, 1),
+		"invalid delivery": strings.Replace(valid, `"delivery": "none"`, `"delivery": "printer"`, 1),
+		"not json":         "{",
+	}
+	for name, content := range cases {
+		if err := ValidateReport([]byte(content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestReportSchemaMatchesVersion(t *testing.T) {
+	var schema struct {
+		Properties struct {
+			SchemaVersion struct {
+				Const int `json:"const"`
+			} `json:"schema_version"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(ReportSchema), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if schema.Properties.SchemaVersion.Const != ReportSchemaVersion {
+		t.Errorf("schema declares version %d, code writes %d", schema.Properties.SchemaVersion.Const, ReportSchemaVersion)
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Matches route registrations for Go net/http muxes and routers (chi, gin,
+// echo, gorilla), Express style routers and Flask/FastAPI decorators. The
+// first group is the route path and the second the remaining arguments.
+var routeRegistrationPattern = regexp.MustCompile(`\.(?:HandleFunc|Handle|Get|Post|Put|Patch|Delete|Options|Head|Any|Method|Path|GET|POST|PUT|PATCH|DELETE|OPTIONS|HEAD|get|post|put|patch|delete|options|head|all|route|api_route|add_url_rule|websocket)\s*\(\s*["'` + "`" + `]([^"'` + "`" + `]+)["'` + "`" + `]\s*,?(.*)`)
+
+// Matches the path prefix a router or group is mounted below, such as
+// app.use("/api", router), r.Mount("/api", sub), r.Group("/v1"),
+// app.include_router(router, prefix="/api") or a Flask url_prefix="/api"
+var routeMountPattern = regexp.MustCompile(`\.(?:use|Mount|Group|Route|include_router|register_blueprint)\s*\(\s*["'` + "`" + `](/[^"'` + "`" + `]*)["'` + "`" + `]|\b(?:url_)?prefix\s*=\s*["'](/[^"']*)["']`)
+
+// Matches middleware registrations such as app.use(auth), r.Use(logger) or @app.before_request
+var middlewarePattern = regexp.MustCompile(`\.(?:use|Use)\s*\((.*)\)|@\w+\.(?:before_request|after_request|middleware)`)
+
+// Matches identifiers, optionally qualified (handlers.ListUsers)
+var routeIdentifierPattern = regexp.MustCompile(`[A-Za-z_$][\w$]*(?:\.[A-Za-z_$][\w$]*)*`)
+
+// Matches the function defined after a Python decorator
+var pyDefPattern = regexp.MustCompile(`^\s*(?:async\s+)?def\s+(\w+)`)
+
+// Matches a Python decorator line, capturing the decorator name
+var pyDecoratorPattern = regexp.MustCompile(`^\s*@([\w.]+)`)
+
+// Identifiers that appear in handler argument lists but never name a handler
+var routeIgnoredIdentifiers = map[string]bool{
+	"func": true, "function": true, "async": true, "await": true, "return": true,
+	"req": true, "res": true, "next": true, "w": true, "r": true, "c": true, "ctx": true,
+	"http": true, "methods": true, "GET": true, "POST": true, "PUT": true, "PATCH": true,
+	"DELETE": true, "true": true, "false": true, "nil": true, "null": true, "None": true,
+	"ResponseWriter": true, "Request": true, "Context": true, "Methods": true,
+}
+
+// File extensions searched for route registrations and handler definitions
+var routeSourceExtensions = map[string]bool{
+	".go": true, ".js": true, ".mjs": true, ".cjs": true, ".ts": true, ".py": true,
+}
+
+// normalizeRouteSegments splits a route into path segments, replacing
+// parameters (:id, {id}, <int:id>, [id]) with a wildcard and dropping any
+// leading HTTP method used by Go 1.22 mux patterns ("GET /users").
+func normalizeRouteSegments(route string) []string {
+	if fields := strings.Fields(route); len(fields) == 2 {
+		route = fields[1]
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(route, "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "{") ||
+			strings.HasPrefix(segment, "<") || strings.HasPrefix(segment, "[") {
+			segment = "*"
+		}
+		segments = append(segments, segment)
+	}
+	return segments
+}
+
+// isRoutePath reports whether a string registered with a router is a route
+// path, "/users" or "GET /users" as in Go mux patterns, rather than the key of
+// some other .get("users") call
+func isRoutePath(registered string) bool {
+	if fields := strings.Fields(registered); len(fields) == 2 && strings.ToUpper(fields[0]) == fields[0] {
+		registered = fields[1]
+	}
+	return strings.HasPrefix(registered, "/")
+}
+
+// routeMatches reports whether a registered route serves the requested route.
+// The whole requested path has to match, though it may start with any of the
+// prefixes routers are mounted below in the project (app.use("/api", router)
+// with router.get("/users")), nested mounts included.
+func routeMatches(registered string, requested string, prefixes []string) bool {
+	if !isRoutePath(registered) {
+		return false
+	}
+	return matchRouteSegments(normalizeRouteSegments(registered), normalizeRouteSegments(requested), prefixes)
+}
+
+// matchRouteSegments matches the registered segments against the requested
+// ones after any number of mount prefixes
+func matchRouteSegments(reg []string, req []string, prefixes []string) bool {
+	if segmentsMatch(reg, req) {
+		return true
+	}
+	for _, prefix := range prefixes {
+		segments := normalizeRouteSegments(prefix)
+		if len(segments) > 0 && len(segments) < len(req) && segmentsMatch(segments, req[:len(segments)]) &&
+			matchRouteSegments(reg, req[len(segments):], prefixes) {
+			return true
+		}
+	}
+	return false
+}
+
+// segmentsMatch reports whether two normalized routes are the same, wildcards
+// matching any segment
+func segmentsMatch(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != "*" && b[i] != "*" && a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// routeIdentifiers extracts the unqualified names of the handlers and
+// middleware referenced in a registration's argument list.
+func routeIdentifiers(args string) []string {
+	var names []string
+	for _, ident := range routeIdentifierPattern.FindAllString(args, -1) {
+		parts := strings.Split(ident, ".")
+		name := parts[len(parts)-1]
+		if !routeIgnoredIdentifiers[name] && len(name) > 1 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// definitionPattern builds a regular expression matching the definition of a
+// function, method, class or variable named name in Go, JavaScript or Python.
+func definitionPattern(name string) *regexp.Regexp {
+	quoted := regexp.QuoteMeta(name)
+	return regexp.MustCompile(`(?m)(?:\b(?:func|function|def|class)\s+(?:\([^)]*\)\s*)?` + quoted + `\b|\b(?:const|let|var)\s+` + quoted + `\s*[=:]|^\s*` + quoted + `\s*(?::=|=)\s*(?:func|function|async|\())`)
+}
+
+// collectRoute selects the files that register the requested route together
+// with the files defining its handlers and any middleware applied alongside it.
+func collectRoute(dir string, route string, opts *options) ([]fileEntry, error) {
+	candidates, err := collectFiles(dir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make(map[string]string)
+	var sources []fileEntry
+	for _, file := range candidates {
+		if !routeSourceExtensions[strings.ToLower(filepath.Ext(file.path))] {
+			continue
+		}
+		content, err := os.ReadFile(file.path)
+		if err != nil {
+			continue
+		}
+		contents[file.path] = string(content)
+		sources = append(sources, file)
+	}
+
+	selected := make(map[string]bool)
+	var files []fileEntry
+	add := func(file fileEntry, reason string) {
+		if selected[file.path] {
+			return
+		}
+		selected[file.path] = true
+		if opts.verbose {
+			opts.logf("Including %s: %s\n", reason, file.relPath)
+		}
+		files = append(files, file)
+	}
+
+	// Collect the prefixes routers are mounted below anywhere in the project
+	var prefixes []string
+	for _, file := range sources {
+		for _, match := range routeMountPattern.FindAllStringSubmatch(contents[file.path], -1) {
+			if prefix := match[1] + match[2]; prefix != "/" {
+				prefixes = append(prefixes, prefix)
+			}
+		}
+	}
+
+	// Find the registrations and the identifiers they reference
+	var identifiers []string
+	for _, file := range sources {
+		lines := strings.Split(contents[file.path], "\n")
+		registered := false
+		for i, line := range lines {
+			match := routeRegistrationPattern.FindStringSubmatch(line)
+			if match == nil || !routeMatches(match[1], route, prefixes) {
+				continue
+			}
+			registered = true
+			identifiers = append(identifiers, routeIdentifiers(match[2])...)
+
+			// Flask style decorators name the handler on the following def
+			// line, with any further decorators acting as middleware
+			if strings.HasPrefix(strings.TrimSpace(line), "@") {
+				for _, next := range lines[i+1:] {
+					if decorator := pyDecoratorPattern.FindStringSubmatch(next); decorator != nil {
+						identifiers = append(identifiers, routeIdentifiers(decorator[1])...)
+						continue
+					}
+					if def := pyDefPattern.FindStringSubmatch(next); def != nil {
+						identifiers = append(identifiers, def[1])
+					}
+					break
+				}
+			}
+		}
+		if !registered {
+			continue
+		}
+		add(file, "route registration")
+
+		// Middleware registered in the same file applies to the route as well
+		for _, line := range lines {
+			if match := middlewarePattern.FindStringSubmatch(line); match != nil {
+				identifiers = append(identifiers, routeIdentifiers(match[1])...)
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no registration found for route %s", route)
+	}
+
+	// Include the files defining each referenced handler or middleware
+	seen := make(map[string]bool)
+	for _, name := range identifiers {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		pattern := definitionPattern(name)
+		for _, file := range sources {
+			if pattern.MatchString(contents[file.path]) {
+				add(file, "definition of "+name)
+			}
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,96 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRouteMatches(t *testing.T) {
+	prefixes := []string{"/api", "/v1"}
+	tests := []struct {
+		registered string
+		requested  string
+		want       bool
+	}{
+		{"/users", "/users", true},
+		{"/users/:id", "/users/42", true},
+		{"GET /users/{id}", "/users/42", true},
+		{"/users/<int:id>", "/users/{id}", true},
+		{"/users", "/api/users", true},
+		{"/users", "/api/v1/users", true},
+		{"/", "/", true},
+		{"/users", "/users/42", false},
+		{"/users", "/admin/users", false},
+		{"/api/users", "/users", false},
+		{"users", "/users", false},
+		{"PATH", "/PATH", false},
+		{"get users", "/users", false},
+	}
+	for _, tt := range tests {
+		if got := routeMatches(tt.registered, tt.requested, prefixes); got != tt.want {
+			t.Errorf("routeMatches(%q, %q) = %v, want %v", tt.registered, tt.requested, got, tt.want)
+		}
+	}
+}
+
+func TestCollectRoute(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"server.go":   "package main\n\nfunc main() {\n\tmux.HandleFunc(\"GET /users/{id}\", getUser)\n}\n",
+		"users.go":    "package main\n\nfunc getUser(w http.ResponseWriter, r *http.Request) {}\n",
+		"app.js":      "app.use(\"/api\", router);\nrouter.get(\"/orders\", listOrders);\n",
+		"orders.js":   "function listOrders(req, res) {}\n",
+		"app.py":      "@app.route(\"/health\")\n@login_required\ndef health():\n    return \"ok\"\n",
+		"auth.py":     "def login_required(f):\n    return f\n",
+		"settings.py": "users = cache.get(\"users\")\npath = os.environ.get(\"PATH\")\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	collect := func(route string) ([]string, error) {
+		opts := DefaultOptions()
+		opts.Route = route
+		collector, err := NewCollector(dir, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := collector.Collect()
+		if err != nil {
+			return nil, err
+		}
+		var paths []string
+		for _, file := range result.Files {
+			paths = append(paths, file.RelPath)
+		}
+		return paths, nil
+	}
+
+	tests := []struct {
+		route string
+		want  []string
+	}{
+		{"/users/42", []string{"./server.go", "./users.go"}},
+		{"/api/orders", []string{"./app.js", "./orders.js"}},
+		{"/health", []string{"./app.py", "./auth.py"}},
+	}
+	for _, tt := range tests {
+		got, err := collect(tt.route)
+		if err != nil {
+			t.Errorf("route %s: %v", tt.route, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("route %s selected %v, want %v", tt.route, got, tt.want)
+		}
+	}
+
+	// Lookups that merely share a word with the route are not registrations
+	for _, route := range []string{"/users", "/PATH"} {
+		if got, err := collect(route); err == nil {
+			t.Errorf("route %s selected %v, want no registration found", route, got)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"sync"
+)
+
+// The reasons files and directories are left out of the output
+const (
+	skipExcluded  = "excluded"
+	skipHidden    = "hidden"
+	skipUntracked = "untracked"
+	skipHgIgnore  = "hgignore"
+	skipTooDeep   = "too deep"
+	skipTooLarge  = "too large"
+	skipBuildTags = "build tags"
+	skipBinary    = "binary"
+	skipGenerated = "generated"
+	skipOwner     = "owner"
+	skipSymlink   = "symlink"
+	skipIgnored   = "clip4llmignore"
+	skipNoMatch   = "no grep match"
+)
+
+// skipCounter counts the files and directories left out by reason, where a
+// skipped directory counts once. It is safe for concurrent use, and a nil
+// skipCounter counts nothing.
+type skipCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// newSkipCounter returns an empty skipCounter
+func newSkipCounter() *skipCounter {
+	return &skipCounter{counts: make(map[string]int)}
+}
+
+// add counts one entry left out for reason
+func (s *skipCounter) add(reason string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[reason]++
+}
+
+// snapshot returns a copy of the counts by reason
+func (s *skipCounter) snapshot() map[string]int {
+	counts := make(map[string]int)
+	if s == nil {
+		return counts
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for reason, n := range s.counts {
+		counts[reason] = n
+	}
+	return counts
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The orders --sort puts the selected files in
+var sortOrders = []string{"path", "size", "mtime", "extension"}
+
+// isSortOrder reports whether order is one of sortOrders
+func isSortOrder(order string) bool {
+	for _, name := range sortOrders {
+		if name == order {
+			return true
+		}
+	}
+	return false
+}
+
+// sortFiles orders files by path, size (smallest first), mtime (most recently
+// modified first) or extension, breaking ties by path with accents folded, and
+// reverses the order when asked. Files that cannot be stated sort as empty and never modified.
+func sortFiles(files []fileEntry, order string, reverse bool) {
+	sizes := make(map[string]int64, len(files))
+	mtimes := make(map[string]int64, len(files))
+	if order == "size" || order == "mtime" {
+		for _, file := range files {
+			if info, err := os.Stat(file.path); err == nil {
+				sizes[file.path] = info.Size()
+				mtimes[file.path] = info.ModTime().UnixNano()
+			}
+		}
+	}
+
+	less := func(a, b fileEntry) bool {
+		switch order {
+		case "size":
+			if sizes[a.path] != sizes[b.path] {
+				return sizes[a.path] < sizes[b.path]
+			}
+		case "mtime":
+			if mtimes[a.path] != mtimes[b.path] {
+				return mtimes[a.path] > mtimes[b.path]
+			}
+		case "extension":
+			extA := strings.ToLower(filepath.Ext(a.relPath))
+			extB := strings.ToLower(filepath.Ext(b.relPath))
+			if extA != extB {
+				return extA < extB
+			}
+		}
+		return comparePaths(filepath.ToSlash(a.relPath), filepath.ToSlash(b.relPath)) < 0
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if reverse {
+			return less(files[j], files[i])
+		}
+		return less(files[i], files[j])
+	})
+}
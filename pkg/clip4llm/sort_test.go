@@ -0,0 +1,56 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSortFiles(t *testing.T) {
+	dir := t.TempDir()
+	var files []fileEntry
+	now := time.Now()
+	for i, f := range []struct {
+		name string
+		size int
+	}{{"b.go", 30}, {"a.md", 10}, {"c/d.go", 20}} {
+		path := filepath.Join(dir, filepath.FromSlash(f.name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", f.size)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// c/d.go is the most recently modified, b.go the oldest
+		mtime := now.Add(time.Duration(i) * time.Hour)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, fileEntry{path: path, relPath: "./" + f.name})
+	}
+
+	cases := []struct {
+		order   string
+		reverse bool
+		want    string
+	}{
+		{"path", false, "./a.md,./b.go,./c/d.go"},
+		{"path", true, "./c/d.go,./b.go,./a.md"},
+		{"size", false, "./a.md,./c/d.go,./b.go"},
+		{"mtime", false, "./c/d.go,./a.md,./b.go"},
+		{"extension", false, "./b.go,./c/d.go,./a.md"},
+	}
+	for _, c := range cases {
+		sorted := append([]fileEntry(nil), files...)
+		sortFiles(sorted, c.order, c.reverse)
+		var got []string
+		for _, file := range sorted {
+			got = append(got, file.relPath)
+		}
+		if strings.Join(got, ",") != c.want {
+			t.Errorf("sortFiles(%s, reverse=%v) = %v, want %s", c.order, c.reverse, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateData is what a --template can refer to
+type templateData struct {
+	Files      string // the rendered output the template wraps
+	Tree       string // directory tree of the included files
+	Date       string // today's date as YYYY-MM-DD
+	FileCount  int
+	TokenCount int // estimated tokens of Files with the --tokenizer
+}
+
+// applyTemplate wraps the output in the Go text/template read from path
+func applyTemplate(path string, output string, files []fileEntry, opts *options) (string, error) {
+	text, err := os.ReadFile(expandHome(path))
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New(path).Parse(string(text))
+	if err != nil {
+		return "", err
+	}
+
+	var paths []string
+	for _, file := range files {
+		paths = append(paths, file.relPath)
+	}
+	data := templateData{
+		Files:      output,
+		Tree:       renderTree(paths, nil, false, nil),
+		Date:       time.Now().Format("2006-01-02"),
+		FileCount:  len(files),
+		TokenCount: opts.tokenizer.estimate(output),
+	}
+
+	var builder strings.Builder
+	if err := tmpl.Execute(&builder, data); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
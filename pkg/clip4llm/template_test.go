@@ -0,0 +1,24 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt.tmpl")
+	if err := os.WriteFile(path, []byte("Review {{.FileCount}} files:\n{{.Tree}}\n{{.Files}}Done."), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	model, _ := lookupTokenizer("cl100k_base")
+	files := []fileEntry{{path: "/src/main.go", relPath: "./main.go"}}
+	got, err := applyTemplate(path, "CODE\n", files, &options{tokenizer: model})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Review 1 files:\n.\n└── main.go\nCODE\nDone."; got != want {
+		t.Errorf("applyTemplate = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Matches a TODO, FIXME or HACK marker inside a comment, capturing the
+// marker, its optional (owner) and the text that follows it
+var todoPattern = regexp.MustCompile(`(?://|#|/\*|<!--|--|;|\*)\s*.*?\b(TODO|FIXME|HACK)\b(\([^)]*\))?[\s:\-]*(.*)`)
+
+// collectTodos returns a file and line referenced list of the TODO, FIXME and
+// HACK comments in the selected files.
+func collectTodos(files []fileEntry) string {
+	var todos []string
+	for _, file := range files {
+		f, err := os.Open(file.path)
+		if err != nil {
+			continue
+		}
+
+		scanner := bufio.NewScanner(f)
+		line := 0
+		for scanner.Scan() {
+			line++
+			match := todoPattern.FindStringSubmatch(scanner.Text())
+			if match == nil {
+				continue
+			}
+			text := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(match[3]), "*/"))
+			text = strings.TrimSpace(strings.TrimSuffix(text, "-->"))
+			todos = append(todos, fmt.Sprintf("%s:%d: %s%s %s", file.relPath, line, match[1], match[2], text))
+		}
+		f.Close()
+	}
+	return strings.Join(todos, "\n")
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Average number of bytes per token, used when only a file size is known
+const charsPerToken = 4
+
+// tokenizerModel approximates the token counts of a tokenizer family by
+// splitting text the way BPE pre-tokenizers do (letters, digits, whitespace,
+// punctuation and non-ASCII runs) and applying per-family merge ratios.
+type tokenizerModel struct {
+	name            string
+	lettersPerToken float64 // letters merged per token within a word
+	digitsPerToken  float64 // digits merged per token within a number
+	punctPerToken   float64 // punctuation characters merged per token
+	spacesPerToken  float64 // whitespace characters merged per token in indentation and blank lines
+	bytesPerToken   float64 // UTF-8 bytes per token for non-ASCII text
+}
+
+// The tokenizer families estimates are reported for, the first is the default
+var tokenizerModels = []*tokenizerModel{
+	{name: "cl100k_base", lettersPerToken: 6.0, digitsPerToken: 3, punctPerToken: 2.5, spacesPerToken: 8, bytesPerToken: 2.0},
+	{name: "o200k_base", lettersPerToken: 6.5, digitsPerToken: 3, punctPerToken: 2.5, spacesPerToken: 8, bytesPerToken: 3.0},
+	{name: "llama", lettersPerToken: 4.5, digitsPerToken: 1, punctPerToken: 1.5, spacesPerToken: 2, bytesPerToken: 1.5},
+}
+
+// lookupTokenizer returns the tokenizer model with the given name
+func lookupTokenizer(name string) (*tokenizerModel, error) {
+	var names []string
+	for _, model := range tokenizerModels {
+		if model.name == name {
+			return model, nil
+		}
+		names = append(names, model.name)
+	}
+	return nil, fmt.Errorf("unknown tokenizer %q (expected one of %s)", name, strings.Join(names, ", "))
+}
+
+// estimate returns the estimated number of tokens text consumes
+func (m *tokenizerModel) estimate(text string) int {
+	tokens := 0.0
+	for i := 0; i < len(text); {
+		c := text[i]
+		j := i + 1
+
+		switch {
+		case isASCIILetter(c):
+			for j < len(text) && isASCIILetter(text[j]) {
+				j++
+			}
+			tokens += math.Ceil(float64(j-i) / m.lettersPerToken)
+
+		case c >= '0' && c <= '9':
+			for j < len(text) && text[j] >= '0' && text[j] <= '9' {
+				j++
+			}
+			tokens += math.Ceil(float64(j-i) / m.digitsPerToken)
+
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			for j < len(text) && (text[j] == ' ' || text[j] == '\t' || text[j] == '\n' || text[j] == '\r') {
+				j++
+			}
+			// A single space is merged into the word or punctuation that follows
+			if j-i == 1 && c == ' ' && j < len(text) && text[j] < 0x80 && !(text[j] >= '0' && text[j] <= '9') {
+				break
+			}
+			tokens += math.Ceil(float64(j-i) / m.spacesPerToken)
+
+		case c >= 0x80:
+			for j < len(text) && text[j] >= 0x80 {
+				j++
+			}
+			tokens += math.Ceil(float64(j-i) / m.bytesPerToken)
+
+		default:
+			for j < len(text) && isASCIIPunct(text[j]) {
+				j++
+			}
+			tokens += math.Ceil(float64(j-i) / m.punctPerToken)
+			// Line breaks directly after punctuation are merged into its token
+			for j < len(text) && (text[j] == '\n' || text[j] == '\r') {
+				j++
+			}
+		}
+
+		i = j
+	}
+	return int(tokens)
+}
+
+// isASCIILetter reports whether c is an ASCII letter
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isASCIIPunct reports whether c is ASCII and not a letter, digit or whitespace
+func isASCIIPunct(c byte) bool {
+	return c < 0x80 && !isASCIILetter(c) && !(c >= '0' && c <= '9') &&
+		c != ' ' && c != '\t' && c != '\n' && c != '\r'
+}
+
+// estimateTokens returns the estimated token count of text for the default tokenizer
+func estimateTokens(text string) int {
+	return tokenizerModels[0].estimate(text)
+}
@@ -0,0 +1,47 @@
+package clip4llm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenizerEstimate(t *testing.T) {
+	model, err := lookupTokenizer("cl100k_base")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"hello", 1},
+		{"hello world", 2},
+		{"12345", 2},
+		{"();\n", 2},
+		{"\n\n", 1},
+	}
+	for _, c := range cases {
+		if got := model.estimate(c.text); got != c.want {
+			t.Errorf("estimate(%q) = %d, want %d", c.text, got, c.want)
+		}
+	}
+}
+
+func TestTokenizerEstimateScalesWithText(t *testing.T) {
+	text := strings.Repeat("func main() {\n\tfmt.Println(\"hello, world\")\n}\n", 100)
+	for _, model := range tokenizerModels {
+		tokens := model.estimate(text)
+		// Source code averages between 2 and 6 bytes per token for all families
+		if tokens < len(text)/6 || tokens > len(text)/2 {
+			t.Errorf("%s estimate %d out of range for %d bytes", model.name, tokens, len(text))
+		}
+	}
+}
+
+func TestLookupTokenizerUnknown(t *testing.T) {
+	if _, err := lookupTokenizer("gpt2"); err == nil {
+		t.Error("expected an error for an unknown tokenizer")
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The phases of a run in the order they are reported
+var tracePhases = []string{"walk", "classify", "read", "format", "deliver"}
+
+// Number of slowest files listed in the trace summary
+const traceSlowestFiles = 10
+
+// Phases timed within another phase, reported indented below it
+var traceNested = map[string]string{"classify": "walk", "read": "format"}
+
+// Tracer records the time spent in each phase of a run and on each file. It is
+// safe for concurrent use, and time spent in parallel is summed, so a nested
+// phase such as classifying files during the walk can exceed its parent. A nil
+// Tracer records nothing.
+type Tracer struct {
+	mu      sync.Mutex
+	started time.Time
+	phases  map[string]time.Duration
+	files   map[string]time.Duration
+}
+
+// NewTracer returns a Tracer whose total time starts now
+func NewTracer() *Tracer {
+	return &Tracer{
+		started: time.Now(),
+		phases:  make(map[string]time.Duration),
+		files:   make(map[string]time.Duration),
+	}
+}
+
+// Begin starts timing phase, attributing the time to path as well when it is
+// not empty, and returns the function that stops the timer. An empty phase
+// only times the file.
+func (t *Tracer) Begin(phase string, path string) func() {
+	if t == nil {
+		return func() {}
+	}
+
+	start := time.Now()
+	return func() {
+		elapsed := time.Since(start)
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if phase != "" {
+			t.phases[phase] += elapsed
+		}
+		if path != "" {
+			t.files[path] += elapsed
+		}
+	}
+}
+
+// Print writes the time spent per phase and the slowest files to w, with
+// their paths relative to dir
+func (t *Tracer) Print(w io.Writer, dir string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fmt.Fprintf(w, "Trace (total %s):\n", roundDuration(time.Since(t.started)))
+	for _, phase := range tracePhases {
+		if _, ok := traceNested[phase]; ok {
+			continue
+		}
+		fmt.Fprintf(w, "\t%-11s %s\n", phase, roundDuration(t.phases[phase]))
+		for _, nested := range tracePhases {
+			if traceNested[nested] == phase {
+				fmt.Fprintf(w, "\t  %-9s %s\n", nested, roundDuration(t.phases[nested]))
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(t.files))
+	for path := range t.files {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if t.files[paths[i]] != t.files[paths[j]] {
+			return t.files[paths[i]] > t.files[paths[j]]
+		}
+		return paths[i] < paths[j]
+	})
+	if len(paths) > traceSlowestFiles {
+		paths = paths[:traceSlowestFiles]
+	}
+	if len(paths) > 0 {
+		fmt.Fprintln(w, "Slowest files:")
+		for _, path := range paths {
+			name := path
+			if rel, err := relativePath(dir, path); err == nil {
+				name = rel
+			}
+			fmt.Fprintf(w, "\t%s %s\n", roundDuration(t.files[path]), name)
+		}
+	}
+}
+
+// roundDuration rounds d to a precision that is readable in the trace
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	}
+	return d.Round(time.Microsecond)
+}
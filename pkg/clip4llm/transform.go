@@ -0,0 +1,133 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+// transformContent applies the enabled content transformations to the content
+// of the file at the relative path before it is emitted.
+func transformContent(path string, content []byte, opts *options) []byte {
+	// Transcode UTF-16 and Latin-1 files to UTF-8 like the rest of the output
+	content, encoding := decodeText(content)
+	if encoding != encodingUTF8 && encoding != encodingBinary && opts.verbose {
+		opts.logf("Transcoded %s from %s to UTF-8\n", path, encoding)
+	}
+
+	// Replace changed files with their patch, new files keep their full
+	// content. The patch skips the transformations of whole files, but not
+	// the redaction of the secrets its lines may hold.
+	if opts.diffMode {
+		patch, err := gitFileDiff(opts.dir, opts.diffRef, path)
+		if err != nil {
+			if opts.verbose {
+				opts.logf("Keeping full content, failed to diff %s: %v\n", path, err)
+			}
+		} else if patch != "" {
+			return redactContent(path, []byte(patch), opts)
+		}
+	}
+
+	// Keep only the lines around the --grep matches, numbered as in the file
+	if opts.grep != nil && opts.grepContext > 0 {
+		if snippets, ok := grepSnippets(content, opts.grep, opts.grepContext); ok {
+			content = snippets
+		}
+	}
+
+	// Render compiled protobuf descriptor sets as their schema
+	if opts.decodeDescriptors && isDescriptorFile(path) {
+		if schema, err := decodeDescriptorSet(content); err == nil {
+			content = schema
+		}
+	}
+
+	// Reduce email messages to their headers and text bodies
+	if opts.extractEmail && isEmailFile(path) {
+		text, err := extractEmail(content)
+		if err != nil {
+			if opts.verbose {
+				opts.logf("Keeping full content, failed to parse %s: %v\n", path, err)
+			}
+		} else {
+			content = text
+		}
+	}
+
+	// Drop the metadata block static site generators put atop markdown
+	if opts.stripFrontMatter && isFrontMatterFile(path) {
+		if stripped, ok := stripFrontMatter(content, opts.keepFrontMatter); ok {
+			if opts.verbose {
+				opts.logf("Stripped front matter from %s, %d bytes saved\n", path, len(content)-len(stripped))
+			}
+			content = stripped
+		}
+	}
+
+	// Describe referenced images instead of leaving dangling links
+	if opts.imagePlaceholders && isImageReferenceFile(path) {
+		replaced, count := replaceImages(path, content)
+		if count > 0 && opts.verbose {
+			opts.logf("Replaced %d images in %s with placeholders\n", count, path)
+		}
+		content = replaced
+	}
+
+	// Reduce source files to their exported declarations and doc comments
+	if opts.declarationsOnly {
+		switch opts.languageFor(path, content) {
+		case "go":
+			skeleton, err := goDeclarationSkeleton(content)
+			if err != nil {
+				if opts.verbose {
+					opts.logf("Keeping full content, failed to parse %s: %v\n", path, err)
+				}
+				break
+			}
+			content = skeleton
+		case "python":
+			content = pyDeclarationSkeleton(content)
+		}
+	}
+
+	// Remove comments from the recognized source languages
+	if opts.stripComments {
+		if stripped, ok := stripComments(opts.languageFor(path, content), content); ok {
+			if opts.verbose {
+				opts.logf("Stripped comments from %s, %d bytes saved\n", path, len(content)-len(stripped))
+			}
+			content = stripped
+		}
+	}
+
+	// Squeeze out the whitespace that costs tokens without carrying meaning
+	if opts.compact {
+		content = compactWhitespace(opts.languageFor(path, content), content, opts.compactIndent)
+	}
+
+	// Reduce localization files to their message keys
+	if opts.i18nMode == "keys" {
+		if _, ok := i18nLanguage(path, opts.i18nDefault); ok {
+			keys, err := i18nKeys(path, content)
+			if err != nil {
+				if opts.verbose {
+					opts.logf("Keeping full content, failed to parse %s: %v\n", path, err)
+				}
+			} else {
+				content = keys
+			}
+		}
+	}
+
+	return redactContent(path, content, opts)
+}
+
+// redactContent replaces the secrets in the content of the file at path with
+// placeholders when redacting, so they never reach the clipboard
+func redactContent(path string, content []byte, opts *options) []byte {
+	if !opts.redactSecrets {
+		return content
+	}
+	redacted, count := redactSecrets(content, opts.redactRules)
+	if count > 0 && opts.verbose {
+		opts.logf("Redacted %d secrets in %s\n", count, path)
+	}
+	return redacted
+}
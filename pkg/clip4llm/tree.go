@@ -0,0 +1,188 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// treeNode is a directory or file in the rendered tree
+type treeNode struct {
+	name     string
+	dir      bool   // a directory, even without children
+	label    string // shown before the name, such as the kind of file
+	note     string // shown after the name, such as a filtered file count
+	size     int    // bytes in the output of the file, or of everything below a directory
+	children map[string]*treeNode
+}
+
+// renderTree draws an ASCII tree, in the style of the tree command, of the
+// given slash-separated relative file paths. The directories in emptyDirs are
+// drawn too, annotated with the number of files they hold that were left out.
+// With labels every file is marked with the emoji of its kind. With sizes,
+// keyed by path, every entry is annotated with its share of their total.
+func renderTree(paths []string, emptyDirs map[string]int, labels bool, sizes map[string]int) string {
+	root := &treeNode{name: ".", children: make(map[string]*treeNode)}
+	// The size of a file counts toward every directory above it too
+	add := func(p string, size int) *treeNode {
+		p = strings.TrimPrefix(path.Clean("/"+strings.TrimPrefix(p, "./")), "/")
+		if p == "" {
+			return nil
+		}
+		node := root
+		for _, part := range strings.Split(p, "/") {
+			child, ok := node.children[part]
+			if !ok {
+				child = &treeNode{name: part, children: make(map[string]*treeNode)}
+				node.children[part] = child
+			}
+			node.dir = true
+			node = child
+			node.size += size
+		}
+		return node
+	}
+
+	total := 0
+	for _, p := range paths {
+		total += sizes[p]
+		if node := add(p, sizes[p]); node != nil && labels {
+			if label, ok := fileKindLabels[fileKind(p)]; ok {
+				node.label = label + " "
+			}
+		}
+	}
+	if sizes != nil {
+		annotateSizes(root, total)
+	}
+	for p, filtered := range emptyDirs {
+		node := add(p, 0)
+		if node == nil {
+			continue
+		}
+		node.dir = true
+		switch filtered {
+		case 0:
+			node.note = " (empty)"
+		case 1:
+			node.note = " (1 file filtered out)"
+		default:
+			node.note = fmt.Sprintf(" (%d files filtered out)", filtered)
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString(".\n")
+	writeTreeChildren(&builder, root, "")
+	return strings.TrimRight(builder.String(), "\n")
+}
+
+// Width of the bar drawn for the share of the output an entry takes
+const sizeBarWidth = 10
+
+// annotateSizes notes the share of total every entry below node takes, as a
+// bar and a percentage so the largest parts stand out
+func annotateSizes(node *treeNode, total int) {
+	for _, child := range node.children {
+		share := 0.0
+		if total > 0 {
+			share = float64(child.size) / float64(total)
+		}
+		filled := int(share*sizeBarWidth + 0.5)
+		child.note += fmt.Sprintf("  %s%s %.1f%%", strings.Repeat("█", filled), strings.Repeat("░", sizeBarWidth-filled), share*100)
+		annotateSizes(child, total)
+	}
+}
+
+// SizeTree draws the directory tree of the files in a report with the share
+// of the output every file and directory takes
+func SizeTree(files []ReportFile) string {
+	paths := make([]string, 0, len(files))
+	sizes := make(map[string]int, len(files))
+	for _, file := range files {
+		paths = append(paths, file.Path)
+		sizes[file.Path] += file.Bytes
+	}
+	return renderTree(paths, nil, false, sizes)
+}
+
+// writeTreeChildren writes the children of node sorted with directories first
+func writeTreeChildren(builder *strings.Builder, node *treeNode, prefix string) {
+	children := make([]*treeNode, 0, len(node.children))
+	for _, child := range node.children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		iDir, jDir := children[i].dir, children[j].dir
+		if iDir != jDir {
+			return iDir
+		}
+		return comparePaths(children[i].name, children[j].name) < 0
+	})
+
+	for i, child := range children {
+		connector, indent := "├── ", "│   "
+		if i == len(children)-1 {
+			connector, indent = "└── ", "    "
+		}
+		name := child.label + child.name
+		if child.dir {
+			name += "/"
+		}
+		name += child.note
+		builder.WriteString(prefix + connector + name + "\n")
+		writeTreeChildren(builder, child, prefix+indent)
+	}
+}
+
+// emptyDirectories walks dir and returns the slash-separated relative paths of
+// the directories holding none of the selected files, with the number of files
+// below each. Only the topmost of nested such directories is returned, and
+// the version control metadata is left out.
+func emptyDirectories(dir string, files []fileEntry) (map[string]int, error) {
+	// Every directory on the way to a selected file is shown anyway
+	populated := make(map[string]bool)
+	for _, file := range files {
+		rel := strings.TrimPrefix(filepath.ToSlash(file.relPath), "./")
+		for p := path.Dir(rel); p != "." && !populated[p]; p = path.Dir(p) {
+			populated[p] = true
+		}
+	}
+
+	empty := make(map[string]int)
+	var current string // the empty directory being counted, if any
+	err := walkTree(dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if current != "" && !strings.HasPrefix(rel, current+"/") {
+			current = ""
+		}
+		if entry.IsDir() {
+			if vcsMetadataDirs[entry.Name()] {
+				return filepath.SkipDir
+			}
+			if current == "" && !populated[rel] {
+				current = rel
+				empty[rel] = 0
+			}
+			return nil
+		}
+		if current != "" {
+			empty[current]++
+		}
+		return nil
+	})
+	return empty, err
+}
@@ -0,0 +1,64 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderTreeEmptyDirectories(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"src/main.go", "build/x/out.o", "build/y.o"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "empty"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := []fileEntry{{path: filepath.Join(dir, "src", "main.go"), relPath: "./src/main.go"}}
+	emptyDirs, err := emptyDirectories(dir, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ".\n" +
+		"├── build/ (2 files filtered out)\n" +
+		"├── empty/ (empty)\n" +
+		"└── src/\n" +
+		"    └── main.go"
+	if got := renderTree([]string{"./src/main.go"}, emptyDirs, false, nil); got != want {
+		t.Errorf("renderTree =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderTreeLabels(t *testing.T) {
+	paths := []string{"./main.go", "./main_test.go", "./README.md", "./go.mod", "./.gitignore", "./data.bin"}
+	want := ".\n" +
+		"├── ⚙️ .gitignore\n" +
+		"├── 📄 README.md\n" +
+		"├── data.bin\n" +
+		"├── ⚙️ go.mod\n" +
+		"├── 🧩 main.go\n" +
+		"└── 🧪 main_test.go"
+	if got := renderTree(paths, nil, true, nil); got != want {
+		t.Errorf("renderTree =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSizeTree(t *testing.T) {
+	files := []ReportFile{{Path: "./main.go", Bytes: 100}, {Path: "./pkg/a.go", Bytes: 300}, {Path: "./pkg/b.go", Bytes: 600}}
+	want := ".\n" +
+		"├── pkg/  █████████░ 90.0%\n" +
+		"│   ├── a.go  ███░░░░░░░ 30.0%\n" +
+		"│   └── b.go  ██████░░░░ 60.0%\n" +
+		"└── main.go  █░░░░░░░░░ 10.0%"
+	if got := SizeTree(files); got != want {
+		t.Errorf("SizeTree =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// truncation limits how much of a file over --max-size is included, by size
+// or by whole lines
+type truncation struct {
+	bytes int // the size kept, 0 when limited by lines
+	lines int // the number of lines kept, 0 when limited by size
+}
+
+// parseTruncation parses a limit such as 8kb or 200lines
+func parseTruncation(value string) (*truncation, error) {
+	text := strings.ToLower(strings.TrimSpace(value))
+	for _, suffix := range []string{"lines", "line", "l"} {
+		if number, ok := strings.CutSuffix(text, suffix); ok {
+			lines, err := strconv.Atoi(strings.TrimSpace(number))
+			if err != nil || lines <= 0 {
+				break
+			}
+			return &truncation{lines: lines}, nil
+		}
+	}
+
+	size, err := ParseByteSize(text)
+	if err != nil || size <= 0 {
+		return nil, fmt.Errorf("invalid --truncate %q (expected a size such as 8kb or a line count such as 200lines)", value)
+	}
+	return &truncation{bytes: size}, nil
+}
+
+// readFileContent reads a selected file, cutting files over --max-size short
+// when truncating.
+func readFileContent(path string, opts *options) ([]byte, error) {
+	if opts.truncate != nil {
+		if info, err := os.Stat(path); err == nil && info.Size() > int64(opts.maxSizeFor(path))*1024 {
+			return readTruncated(path, opts.truncate)
+		}
+	}
+	return os.ReadFile(path)
+}
+
+// readTruncated reads the start of the file up to the limit, ending on a
+// whole line where possible, followed by a marker with the number of lines
+// left out. The rest of the file is only scanned to count its lines.
+func readTruncated(path string, limit *truncation) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var head, cut []byte
+	if limit.lines > 0 {
+		for i := 0; i < limit.lines; i++ {
+			line, err := reader.ReadBytes('\n')
+			head = append(head, line...)
+			if err == io.EOF {
+				return head, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		head = make([]byte, limit.bytes)
+		n, err := io.ReadFull(reader, head)
+		head = head[:n]
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return head, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Give the cut off part of the last line back to the omitted rest
+		end := len(head)
+		if i := bytes.LastIndexByte(head, '\n'); i >= 0 {
+			end = i + 1
+		} else {
+			for end > 0 && !utf8.Valid(head[:end]) {
+				end--
+			}
+		}
+		head, cut = head[:end], head[end:]
+	}
+
+	// Count the lines in the rest, including the part cut from the head and
+	// a last line without a line break
+	omitted := 0
+	remaining := false
+	var last byte
+	rest := io.MultiReader(bytes.NewReader(cut), reader)
+	buffer := make([]byte, 64*1024)
+	for {
+		n, err := rest.Read(buffer)
+		if n > 0 {
+			omitted += bytes.Count(buffer[:n], []byte{'\n'})
+			last = buffer[n-1]
+			remaining = true
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !remaining {
+		return head, nil
+	}
+	if last != '\n' {
+		omitted++
+	}
+	if len(head) > 0 && head[len(head)-1] != '\n' {
+		head = append(head, '\n')
+	}
+	unit := "lines"
+	if omitted == 1 {
+		unit = "line"
+	}
+	return append(head, fmt.Sprintf("... [truncated, %d %s omitted] ...\n", omitted, unit)...), nil
+}
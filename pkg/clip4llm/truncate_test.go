@@ -0,0 +1,69 @@
+package clip4llm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseTruncation(t *testing.T) {
+	cases := []struct {
+		value string
+		want  truncation
+	}{
+		{"8kb", truncation{bytes: 8192}},
+		{"200lines", truncation{lines: 200}},
+		{"50 l", truncation{lines: 50}},
+	}
+	for _, c := range cases {
+		got, err := parseTruncation(c.value)
+		if err != nil || *got != c.want {
+			t.Errorf("parseTruncation(%q) = %+v, %v, want %+v", c.value, got, err, c.want)
+		}
+	}
+	for _, value := range []string{"", "0lines", "-1kb", "many"} {
+		if _, err := parseTruncation(value); err == nil {
+			t.Errorf("parseTruncation(%q) should fail", value)
+		}
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.txt")
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree\nfour\nfive"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		limit truncation
+		want  string
+	}{
+		{truncation{lines: 2}, "one\ntwo\n... [truncated, 3 lines omitted] ...\n"},
+		{truncation{lines: 4}, "one\ntwo\nthree\nfour\n... [truncated, 1 line omitted] ...\n"},
+		{truncation{lines: 10}, "one\ntwo\nthree\nfour\nfive"},
+		{truncation{bytes: 10}, "one\ntwo\n... [truncated, 3 lines omitted] ...\n"},
+		{truncation{bytes: 100}, "one\ntwo\nthree\nfour\nfive"},
+	}
+	for _, c := range cases {
+		got, err := readTruncated(path, &c.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != c.want {
+			t.Errorf("readTruncated(%+v) = %q, want %q", c.limit, got, c.want)
+		}
+	}
+
+	// A single long line is cut on a character boundary
+	if err := os.WriteFile(path, []byte(strings.Repeat("é", 10)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readTruncated(path, &truncation{bytes: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "éé\n... [truncated, 1 line omitted] ...\n"; string(got) != want {
+		t.Errorf("readTruncated = %q, want %q", got, want)
+	}
+}
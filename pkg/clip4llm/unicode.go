@@ -0,0 +1,41 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// normalizePath returns path in the composed NFC form most systems write
+// names in. macOS hands out some names decomposed (NFD), where é is an e
+// followed by a combining accent, which would neither match a pattern typed
+// as é nor sort next to it.
+func normalizePath(path string) string {
+	return norm.NFC.String(path)
+}
+
+// pathSortKey returns path with the accents of its letters removed, so é
+// sorts as e next to its ASCII neighbours rather than after every ASCII
+// name. Other characters, ASCII and CJK alike, keep their code point order.
+func pathSortKey(path string) string {
+	var key strings.Builder
+	for _, r := range norm.NFD.String(path) {
+		if !unicode.Is(unicode.Mn, r) {
+			key.WriteRune(r)
+		}
+	}
+	return key.String()
+}
+
+// comparePaths orders the paths a and b by their sort keys and then by their
+// NFC form, giving the same order on every platform whichever normalization
+// the filesystem reported the names in. It returns -1, 0 or 1.
+func comparePaths(a string, b string) int {
+	if c := strings.Compare(pathSortKey(a), pathSortKey(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(normalizePath(a), normalizePath(b))
+}
@@ -0,0 +1,27 @@
+package clip4llm
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestUnicodePaths(t *testing.T) {
+	nfd := "re\u0301sume\u0301.md" // résumé.md as macOS may report it
+	if matched, err := matchesAnyPatternWithPath(nfd, "./docs/"+nfd, []string{"r\u00e9sum\u00e9.md"}); err != nil || !matched {
+		t.Errorf("NFC pattern should match the NFD name, got %v, %v", matched, err)
+	}
+	if matched, _ := matchesAnyPatternWithPath("r\u00e9sum\u00e9.md", "./docs/r\u00e9sum\u00e9.md", []string{"docs/" + nfd}); !matched {
+		t.Error("NFD pattern should match the NFC path")
+	}
+
+	paths := []string{"zeta.md", "\u4e2d\u6587.md", "e\u0301cole.md", "Readme.md", "ecrire.md", "\u00e9tude.md", "apple.md"}
+	sort.Slice(paths, func(i, j int) bool { return comparePaths(paths[i], paths[j]) < 0 })
+	want := []string{"Readme.md", "apple.md", "e\u0301cole.md", "ecrire.md", "\u00e9tude.md", "zeta.md", "\u4e2d\u6587.md"}
+	if strings.Join(paths, " ") != strings.Join(want, " ") {
+		t.Errorf("sorted = %q, want %q", paths, want)
+	}
+	if relPath, _ := relativePath("/src", "/src/"+nfd); relPath != "./r\u00e9sum\u00e9.md" {
+		t.Errorf("relativePath = %q, want the NFC name", relPath)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Metadata directories of version control systems, never part of the output
+// even when hidden files are included
+var vcsMetadataDirs = map[string]bool{".git": true, ".hg": true, ".svn": true}
+
+// hgIgnore holds the patterns of a Mercurial repository's .hgignore file
+type hgIgnore struct {
+	root     string // the repository root the patterns are relative to
+	patterns []*regexp.Regexp
+}
+
+// loadHgIgnore finds the Mercurial repository holding dir and returns its
+// .hgignore patterns, or nil when dir is not in one or it has no such file.
+// log receives the verbose messages, nil for none.
+func loadHgIgnore(dir string, log io.Writer) (*hgIgnore, error) {
+	root := dir
+	for {
+		if info, err := os.Stat(filepath.Join(root, ".hg")); err == nil && info.IsDir() {
+			break
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			return nil, nil
+		}
+		root = parent
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, ".hgignore"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ignore := &hgIgnore{root: root, patterns: parseHgIgnore(string(content), log)}
+	if log != nil {
+		fmt.Fprintf(log, "Loaded %d patterns from %s\n", len(ignore.patterns), filepath.Join(root, ".hgignore"))
+	}
+	return ignore, nil
+}
+
+// parseHgIgnore compiles the patterns of a .hgignore file. Like Mercurial it
+// reads regular expressions until a "syntax: glob" line, and a pattern may
+// pick its own syntax with a prefix such as glob: or re:. Patterns Go cannot
+// compile, such as those with lookarounds, are skipped.
+func parseHgIgnore(content string, log io.Writer) []*regexp.Regexp {
+	var patterns []*regexp.Regexp
+	syntax := "regexp"
+	for _, line := range strings.Split(content, "\n") {
+		// A # starts a comment unless escaped
+		for i := 0; i < len(line); i++ {
+			if line[i] == '#' && (i == 0 || line[i-1] != '\\') {
+				line = line[:i]
+				break
+			}
+		}
+		line = strings.TrimSpace(strings.ReplaceAll(line, `\#`, "#"))
+		if line == "" {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "syntax:"); ok {
+			syntax = strings.TrimSpace(rest)
+			continue
+		}
+
+		kind, pattern := syntax, line
+		if prefix, rest, ok := strings.Cut(line, ":"); ok {
+			switch prefix {
+			case "re", "regexp", "glob", "relglob", "rootglob", "path", "relre":
+				kind, pattern = prefix, rest
+			}
+		}
+
+		var expr string
+		switch kind {
+		case "re", "regexp", "relre":
+			expr = pattern
+		case "glob", "relglob":
+			expr = `(?:^|/)` + hgGlobRegexp(pattern) + `(?:/|$)`
+		case "rootglob":
+			expr = `^` + hgGlobRegexp(pattern) + `(?:/|$)`
+		case "path":
+			expr = `^` + regexp.QuoteMeta(strings.TrimSuffix(pattern, "/")) + `(?:/|$)`
+		default:
+			if log != nil {
+				fmt.Fprintf(log, "Ignoring .hgignore pattern with unknown syntax %s: %s\n", kind, pattern)
+			}
+			continue
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			if log != nil {
+				fmt.Fprintf(log, "Ignoring .hgignore pattern Go cannot compile: %s (%v)\n", pattern, err)
+			}
+			continue
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns
+}
+
+// hgGlobRegexp translates a Mercurial glob into a regular expression, where
+// ** crosses directories and * and ? do not
+func hgGlobRegexp(glob string) string {
+	var expr strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				expr.WriteString(`.*`)
+				i++
+			} else {
+				expr.WriteString(`[^/]*`)
+			}
+		case '?':
+			expr.WriteString(`[^/]`)
+		case '[':
+			if end := strings.IndexByte(glob[i+1:], ']'); end >= 0 {
+				class := glob[i+1 : i+1+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				expr.WriteString("[" + class + "]")
+				i += end + 1
+			} else {
+				expr.WriteString(`\[`)
+			}
+		case '{':
+			if end := strings.IndexByte(glob[i+1:], '}'); end >= 0 {
+				var alternatives []string
+				for _, alternative := range strings.Split(glob[i+1:i+1+end], ",") {
+					alternatives = append(alternatives, hgGlobRegexp(alternative))
+				}
+				expr.WriteString("(?:" + strings.Join(alternatives, "|") + ")")
+				i += end + 1
+			} else {
+				expr.WriteString(`\{`)
+			}
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return expr.String()
+}
+
+// ignores reports whether the file or directory at path is ignored. A nil
+// hgIgnore ignores nothing.
+func (h *hgIgnore) ignores(path string) bool {
+	if h == nil {
+		return false
+	}
+	rel, err := filepath.Rel(h.root, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, pattern := range h.patterns {
+		if pattern.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
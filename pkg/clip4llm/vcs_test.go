@@ -0,0 +1,43 @@
+package clip4llm
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestHgIgnore(t *testing.T) {
+	root := t.TempDir()
+	ignore := &hgIgnore{root: root, patterns: parseHgIgnore(`# build output
+\.pyc$
+^dist/
+syntax: glob
+*.o
+node_modules
+logs/**.log
+rootglob:tmp
+re:(?!x)lookahead
+path:docs/private
+`, nil)}
+
+	cases := map[string]bool{
+		"app/main.pyc":          true,
+		"dist/bundle.js":        true,
+		"src/dist/keep.js":      false,
+		"lib/util.o":            true,
+		"web/node_modules":      true,
+		"web/node_modules/a.js": true,
+		"logs/2024/app.log":     true,
+		"tmp":                   true,
+		"src/tmp":               false,
+		"docs/private/notes.md": true,
+		"docs/privateer.md":     false,
+		"src/main.go":           false,
+		"lookahead":             false,
+		"../outside/main.pyc":   false,
+	}
+	for rel, want := range cases {
+		if got := ignore.ignores(filepath.Join(root, filepath.FromSlash(rel))); got != want {
+			t.Errorf("ignores(%s) = %v, want %v", rel, got, want)
+		}
+	}
+}
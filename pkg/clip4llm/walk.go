@@ -0,0 +1,94 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package clip4llm
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Directory levels walked below a root before the walk stops descending and
+// warns, unless set otherwise. Real projects stay far below it, pathological
+// trees such as recursive generated directories do not.
+const defaultDepthGuard = 256
+
+// walkFrame is a directory being walked, with the entries still to visit
+type walkFrame struct {
+	path    string
+	entries []fs.DirEntry
+	next    int
+}
+
+// walkTree walks the tree rooted at root like filepath.WalkDir, calling fn
+// for every entry in lexical order with the same SkipDir and SkipAll
+// semantics, but keeps the directories being walked on an explicit stack
+// instead of recursing, so the depth of a tree never exhausts the stack.
+func walkTree(root string, fn fs.WalkDirFunc) error {
+	info, err := os.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkStack(root, fs.FileInfoToDirEntry(info), fn)
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walkStack visits the root entry and, once fn lets the walk into it,
+// everything below it
+func walkStack(root string, entry fs.DirEntry, fn fs.WalkDirFunc) error {
+	if err := fn(root, entry, nil); err != nil || !entry.IsDir() {
+		return err
+	}
+	stack, err := pushDir(nil, root, entry, fn)
+	if err != nil {
+		return err
+	}
+
+	for len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		if top.next >= len(top.entries) {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		entry := top.entries[top.next]
+		top.next++
+		path := filepath.Join(top.path, entry.Name())
+
+		if err := fn(path, entry, nil); err != nil {
+			if err == filepath.SkipDir {
+				// A file skipping its directory leaves out the rest of it
+				if !entry.IsDir() {
+					stack = stack[:len(stack)-1]
+				}
+				continue
+			}
+			return err
+		}
+		if entry.IsDir() {
+			if stack, err = pushDir(stack, path, entry, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pushDir reads the directory at path onto the stack. A directory that
+// cannot be read is reported to fn a second time with the error, as
+// filepath.WalkDir does, and stays off the stack when fn skips it.
+func pushDir(stack []walkFrame, path string, entry fs.DirEntry, fn fs.WalkDirFunc) ([]walkFrame, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if err = fn(path, entry, err); err != nil {
+			if err == filepath.SkipDir && entry.IsDir() {
+				return stack, nil
+			}
+			return stack, err
+		}
+	}
+	return append(stack, walkFrame{path: path, entries: entries}), nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestStartPprof(t *testing.T) {
+	listener, err := startPprof("localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	resp, err := http.Get("http://" + listener.Addr().String() + "/debug/pprof/cmdline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /debug/pprof/cmdline = %s", resp.Status)
+	}
+
+	// Only the loopback interface may serve the profiles
+	for _, addr := range []string{"0.0.0.0:0", ":0x", "example.com:6060", "192.168.1.10:6060"} {
+		if listener, err := startPprof(addr); err == nil {
+			listener.Close()
+			t.Errorf("startPprof(%q) listened on %s", addr, listener.Addr())
+		}
+	}
+}
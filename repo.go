@@ -0,0 +1,67 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// parseRepoSpec splits a --repo value such as github.com/org/name@v1.2.0 into
+// the URL to clone and the ref to check out, empty for the default branch
+func parseRepoSpec(spec string) (string, string, error) {
+	location := strings.TrimPrefix(strings.TrimPrefix(spec, "https://"), "http://")
+	location, ref, _ := strings.Cut(location, "@")
+	location = strings.TrimSuffix(strings.TrimSuffix(location, "/"), ".git")
+	parts := strings.Split(location, "/")
+	if len(parts) != 3 || !strings.Contains(parts[0], ".") || parts[1] == "" || parts[2] == "" {
+		return "", "", fmt.Errorf("invalid --repo %q (expected host/owner/name[@ref], such as github.com/org/name@main)", spec)
+	}
+	// git would take a ref starting with a dash for one of its options
+	if strings.HasPrefix(ref, "-") {
+		return "", "", fmt.Errorf("invalid --repo ref %q (expected a branch, tag or commit)", ref)
+	}
+	return "https://" + location + ".git", ref, nil
+}
+
+// cloneRepo fetches the single commit at ref (a branch, tag or commit, empty
+// for the default branch) of the repository at url into a new temporary
+// directory and returns it. The caller removes the directory.
+func cloneRepo(url, ref string, verbose bool) (string, error) {
+	dir, err := os.MkdirTemp("", "clip4llm-repo-")
+	if err != nil {
+		return "", err
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if verbose {
+		fmt.Printf("Fetching %s at %s\n", url, ref)
+	}
+
+	// A shallow fetch works for commits as well as branches and tags
+	steps := [][]string{
+		{"init", "--quiet"},
+		{"fetch", "--quiet", "--depth", "1", "--", url, ref},
+		{"checkout", "--quiet", "FETCH_HEAD"},
+	}
+	for _, args := range steps {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		// Fail instead of waiting on a credential prompt for private repositories
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			os.RemoveAll(dir)
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", fmt.Errorf("git %s failed: %s", args[0], msg)
+			}
+			return "", fmt.Errorf("git %s failed: %v", args[0], err)
+		}
+	}
+	return dir, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRepoSpec(t *testing.T) {
+	cases := map[string][2]string{
+		"github.com/org/name":                {"https://github.com/org/name.git", ""},
+		"https://github.com/org/name.git@v1": {"https://github.com/org/name.git", "v1"},
+		"gitlab.com/org/name/@abc123":        {"https://gitlab.com/org/name.git", "abc123"},
+	}
+	for spec, want := range cases {
+		url, ref, err := parseRepoSpec(spec)
+		if err != nil || url != want[0] || ref != want[1] {
+			t.Errorf("parseRepoSpec(%q) = %q, %q, %v, want %q, %q", spec, url, ref, err, want[0], want[1])
+		}
+	}
+	for _, spec := range []string{"org/name", "github.com/org", "github.com/org/name/tree/main", "github.com/org/name@--upload-pack=touch /tmp/pwned"} {
+		if _, _, err := parseRepoSpec(spec); err == nil {
+			t.Errorf("parseRepoSpec(%q) should fail", spec)
+		}
+	}
+}
+
+func TestCloneRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	source := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		cmd.Dir = source
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "--quiet")
+	git("commit", "--quiet", "--allow-empty", "-m", "first")
+	git("tag", "v1")
+	if err := os.WriteFile(filepath.Join(source, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "main.go")
+	git("commit", "--quiet", "-m", "second")
+
+	head, err := cloneRepo(source, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(head)
+	if _, err := os.Stat(filepath.Join(head, "main.go")); err != nil {
+		t.Errorf("default branch clone is missing main.go: %v", err)
+	}
+
+	tagged, err := cloneRepo(source, "v1", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tagged)
+	if _, err := os.Stat(filepath.Join(tagged, "main.go")); !os.IsNotExist(err) {
+		t.Errorf("v1 clone should not have main.go, got %v", err)
+	}
+}
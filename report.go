@@ -0,0 +1,32 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/UnitVectorY-Labs/clip4llm/pkg/clip4llm"
+)
+
+// runReportCommand handles "clip4llm report validate <file>" and "clip4llm
+// report schema".
+func runReportCommand(args []string) error {
+	if len(args) == 1 && args[0] == "schema" {
+		fmt.Print(clip4llm.ReportSchema)
+		return nil
+	}
+	if len(args) != 2 || args[0] != "validate" {
+		return fmt.Errorf("usage: clip4llm report validate <file> | clip4llm report schema")
+	}
+
+	content, err := os.ReadFile(args[1])
+	if err != nil {
+		return err
+	}
+	if err := clip4llm.ValidateReport(content); err != nil {
+		return fmt.Errorf("%s is not a valid run report: %v", args[1], err)
+	}
+	fmt.Printf("%s is a valid run report (schema version %d).\n", args[1], clip4llm.ReportSchemaVersion)
+	return nil
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/UnitVectorY-Labs/clip4llm/pkg/clip4llm"
+)
+
+// Number of the largest files listed in the summary
+const statsLargestFiles = 5
+
+// Reading speed in words per minute the reading time of documents assumes
+const wordsPerMinute = 200
+
+// runStats is the summary printed by --stats and --stats-json
+type runStats struct {
+	Files     int             `json:"files"`
+	Skipped   map[string]int  `json:"skipped"`
+	Bytes     int             `json:"bytes"`
+	Tokens    int             `json:"tokens"`
+	Tokenizer string          `json:"tokenizer"`
+	Largest   []largestFile   `json:"largest"`
+	Documents []documentStats `json:"documents,omitempty"`
+	SizeTree  string          `json:"-"` // the --tree heatmap, for reading only
+}
+
+// largestFile is one of the largest files with its last modification
+type largestFile struct {
+	clip4llm.ReportFile
+	Modified *time.Time `json:"modified,omitempty"` // nil when the file cannot be found
+}
+
+// documentStats is the length of one document in a documentation heavy run
+type documentStats struct {
+	Path           string `json:"path"`
+	Words          int    `json:"words"`
+	Tokens         int    `json:"tokens"`
+	ReadingMinutes int    `json:"reading_minutes"`
+}
+
+// documentsStats returns the word count, token estimate and reading time of
+// every selected document when documents make up most of the files, nil
+// otherwise. content returns a file as the output includes it.
+func documentsStats(files []clip4llm.File, report clip4llm.Report, content func(clip4llm.File) ([]byte, error)) []documentStats {
+	tokens := make(map[string]int, len(report.Files))
+	for _, file := range report.Files {
+		tokens[file.Path] = file.Tokens
+	}
+
+	var docs []documentStats
+	for _, file := range files {
+		if !clip4llm.IsDocument(file.RelPath) {
+			continue
+		}
+		text, err := content(file)
+		if err != nil {
+			continue
+		}
+		words := len(strings.Fields(string(text)))
+		docs = append(docs, documentStats{
+			Path:           file.RelPath,
+			Words:          words,
+			Tokens:         tokens[file.RelPath],
+			ReadingMinutes: (words + wordsPerMinute - 1) / wordsPerMinute,
+		})
+	}
+	if len(docs)*2 <= len(files) {
+		return nil
+	}
+	return docs
+}
+
+// buildStats summarizes the run described by report, whose walk left out the
+// skipped files by reason. The largest files are looked up in dir for their
+// modification time.
+func buildStats(report clip4llm.Report, skipped map[string]int, dir string) runStats {
+	sorted := append([]clip4llm.ReportFile(nil), report.Files...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Bytes > sorted[j].Bytes
+	})
+	if len(sorted) > statsLargestFiles {
+		sorted = sorted[:statsLargestFiles]
+	}
+	largest := make([]largestFile, len(sorted))
+	for i, file := range sorted {
+		largest[i].ReportFile = file
+		if info, err := os.Stat(filepath.Join(dir, filepath.FromSlash(file.Path))); err == nil {
+			modified := info.ModTime()
+			largest[i].Modified = &modified
+		}
+	}
+	return runStats{
+		Files:     report.Totals.Files,
+		Skipped:   skipped,
+		Bytes:     report.Totals.Bytes,
+		Tokens:    report.Totals.Tokens,
+		Tokenizer: report.Tokenizer,
+		Largest:   largest,
+	}
+}
+
+// statsSize returns a size of the summary, rounded to a unit or with raw exact
+func statsSize(bytes int, raw bool) string {
+	if raw {
+		return fmt.Sprintf("%d bytes", bytes)
+	}
+	return clip4llm.HumanSize(int64(bytes))
+}
+
+// printStats prints the summary as text, or as JSON for scripts on the
+// original standard output, where nothing else is printed then. Sizes and
+// times are rounded and relative to now unless raw.
+func printStats(stats runStats, asJSON bool, raw bool, now time.Time) error {
+	if asJSON {
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(payloadOut, string(data))
+		return err
+	}
+
+	fmt.Println("Summary:")
+	fmt.Printf("\tFiles included: %d\n", stats.Files)
+	reasons := make([]string, 0, len(stats.Skipped))
+	for reason := range stats.Skipped {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	if len(reasons) == 0 {
+		fmt.Println("\tFiles skipped: none")
+	} else {
+		fmt.Println("\tFiles skipped:")
+		for _, reason := range reasons {
+			fmt.Printf("\t\t%s: %d\n", reason, stats.Skipped[reason])
+		}
+	}
+	fmt.Printf("\tTotal size: %s\n", statsSize(stats.Bytes, raw))
+	fmt.Printf("\tEstimated tokens: %d (%s)\n", stats.Tokens, stats.Tokenizer)
+	if len(stats.Largest) > 0 {
+		fmt.Println("\tLargest files:")
+		for _, file := range stats.Largest {
+			details := statsSize(file.Bytes, raw)
+			switch {
+			case file.Modified == nil:
+			case raw:
+				details += ", modified " + file.Modified.Format(time.RFC3339)
+			default:
+				details += ", modified " + clip4llm.RelativeTime(*file.Modified, now)
+			}
+			fmt.Printf("\t\t%s (%s)\n", file.Path, details)
+		}
+	}
+	if stats.SizeTree != "" {
+		fmt.Println("\tSize heatmap:")
+		for _, line := range strings.Split(stats.SizeTree, "\n") {
+			fmt.Printf("\t\t%s\n", line)
+		}
+	}
+	if len(stats.Documents) > 0 {
+		words, minutes := 0, 0
+		for _, doc := range stats.Documents {
+			words += doc.Words
+			minutes += doc.ReadingMinutes
+		}
+		fmt.Printf("\tDocuments: %d words, ~%d min read\n", words, minutes)
+		for _, doc := range stats.Documents {
+			fmt.Printf("\t\t%s: %d words, ~%d tokens, ~%d min read\n", doc.Path, doc.Words, doc.Tokens, doc.ReadingMinutes)
+		}
+	}
+	return nil
+}
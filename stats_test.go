@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UnitVectorY-Labs/clip4llm/pkg/clip4llm"
+)
+
+func TestBuildStatsLargestFiles(t *testing.T) {
+	report := clip4llm.Report{Tokenizer: "cl100k_base", Totals: clip4llm.ReportTotals{Files: 6, Bytes: 210, Tokens: 50}}
+	for i, size := range []int{10, 60, 30, 50, 20, 40} {
+		report.Files = append(report.Files, clip4llm.ReportFile{Path: fmt.Sprintf("f%d", i), Bytes: size})
+	}
+	stats := buildStats(report, map[string]int{"binary": 2}, t.TempDir())
+	if len(stats.Largest) != statsLargestFiles {
+		t.Fatalf("Largest has %d files, want %d", len(stats.Largest), statsLargestFiles)
+	}
+	if stats.Largest[0].Path != "f1" || stats.Largest[4].Path != "f4" {
+		t.Errorf("Largest = %v, want f1 first and f4 last", stats.Largest)
+	}
+	if stats.Largest[0].Modified != nil {
+		t.Errorf("a missing file got modified %v", stats.Largest[0].Modified)
+	}
+	if stats.Files != 6 || stats.Skipped["binary"] != 2 || stats.Tokenizer != "cl100k_base" {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestDocumentsStats(t *testing.T) {
+	files := []clip4llm.File{{RelPath: "./README.md"}, {RelPath: "./docs/guide.md"}, {RelPath: "./main.go"}}
+	report := clip4llm.Report{Files: []clip4llm.ReportFile{{Path: "./README.md", Tokens: 7}, {Path: "./docs/guide.md", Tokens: 900}, {Path: "./main.go", Tokens: 3}}}
+	content := func(file clip4llm.File) ([]byte, error) {
+		if file.RelPath == "./docs/guide.md" {
+			return []byte(strings.Repeat("word ", 450)), nil
+		}
+		return []byte("# Title\n\nShort intro.\n"), nil
+	}
+
+	docs := documentsStats(files, report, content)
+	if len(docs) != 2 {
+		t.Fatalf("got %d documents, want 2", len(docs))
+	}
+	if docs[0].Words != 4 || docs[0].Tokens != 7 || docs[0].ReadingMinutes != 1 {
+		t.Errorf("README stats = %+v", docs[0])
+	}
+	if docs[1].Words != 450 || docs[1].ReadingMinutes != 3 {
+		t.Errorf("guide stats = %+v", docs[1])
+	}
+
+	if docs := documentsStats(files[1:], report, content); docs != nil {
+		t.Errorf("half documents should not count as documentation heavy, got %+v", docs)
+	}
+}
+
+func TestPrintStatsJSON(t *testing.T) {
+	defer func(out io.Writer) { payloadOut = out }(payloadOut)
+	var payload bytes.Buffer
+	payloadOut = &payload
+
+	stats := runStats{Files: 2, Skipped: map[string]int{"hidden": 1}, Bytes: 300, Tokens: 80, Tokenizer: "cl100k_base", SizeTree: "."}
+	if err := printStats(stats, true, false, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	var got runStats
+	if err := json.Unmarshal(payload.Bytes(), &got); err != nil {
+		t.Fatalf("stdout is not the JSON summary: %v\n%s", err, payload.String())
+	}
+	if got.Files != 2 || got.Skipped["hidden"] != 1 || got.Tokens != 80 || got.SizeTree != "" {
+		t.Errorf("summary = %+v", got)
+	}
+}
+
+func TestBuildStatsModified(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2024, 5, 1, 14, 3, 0, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(dir, "main.go"), modified, modified); err != nil {
+		t.Fatal(err)
+	}
+	report := clip4llm.Report{Files: []clip4llm.ReportFile{{Path: "./main.go", Bytes: 13}}}
+	stats := buildStats(report, nil, dir)
+	if len(stats.Largest) != 1 || stats.Largest[0].Modified == nil || !stats.Largest[0].Modified.Equal(modified) {
+		t.Fatalf("Largest = %+v, want main.go modified %v", stats.Largest, modified)
+	}
+}
+
+func TestStatsSize(t *testing.T) {
+	if got := statsSize(2355, false); got != "2.3 KB" {
+		t.Errorf("statsSize = %q, want 2.3 KB", got)
+	}
+	if got := statsSize(2355, true); got != "2355 bytes" {
+		t.Errorf("raw statsSize = %q, want 2355 bytes", got)
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2024 UnitVectorY Labs
+// Licensed under the MIT License. See LICENSE file in the project root for full license information.
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Quiet period after the last change before the output is rebuilt, so saving
+// many files at once or an editor's write and rename triggers one rebuild
+const watchDebounce = 300 * time.Millisecond
+
+// Version control metadata changes on every status check, it is never watched
+var unwatchedDirs = map[string]bool{".git": true, ".hg": true, ".svn": true}
+
+// watchFiles calls rebuild whenever files below dir or one of the configs
+// change, once the changes have settled, until the watcher stops. rebuild is
+// told whether a config changed so it can be reloaded first. Changes to the
+// skipped paths, such as the output file, are ignored so writing them does
+// not trigger another rebuild.
+func watchFiles(dir string, skip []string, configs []string, verbose bool, rebuild func(reload bool)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := watchTree(watcher, dir, verbose); err != nil {
+		return err
+	}
+
+	// A config outside of dir, such as ~/.clip4llm, is watched through its
+	// directory, where every other change is ignored
+	watchedConfigs := make(map[string]bool)
+	for _, path := range configs {
+		watchedConfigs[path] = true
+		if !isWithin(dir, path) {
+			if err := watcher.Add(filepath.Dir(path)); err != nil && verbose {
+				fmt.Printf("Failed to watch %s: %v\n", path, err)
+			}
+		}
+	}
+
+	skipped := make(map[string]bool)
+	for _, path := range skip {
+		skipped[path] = true
+	}
+	return watchEvents(watcher, dir, skipped, watchedConfigs, verbose, rebuild)
+}
+
+// watchEvents handles the events of the watcher until it is closed, calling
+// rebuild once the changes have settled for watchDebounce
+func watchEvents(watcher *fsnotify.Watcher, dir string, skipped map[string]bool, configs map[string]bool, verbose bool, rebuild func(reload bool)) error {
+	var pending <-chan time.Time
+	reload := false
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			// Touching permissions or timestamps does not change the output
+			if skipped[event.Name] || unwatchedDirs[filepath.Base(event.Name)] || event.Op == fsnotify.Chmod {
+				continue
+			}
+			config := configs[event.Name]
+			if !config && !isWithin(dir, event.Name) {
+				continue
+			}
+			// New directories are not watched until they are added
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watchTree(watcher, event.Name, verbose); err != nil && verbose {
+						fmt.Printf("Failed to watch %s: %v\n", event.Name, err)
+					}
+				}
+			}
+			if verbose {
+				fmt.Printf("Changed: %s\n", event.Name)
+			}
+			reload = reload || config
+			pending = time.After(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Println("Watch error:", err)
+		case <-pending:
+			pending = nil
+			rebuild(reload)
+			reload = false
+		}
+	}
+}
+
+// isWithin reports whether path is dir or below it
+func isWithin(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// watchTree adds root and every directory below it to the watcher
+func watchTree(watcher *fsnotify.Watcher, root string, verbose bool) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// A directory removed while walking is not worth stopping for
+			if verbose {
+				fmt.Printf("Not watching %s: %v\n", path, err)
+			}
+			return nil
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if unwatchedDirs[entry.Name()] {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
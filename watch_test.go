@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestWatchEvents(t *testing.T) {
+	dir := t.TempDir()
+	home := t.TempDir()
+	config := filepath.Join(home, ".clip4llm")
+	output := filepath.Join(dir, "out.txt")
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := watchTree(watcher, dir, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := watcher.Add(home); err != nil {
+		t.Fatal(err)
+	}
+
+	rebuilds := make(chan bool, 10)
+	done := make(chan error)
+	go func() {
+		done <- watchEvents(watcher, dir, map[string]bool{output: true}, map[string]bool{config: true}, false, func(reload bool) {
+			rebuilds <- reload
+		})
+	}()
+
+	write := func(path string) {
+		if err := os.WriteFile(path, []byte(path), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	next := func() (bool, bool) {
+		select {
+		case reload := <-rebuilds:
+			return reload, true
+		case <-time.After(watchDebounce * 5):
+			return false, false
+		}
+	}
+
+	// A burst of changes settles into one rebuild
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		write(filepath.Join(dir, name))
+	}
+	if reload, ok := next(); !ok || reload {
+		t.Errorf("after changing files got rebuild=%v reload=%v, want one rebuild without reload", ok, reload)
+	}
+	if _, ok := next(); ok {
+		t.Error("a burst of changes rebuilt more than once")
+	}
+
+	// Neither the output nor other files next to the config rebuild
+	write(output)
+	write(filepath.Join(home, "notes.txt"))
+	if _, ok := next(); ok {
+		t.Error("changing the output or an unrelated file rebuilt")
+	}
+
+	// The config reloads before rebuilding
+	write(config)
+	if reload, ok := next(); !ok || !reload {
+		t.Errorf("after changing the config got rebuild=%v reload=%v, want a reload", ok, reload)
+	}
+
+	watcher.Close()
+	if err := <-done; err != nil {
+		t.Errorf("watchEvents returned %v", err)
+	}
+}